@@ -2,27 +2,51 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"kiro-manager/awssso"
 	"kiro-manager/backup"
+	"kiro-manager/datadir"
+	"kiro-manager/httpclient"
 	"kiro-manager/kiropath"
 	"kiro-manager/kiroprocess"
 	"kiro-manager/kiroversion"
+	"kiro-manager/logging"
 	"kiro-manager/machineid"
+	"kiro-manager/report"
 	"kiro-manager/settings"
 	"kiro-manager/softreset"
+	"kiro-manager/sysenv"
 	"kiro-manager/tokenrefresh"
 	"kiro-manager/usage"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
 	ctx context.Context
+
+	// refreshMu 保護 refreshCancel（批次刷新進行中時非 nil）
+	refreshMu     sync.Mutex
+	refreshCancel context.CancelFunc
+
+	// watchCancel 停止監看 kiro-auth-token.json
+	watchCancel context.CancelFunc
+
+	// launchMu 保護 pendingLaunch（關閉 Kiro 前記錄、操作成功後用於重新啟動）
+	launchMu      sync.Mutex
+	pendingLaunch *kiroprocess.LaunchState
 }
 
 // NewApp creates a new App application struct
@@ -35,19 +59,60 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	// 不再於啟動時自動備份，避免觸發防毒軟體誤報
 	// 改為在用戶首次執行需要備份的操作時才觸發
+
+	// 若上次切換帳號途中中斷，回滾至切換前的登入狀態
+	if recovered, err := backup.RecoverInterruptedRestore(); err != nil {
+		logging.Error("failed to recover interrupted restore", "err", err)
+	} else if recovered {
+		logging.Warn("rolled back an interrupted restore")
+	}
+
+	// 將舊版明文備份加密（僅執行一次；密語模式會在解鎖後執行）
+	if n, err := backup.MigratePlaintextBackups(); err != nil {
+		logging.Warn("failed to encrypt legacy backups", "err", err)
+	} else if n > 0 {
+		logging.Info("encrypted legacy backups", "files", n)
+	}
+
+	// 監看目前登入的帳號，Kiro 內登入或切換帳號時即時通知前端
+	a.startTokenWatcher(ctx)
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	if a.watchCancel != nil {
+		a.watchCancel()
+	}
+}
+
+// context 取得 Wails 的應用程式 context（CLI 模式下為 context.Background）
+func (a *App) context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
+}
+
+// emit 發送 Wails 事件（CLI 模式下沒有前端，直接忽略）
+func (a *App) emit(event string, data interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, event, data)
 }
 
 // BackupItem 備份項目（前端用）
 type BackupItem struct {
-	Name              string  `json:"name"`
-	BackupTime        string  `json:"backupTime"`
-	HasToken          bool    `json:"hasToken"`
-	HasMachineID      bool    `json:"hasMachineId"`
-	MachineID         string  `json:"machineId"`
-	Provider          string  `json:"provider"`
-	IsCurrent         bool    `json:"isCurrent"`
-	IsOriginalMachine bool    `json:"isOriginalMachine"` // Machine ID 與原始機器相同
-	IsTokenExpired    bool    `json:"isTokenExpired"`    // Token 是否已過期
+	Name              string `json:"name"`
+	BackupTime        string `json:"backupTime"`
+	HasToken          bool   `json:"hasToken"`
+	HasMachineID      bool   `json:"hasMachineId"`
+	MachineID         string `json:"machineId"`
+	Provider          string `json:"provider"`
+	IsCurrent         bool   `json:"isCurrent"`
+	IsOriginalMachine bool   `json:"isOriginalMachine"` // Machine ID 與原始機器相同
+	IsTokenExpired    bool   `json:"isTokenExpired"`    // Token 是否已過期
+	TokenExpiresAt    string `json:"tokenExpiresAt"`    // Token 到期時間（RFC3339）
 	// Usage 相關欄位 (Requirements: 1.1, 1.2)
 	SubscriptionTitle string  `json:"subscriptionTitle"` // 訂閱類型名稱
 	UsageLimit        float64 `json:"usageLimit"`        // 總額度
@@ -55,16 +120,60 @@ type BackupItem struct {
 	Balance           float64 `json:"balance"`           // 餘額
 	IsLowBalance      bool    `json:"isLowBalance"`      // 餘額低於 20%
 	CachedAt          string  `json:"cachedAt"`          // 緩存時間（用於前端判斷冷卻期）
+	// Breakdown 各額度來源（基本額度 / 免費試用 / 獎勵）的明細
+	Breakdown []usage.BreakdownItem `json:"breakdown"`
+	// 使用者描述資料（meta.json）
+	DisplayName string   `json:"displayName"`
+	Email       string   `json:"email"`
+	Tags        []string `json:"tags"`
+	Notes       string   `json:"notes"`
 }
 
 // Result 通用回傳結果
 type Result struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"errorCode,omitempty"` // 需要前端特別處理的失敗原因（kiroStillRunning）
+}
+
+// 備份列表的排序欄位
+const (
+	SortByName       = "name"
+	SortByBackupTime = "backupTime"
+	SortByTag        = "tag"
+	SortByProvider   = "provider"
+	SortByExpiry     = "expiry"
+	SortByBalance    = "balance"
+)
+
+// 備份列表的 Token 狀態篩選
+const (
+	TokenStateValid   = "valid"
+	TokenStateExpired = "expired"
+)
+
+// BackupQuery 備份列表的篩選與排序條件（零值表示不篩選、依名稱排序）
+type BackupQuery struct {
+	Search         string   `json:"search"`         // 比對名稱、顯示名稱、Email 與標籤（不分大小寫）
+	Tags           []string `json:"tags"`           // 必須包含全部標籤
+	Provider       string   `json:"provider"`       // 登入方式，例如 Github、Google、BuilderId
+	TokenState     string   `json:"tokenState"`     // valid / expired，空字串為全部
+	LowBalanceOnly bool     `json:"lowBalanceOnly"` // 只顯示低餘額備份
+	SortBy         string   `json:"sortBy"`         // name / backupTime / tag / provider / expiry / balance
+	Desc           bool     `json:"desc"`           // 反向排序
 }
 
-// GetBackupList 取得備份列表
-func (a *App) GetBackupList() ([]BackupItem, error) {
+// GetBackupList 取得備份列表，依 query 篩選與排序
+func (a *App) GetBackupList(query BackupQuery) ([]BackupItem, error) {
+	items, err := a.listBackupItems()
+	if err != nil {
+		return nil, err
+	}
+	return filterBackupItems(items, query)
+}
+
+// listBackupItems 讀取所有備份（不含 original）的列表資料
+func (a *App) listBackupItems() ([]BackupItem, error) {
 	backups, err := backup.ListBackups()
 	if err != nil {
 		return nil, err
@@ -90,6 +199,10 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 			Name:         b.Name,
 			HasToken:     b.HasToken,
 			HasMachineID: b.HasMachineID,
+			DisplayName:  b.Meta.DisplayName,
+			Email:        b.Meta.Email,
+			Tags:         b.Meta.Tags,
+			Notes:        b.Meta.Notes,
 		}
 
 		if !b.BackupTime.IsZero() {
@@ -114,6 +227,7 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 				}
 				// 檢查 token 是否已過期
 				item.IsTokenExpired = awssso.IsTokenExpired(token)
+				item.TokenExpiresAt = token.ExpiresAtString()
 			}
 		}
 
@@ -123,6 +237,7 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 			item.UsageLimit = usageCache.UsageLimit
 			item.CurrentUsage = usageCache.CurrentUsage
 			item.Balance = usageCache.Balance
+			item.Breakdown = usageCache.Breakdown
 			// 使用設定的閾值重新計算 IsLowBalance
 			threshold := settings.GetLowBalanceThreshold()
 			if usageCache.UsageLimit > 0 {
@@ -141,6 +256,187 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 	return items, nil
 }
 
+// filterBackupItems 依查詢條件篩選並排序備份列表
+// 排序欄位相同時依名稱排序；缺少排序值（無標籤、無餘額緩存等）的備份一律排在最後
+func filterBackupItems(items []BackupItem, q BackupQuery) ([]BackupItem, error) {
+	switch q.TokenState {
+	case "", TokenStateValid, TokenStateExpired:
+	default:
+		return nil, fmt.Errorf("無效的 Token 狀態: %s", q.TokenState)
+	}
+
+	sorter, err := backupItemSorter(q.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	search := strings.ToLower(strings.TrimSpace(q.Search))
+	tags := backup.NormalizeTags(q.Tags)
+
+	result := []BackupItem{}
+	for _, item := range items {
+		if search != "" && !backupItemMatches(item, search) {
+			continue
+		}
+		if !hasAllTags(item.Tags, tags) {
+			continue
+		}
+		if q.Provider != "" && !strings.EqualFold(item.Provider, q.Provider) {
+			continue
+		}
+		if q.TokenState == TokenStateValid && item.IsTokenExpired || q.TokenState == TokenStateExpired && !item.IsTokenExpired {
+			continue
+		}
+		if q.LowBalanceOnly && !item.IsLowBalance {
+			continue
+		}
+		result = append(result, item)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		// 缺少排序值的備份不受 Desc 影響，一律排在最後
+		if hasA, hasB := sorter.has(a), sorter.has(b); hasA != hasB {
+			return hasA
+		}
+		if c := sorter.compare(a, b); c != 0 {
+			if q.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return result, nil
+}
+
+// backupSorter 單一排序欄位的比較方式
+type backupSorter struct {
+	has     func(item BackupItem) bool // 備份是否有此欄位的值
+	compare func(a, b BackupItem) int  // 兩者都有值時的比較結果（-1 / 0 / 1）
+}
+
+// always 用於所有備份都有值的欄位
+func always(BackupItem) bool { return true }
+
+// backupItemSorter 返回指定欄位的排序方式
+func backupItemSorter(sortBy string) (backupSorter, error) {
+	switch sortBy {
+	case "", SortByName:
+		return backupSorter{always, func(a, b BackupItem) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}}, nil
+	case SortByBackupTime:
+		return backupSorter{
+			func(item BackupItem) bool { return item.BackupTime != "" },
+			func(a, b BackupItem) int { return strings.Compare(a.BackupTime, b.BackupTime) },
+		}, nil
+	case SortByTag:
+		return backupSorter{
+			func(item BackupItem) bool { return len(item.Tags) > 0 },
+			func(a, b BackupItem) int { return strings.Compare(firstTag(a), firstTag(b)) },
+		}, nil
+	case SortByProvider:
+		return backupSorter{
+			func(item BackupItem) bool { return item.Provider != "" },
+			func(a, b BackupItem) int {
+				return strings.Compare(strings.ToLower(a.Provider), strings.ToLower(b.Provider))
+			},
+		}, nil
+	case SortByExpiry:
+		return backupSorter{
+			func(item BackupItem) bool { _, ok := parseTokenExpiry(item.TokenExpiresAt); return ok },
+			func(a, b BackupItem) int {
+				ta, _ := parseTokenExpiry(a.TokenExpiresAt)
+				tb, _ := parseTokenExpiry(b.TokenExpiresAt)
+				return ta.Compare(tb)
+			},
+		}, nil
+	case SortByBalance:
+		return backupSorter{
+			func(item BackupItem) bool { return item.CachedAt != "" },
+			func(a, b BackupItem) int {
+				switch {
+				case a.Balance < b.Balance:
+					return -1
+				case a.Balance > b.Balance:
+					return 1
+				}
+				return 0
+			},
+		}, nil
+	}
+	return backupSorter{}, fmt.Errorf("無效的排序欄位: %s", sortBy)
+}
+
+// firstTag 返回第一個標籤（小寫），用於依標籤排序
+func firstTag(item BackupItem) string {
+	if len(item.Tags) == 0 {
+		return ""
+	}
+	return strings.ToLower(item.Tags[0])
+}
+
+// parseTokenExpiry 解析 Token 到期時間
+func parseTokenExpiry(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// backupItemMatches 檢查備份的名稱、顯示名稱、Email 或標籤是否包含搜尋字串（search 需為小寫）
+func backupItemMatches(item BackupItem, search string) bool {
+	fields := append([]string{item.Name, item.DisplayName, item.Email}, item.Tags...)
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllTags 檢查 tags 是否包含 want 中的全部標籤（不分大小寫）
+func hasAllTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, t := range tags {
+			if strings.EqualFold(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateBackupMeta 更新備份的顯示名稱、Email、標籤與備註
+func (a *App) UpdateBackupMeta(name string, meta backup.BackupMeta) Result {
+	if name == "" {
+		return Result{Success: false, Message: "備份名稱不能為空"}
+	}
+
+	if err := backup.WriteBackupMeta(name, &meta); err != nil {
+		switch {
+		case errors.Is(err, backup.ErrBackupNotFound):
+			return Result{Success: false, Message: "備份不存在"}
+		case errors.Is(err, backup.ErrInvalidMeta):
+			return Result{Success: false, Message: fmt.Sprintf("描述資料無效: %v", err)}
+		}
+		return Result{Success: false, Message: fmt.Sprintf("儲存描述資料失敗: %v", err)}
+	}
+
+	logging.Info("backup metadata updated", "name", name)
+	return Result{Success: true, Message: "描述資料已更新"}
+}
+
 // UsageCacheResult 餘額刷新結果
 type UsageCacheResult struct {
 	Success           bool    `json:"success"`
@@ -152,11 +448,19 @@ type UsageCacheResult struct {
 	IsLowBalance      bool    `json:"isLowBalance"`
 	IsTokenExpired    bool    `json:"isTokenExpired"` // Token 是否已過期（刷新成功後為 false）
 	CachedAt          string  `json:"cachedAt"`       // 緩存時間（用於前端判斷冷卻期）
+	ErrorCode         string  `json:"errorCode"`      // 失敗原因分類（unauthorized / rateLimited / serverUnavailable / network）
+	// Breakdown 各額度來源的明細（僅刷新成功時）
+	Breakdown []usage.BreakdownItem `json:"breakdown"`
 }
 
 // RefreshBackupUsage 刷新指定備份的餘額資訊
 // 需求: 1.1, 1.2, 1.3, 1.4, 1.5
 func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
+	return refreshBackupUsage(a.context(), name)
+}
+
+// refreshBackupUsage 刷新指定備份的餘額資訊，ctx 取消時中止進行中的請求
+func refreshBackupUsage(ctx context.Context, name string) UsageCacheResult {
 	if name == "" {
 		return UsageCacheResult{Success: false, Message: "備份名稱不能為空"}
 	}
@@ -181,44 +485,24 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 	// 檢查 token 是否已過期（需求 1.1）
 	if awssso.IsTokenExpired(token) {
 		// 嘗試刷新 Token（需求 1.1, 1.2, 1.3）
-		// 使用對應環境快照的 Machine ID 的 SHA256 雜湊值
-		var newTokenInfo *tokenrefresh.TokenInfo
-		var err error
-
-		// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
-		authType := tokenrefresh.DetectAuthType(token)
-		if authType == "idc" && token.ClientIdHash != "" {
-			// 從備份目錄讀取 IdC credentials
-			clientID, clientSecret, credErr := backup.ReadBackupIdCCredentials(name, token.ClientIdHash)
-			if credErr != nil {
-				return UsageCacheResult{Success: false, Message: "無法讀取 IdC 認證資訊: " + credErr.Error()}
+		// 刷新失敗，返回錯誤（需求 1.5）
+		if err := refreshBackupToken(ctx, name, token, hashedMachineID); err != nil {
+			logging.Warn("failed to refresh backup token", "backup", name, "err", err, "cause", errors.Unwrap(err))
+			code := apiErrorCode(err)
+			if code == "unauthorized" {
+				// Refresh Token 本身已失效，只能重新登入
+				code = "refreshTokenExpired"
 			}
-			newTokenInfo, err = tokenrefresh.RefreshAccessTokenFromBackup(token, hashedMachineID, clientID, clientSecret)
-		} else {
-			// Social 認證或其他情況，使用原有邏輯
-			newTokenInfo, err = tokenrefresh.RefreshAccessToken(token, hashedMachineID)
-		}
-
-		if err != nil {
-			// 刷新失敗，返回錯誤（需求 1.5）
-			return UsageCacheResult{Success: false, Message: err.Error()}
-		}
-
-		// 更新 token 結構的新值（需求 1.2, 1.3）
-		token.AccessToken = newTokenInfo.AccessToken
-		token.ExpiresAt = newTokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
-
-		// 呼叫 WriteBackupToken() 持久化刷新後的 token（需求 3.1, 3.2）
-		if err := backup.WriteBackupToken(name, token.AccessToken, token.ExpiresAt); err != nil {
-			return UsageCacheResult{Success: false, Message: "Token 刷新成功但寫入失敗: " + err.Error()}
+			return UsageCacheResult{Success: false, Message: err.Error(), ErrorCode: code}
 		}
 	}
 
 	// 呼叫 API 取得用量資訊（需求 1.4）
 	// hashedMachineID 已在上方計算
-	usageInfo, err := usage.GetUsageLimitsWithMachineID(token, hashedMachineID)
+	usageInfo, err := usage.DefaultClient.GetUsageLimitsWithMachineID(ctx, token, hashedMachineID)
 	if err != nil {
-		return UsageCacheResult{Success: false, Message: fmt.Sprintf("API 呼叫失敗: %v", err)}
+		logging.Warn("failed to refresh backup usage", "backup", name, "err", err)
+		return UsageCacheResult{Success: false, Message: apiErrorMessage(err), ErrorCode: apiErrorCode(err)}
 	}
 
 	if usageInfo == nil || usageInfo.SubscriptionTitle == "" {
@@ -239,6 +523,7 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 		CurrentUsage:      usageInfo.CurrentUsage,
 		Balance:           usageInfo.Balance,
 		IsLowBalance:      isLowBalance,
+		Breakdown:         usageInfo.Breakdown,
 	}
 	if err := backup.WriteUsageCache(name, cache); err != nil {
 		return UsageCacheResult{Success: false, Message: fmt.Sprintf("緩存寫入失敗: %v", err)}
@@ -257,7 +542,313 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 		IsLowBalance:      isLowBalance,
 		IsTokenExpired:    false, // 刷新成功代表 token 有效
 		CachedAt:          cachedAt,
+		Breakdown:         usageInfo.Breakdown,
+	}
+}
+
+// refreshBackupToken 刷新備份的 Token 並寫回備份，成功後會更新傳入的 token
+// 使用對應環境快照的 Machine ID 的 SHA256 雜湊值
+func refreshBackupToken(ctx context.Context, name string, token *awssso.KiroAuthToken, hashedMachineID string) error {
+	var clientID, clientSecret string
+
+	// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
+	authType := tokenrefresh.DetectAuthType(token)
+	if authType == "idc" {
+		// 從備份目錄讀取 IdC credentials
+		var credErr error
+		clientID, clientSecret, credErr = backup.ReadBackupIdCCredentials(name, token)
+		// 沒有 clientIdHash 的舊備份找不到時，沿用 tokenrefresh 從 SSO cache 讀取的行為
+		if credErr != nil && token.ClientIdHash != "" {
+			return fmt.Errorf("無法讀取 IdC 認證資訊: %w", credErr)
+		}
+	}
+	// Social 認證或其他情況 clientID/clientSecret 為空，由 tokenrefresh 依認證類型處理
+	newTokenInfo, err := tokenrefresh.DefaultClient.RefreshAccessTokenWithCredentials(ctx, token, hashedMachineID, clientID, clientSecret)
+
+	if err != nil {
+		return err
 	}
+
+	// 更新 token 結構的新值（需求 1.2, 1.3）
+	token.AccessToken = newTokenInfo.AccessToken
+	token.ExpiresAt = newTokenInfo.ExpiresAt
+
+	// 呼叫 WriteBackupToken() 持久化刷新後的 token（需求 3.1, 3.2）
+	if err := backup.WriteBackupToken(name, token); err != nil {
+		return fmt.Errorf("Token 刷新成功但寫入失敗: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// 批次刷新餘額
+// ============================================================================
+
+const (
+	// EventUsageRefreshProgress 批次刷新中每個備份完成時發送的事件（資料為 RefreshProgress）
+	EventUsageRefreshProgress = "usage:refresh-progress"
+	// maxRefreshWorkers 批次刷新同時進行的請求數
+	maxRefreshWorkers = 4
+)
+
+// BackupRefreshResult 單一備份的刷新結果
+type BackupRefreshResult struct {
+	Name   string           `json:"name"`
+	Result UsageCacheResult `json:"result"`
+}
+
+// RefreshProgress 批次刷新進度
+type RefreshProgress struct {
+	Name   string           `json:"name"`
+	Done   int              `json:"done"`  // 已完成數量
+	Total  int              `json:"total"` // 總數量
+	Result UsageCacheResult `json:"result"`
+}
+
+// RefreshAllReport 批次刷新結果彙總
+type RefreshAllReport struct {
+	Success   bool                  `json:"success"`
+	Message   string                `json:"message"`
+	Total     int                   `json:"total"`
+	Succeeded []string              `json:"succeeded"` // 刷新成功
+	Failed    []string              `json:"failed"`    // 刷新失敗（不含 Refresh Token 失效）
+	Expired   []string              `json:"expired"`   // Refresh Token 已失效，需重新登入
+	Canceled  []string              `json:"canceled"`  // 取消時尚未完成
+	Results   []BackupRefreshResult `json:"results"`   // 依備份順序排列的個別結果
+}
+
+// refreshableBackups 取得可刷新餘額的備份（排除原始備份與沒有 Token 的備份）
+func refreshableBackups() ([]string, error) {
+	backups, err := backup.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, b := range backups {
+		if b.Name != backup.OriginalBackupName && b.HasToken {
+			names = append(names, b.Name)
+		}
+	}
+	return names, nil
+}
+
+// refreshAll 以最多 workers 個併發刷新 names 的餘額
+// 每完成一個備份呼叫一次 onProgress；ctx 取消後尚未開始的備份會標記為已取消
+func refreshAll(ctx context.Context, names []string, workers int, refresh func(context.Context, string) UsageCacheResult, onProgress func(RefreshProgress)) RefreshAllReport {
+	results := make([]UsageCacheResult, len(names))
+	canceled := make([]bool, len(names))
+	for i := range canceled {
+		canceled[i] = true
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	jobs := make(chan int)
+	for w := 0; w < min(workers, len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				result := refresh(ctx, names[i])
+				// 請求因取消而失敗時視為已取消，而不是刷新失敗
+				if !result.Success && ctx.Err() != nil {
+					continue
+				}
+
+				mu.Lock()
+				results[i] = result
+				canceled[i] = false
+				done++
+				progress := RefreshProgress{Name: names[i], Done: done, Total: len(names), Result: result}
+				if onProgress != nil {
+					onProgress(progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i := range names {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := RefreshAllReport{
+		Total:     len(names),
+		Succeeded: []string{},
+		Failed:    []string{},
+		Expired:   []string{},
+		Canceled:  []string{},
+		Results:   make([]BackupRefreshResult, len(names)),
+	}
+	for i, name := range names {
+		result := results[i]
+		switch {
+		case canceled[i]:
+			result = UsageCacheResult{Success: false, Message: "已取消", ErrorCode: "canceled"}
+			report.Canceled = append(report.Canceled, name)
+		case result.Success:
+			report.Succeeded = append(report.Succeeded, name)
+		case result.ErrorCode == "refreshTokenExpired":
+			report.Expired = append(report.Expired, name)
+		default:
+			report.Failed = append(report.Failed, name)
+		}
+		report.Results[i] = BackupRefreshResult{Name: name, Result: result}
+	}
+
+	report.Success = len(report.Failed) == 0 && len(report.Expired) == 0 && len(report.Canceled) == 0
+	report.Message = fmt.Sprintf("成功 %d 個，失敗 %d 個，Refresh Token 失效 %d 個",
+		len(report.Succeeded), len(report.Failed), len(report.Expired))
+	if len(report.Canceled) > 0 {
+		report.Message += fmt.Sprintf("，已取消 %d 個", len(report.Canceled))
+	}
+	return report
+}
+
+// RefreshAllBackupUsage 批次刷新所有備份的餘額
+// 每完成一個備份發送 EventUsageRefreshProgress 事件，可用 CancelRefreshAll 取消
+func (a *App) RefreshAllBackupUsage() RefreshAllReport {
+	ctx, cancel := context.WithCancel(a.context())
+	defer cancel()
+
+	a.refreshMu.Lock()
+	if a.refreshCancel != nil {
+		a.refreshMu.Unlock()
+		return RefreshAllReport{Success: false, Message: "批次刷新正在進行中"}
+	}
+	a.refreshCancel = cancel
+	a.refreshMu.Unlock()
+
+	defer func() {
+		a.refreshMu.Lock()
+		a.refreshCancel = nil
+		a.refreshMu.Unlock()
+	}()
+
+	names, err := refreshableBackups()
+	if err != nil {
+		return RefreshAllReport{Success: false, Message: fmt.Sprintf("無法讀取備份列表: %v", err)}
+	}
+
+	report := refreshAll(ctx, names, maxRefreshWorkers, refreshBackupUsage, func(p RefreshProgress) {
+		a.emit(EventUsageRefreshProgress, p)
+	})
+	logging.Info("batch usage refresh finished", "total", report.Total, "succeeded", len(report.Succeeded),
+		"failed", len(report.Failed), "expired", len(report.Expired), "canceled", len(report.Canceled))
+	return report
+}
+
+// CancelRefreshAll 取消進行中的批次刷新
+func (a *App) CancelRefreshAll() Result {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+	if a.refreshCancel == nil {
+		return Result{Success: false, Message: "沒有進行中的批次刷新"}
+	}
+	a.refreshCancel()
+	return Result{Success: true, Message: "正在取消批次刷新"}
+}
+
+// isRefreshingAll 批次刷新是否進行中（進行中時備份目錄會被寫入，不可改名）
+func (a *App) isRefreshingAll() bool {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+	return a.refreshCancel != nil
+}
+
+// usageTrends 分析 names 的用量歷史
+func usageTrends(names []string, days int, now time.Time) ([]report.Trend, error) {
+	trends := make([]report.Trend, 0, len(names))
+	for _, name := range names {
+		entries, err := backup.ReadUsageHistory(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		trends = append(trends, report.Analyze(name, entries, now, days))
+	}
+	return trends, nil
+}
+
+// allBackupNames 取得所有備份名稱
+func allBackupNames() ([]string, error) {
+	backups, err := backup.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(backups))
+	for _, b := range backups {
+		names = append(names, b.Name)
+	}
+	return names, nil
+}
+
+// GetUsageTrend 取得備份最近 days 天的用量趨勢（days <= 0 時使用預設天數）
+func (a *App) GetUsageTrend(name string, days int) (report.Trend, error) {
+	trends, err := usageTrends([]string{name}, days, sysenv.Now())
+	if err != nil {
+		return report.Trend{}, err
+	}
+	return trends[0], nil
+}
+
+// GetUsageTrends 取得所有備份最近 days 天的用量趨勢
+func (a *App) GetUsageTrends(days int) ([]report.Trend, error) {
+	names, err := allBackupNames()
+	if err != nil {
+		return nil, err
+	}
+	return usageTrends(names, days, sysenv.Now())
+}
+
+// ExportUsageCSV 將所有備份最近 days 天的每日用量匯出為 CSV
+func (a *App) ExportUsageCSV(days int) Result {
+	names, err := allBackupNames()
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法讀取備份列表: %v", err)}
+	}
+	trends, err := usageTrends(names, days, sysenv.Now())
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法讀取用量歷史: %v", err)}
+	}
+
+	defaultName := fmt.Sprintf("kiro-usage-%s.csv", sysenv.Now().Format("20060102"))
+	savePath, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "匯出用量報表",
+		DefaultFilename: defaultName,
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "CSV (*.csv)", Pattern: "*.csv"},
+		},
+	})
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法開啟儲存對話框: %v", err)}
+	}
+	if savePath == "" {
+		return Result{Success: false, Message: "已取消匯出"}
+	}
+
+	f, err := os.OpenFile(savePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出用量報表失敗: %v", err)}
+	}
+	defer f.Close()
+
+	if err := report.WriteCSV(f, trends); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出用量報表失敗: %v", err)}
+	}
+	return Result{Success: true, Message: savePath}
 }
 
 // CreateBackup 建立新備份
@@ -267,9 +858,11 @@ func (a *App) CreateBackup(name string) Result {
 	}
 
 	if err := backup.CreateBackup(name); err != nil {
-		return Result{Success: false, Message: err.Error()}
+		logging.Error("failed to create backup", "backup", name, "err", err)
+		return Result{Success: false, Message: backupNameErrorMessage(err)}
 	}
 
+	logging.Info("backup created", "backup", name)
 	return Result{Success: true, Message: "備份成功"}
 }
 
@@ -279,25 +872,53 @@ func (a *App) SwitchToBackup(name string) Result {
 		return Result{Success: false, Message: "請選擇備份"}
 	}
 
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	// 關閉 Kiro（先要求正常結束，逾時需使用者確認才會強制關閉）
+	a.captureKiroLaunch()
+	if result := a.ensureKiroClosed(); result != nil {
+		return *result
 	}
 
 	if err := backup.RestoreBackup(name); err != nil {
-		return Result{Success: false, Message: fmt.Sprintf("恢復 Token 失敗: %v", err)}
+		logging.Error("failed to switch backup", "backup", name, "err", err)
+		return Result{Success: false, Message: restoreErrorMessage(err)}
 	}
 
+	logging.Info("switched to backup", "backup", name)
 	return Result{Success: true, Message: "切換成功"}
 }
 
+// restoreErrorMessage 依恢復失敗的階段產生提示訊息
+func restoreErrorMessage(err error) string {
+	var restoreErr *backup.RestoreError
+	if !errors.As(err, &restoreErr) {
+		return fmt.Sprintf("恢復 Token 失敗: %v", err)
+	}
 
+	var stage string
+	switch restoreErr.Stage {
+	case backup.RestoreStagePrepare:
+		stage = "讀取備份"
+	case backup.RestoreStageSnapshot:
+		stage = "快照目前登入"
+	case backup.RestoreStageWrite:
+		stage = "寫入暫存檔"
+	case backup.RestoreStageCommit:
+		stage = "替換檔案"
+	default:
+		stage = string(restoreErr.Stage)
+	}
+
+	message := fmt.Sprintf("恢復 Token 失敗（%s階段）: %v", stage, restoreErr.Err)
+	switch {
+	case restoreErr.Stage != backup.RestoreStageCommit:
+		message += "，目前的登入狀態未受影響"
+	case restoreErr.RolledBack:
+		message += "，已自動回滾至切換前的登入狀態"
+	default:
+		message += fmt.Sprintf("，回滾失敗: %v（下次啟動時將再次嘗試回滾）", restoreErr.RollbackErr)
+	}
+	return message
+}
 
 // DeleteBackup 刪除備份
 func (a *App) DeleteBackup(name string) Result {
@@ -312,6 +933,229 @@ func (a *App) DeleteBackup(name string) Result {
 	return Result{Success: true, Message: "刪除成功"}
 }
 
+// RenameBackup 重新命名備份
+func (a *App) RenameBackup(oldName, newName string) Result {
+	newName = strings.TrimSpace(newName)
+	if oldName == backup.OriginalBackupName {
+		return Result{Success: false, Message: "不能重新命名原始備份"}
+	}
+	if a.isRefreshingAll() {
+		return Result{Success: false, Message: "批次刷新進行中，請稍後再試"}
+	}
+
+	if err := backup.RenameBackup(oldName, newName); err != nil {
+		logging.Error("failed to rename backup", "backup", oldName, "newName", newName, "err", err)
+		return Result{Success: false, Message: backupNameErrorMessage(err)}
+	}
+
+	logging.Info("backup renamed", "backup", oldName, "newName", newName)
+	return Result{Success: true, Message: "重新命名成功"}
+}
+
+// CloneBackup 複製備份為新名稱
+func (a *App) CloneBackup(srcName, dstName string) Result {
+	dstName = strings.TrimSpace(dstName)
+	if srcName == backup.OriginalBackupName {
+		return Result{Success: false, Message: "不能複製原始備份"}
+	}
+
+	if err := backup.CloneBackup(srcName, dstName); err != nil {
+		logging.Error("failed to clone backup", "backup", srcName, "newName", dstName, "err", err)
+		return Result{Success: false, Message: backupNameErrorMessage(err)}
+	}
+
+	logging.Info("backup cloned", "backup", srcName, "newName", dstName)
+	return Result{Success: true, Message: "複製成功"}
+}
+
+// backupNamePunctuationHint 提示訊息中列出的允許符號
+const backupNamePunctuationHint = "- _ . @ + ( ) [ ] # & , ' ~ ="
+
+// backupNameErrorMessage 建立 / 改名 / 複製失敗的提示訊息
+func backupNameErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, backup.ErrInvalidBackupName):
+		return fmt.Sprintf("備份名稱無效：只能使用文字、數字、空白與 %s，長度不超過 %d 個字元，不可以 . 或空白開頭結尾，也不可使用 original 等保留名稱",
+			backupNamePunctuationHint, backup.MaxBackupNameLength)
+	case errors.Is(err, backup.ErrBackupExists):
+		return "已有同名備份"
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return "備份不存在"
+	}
+	return err.Error()
+}
+
+// ============================================================================
+// 備份封存檔（匯出 / 匯入）
+// ============================================================================
+
+// ArchiveSelection 選取的封存檔資訊（匯入前預覽）
+type ArchiveSelection struct {
+	Success   bool     `json:"success"`
+	Message   string   `json:"message"`
+	Path      string   `json:"path"`
+	Encrypted bool     `json:"encrypted"` // 是否需要密語
+	CreatedAt string   `json:"createdAt"`
+	Backups   []string `json:"backups"` // 封存檔中的備份名稱
+}
+
+// ImportBackupsResult 匯入結果
+type ImportBackupsResult struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Backups []backup.ImportedBackup `json:"backups"`
+}
+
+// archiveErrorMessage 將封存檔錯誤轉為使用者訊息
+func archiveErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, backup.ErrPassphraseRequired):
+		return "此封存檔已加密，請輸入密語"
+	case errors.Is(err, backup.ErrWrongPassphrase):
+		return "密語錯誤"
+	case errors.Is(err, backup.ErrArchiveChecksum):
+		return "封存檔內容校驗失敗，檔案可能已損壞或遭到修改"
+	case errors.Is(err, backup.ErrUnsupportedArchive):
+		return "封存檔版本過新，請先更新 Kiro Manager"
+	case errors.Is(err, backup.ErrInvalidArchive):
+		return "不是有效的備份封存檔"
+	}
+	return err.Error()
+}
+
+// exportableBackups 取得可匯出的備份（排除原始備份）
+func exportableBackups() ([]string, error) {
+	names, err := allBackupNames()
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, name := range names {
+		if name != backup.OriginalBackupName {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// ExportBackups 將備份匯出為封存檔（names 為空時匯出所有備份）
+// passphrase 非空時以密語加密封存檔
+func (a *App) ExportBackups(names []string, passphrase string) Result {
+	if len(names) == 0 {
+		var err error
+		if names, err = exportableBackups(); err != nil {
+			return Result{Success: false, Message: fmt.Sprintf("無法讀取備份列表: %v", err)}
+		}
+		if len(names) == 0 {
+			return Result{Success: false, Message: "沒有可匯出的備份"}
+		}
+	}
+
+	defaultName := fmt.Sprintf("kiro-backups-%s.zip", time.Now().Format("20060102-150405"))
+	savePath, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "匯出備份",
+		DefaultFilename: defaultName,
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "Kiro Manager Archive (*.zip)", Pattern: "*.zip"},
+		},
+	})
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法開啟儲存對話框: %v", err)}
+	}
+	if savePath == "" {
+		return Result{Success: false, Message: "已取消匯出"}
+	}
+
+	f, err := os.OpenFile(savePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出備份失敗: %v", err)}
+	}
+	if err := backup.Export(names, f, backup.ExportOptions{Passphrase: passphrase}); err != nil {
+		f.Close()
+		os.Remove(savePath)
+		return Result{Success: false, Message: fmt.Sprintf("匯出備份失敗: %v", err)}
+	}
+	if err := f.Close(); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出備份失敗: %v", err)}
+	}
+
+	logging.Info("backups exported", "count", len(names), "encrypted", passphrase != "")
+	return Result{Success: true, Message: savePath}
+}
+
+// SelectBackupArchive 選取要匯入的封存檔並讀取其 manifest
+func (a *App) SelectBackupArchive() ArchiveSelection {
+	openPath, err := wailsruntime.OpenFileDialog(a.ctx, wailsruntime.OpenDialogOptions{
+		Title: "匯入備份",
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "Kiro Manager Archive (*.zip)", Pattern: "*.zip"},
+		},
+	})
+	if err != nil {
+		return ArchiveSelection{Success: false, Message: fmt.Sprintf("無法開啟檔案對話框: %v", err)}
+	}
+	if openPath == "" {
+		return ArchiveSelection{Success: false, Message: "已取消匯入"}
+	}
+
+	f, err := os.Open(openPath)
+	if err != nil {
+		return ArchiveSelection{Success: false, Message: fmt.Sprintf("無法讀取封存檔: %v", err)}
+	}
+	defer f.Close()
+
+	manifest, err := backup.InspectArchive(f)
+	if err != nil {
+		return ArchiveSelection{Success: false, Message: archiveErrorMessage(err)}
+	}
+
+	selection := ArchiveSelection{
+		Success:   true,
+		Path:      openPath,
+		Encrypted: manifest.Encrypted,
+		CreatedAt: manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+		Backups:   []string{},
+	}
+	for _, b := range manifest.Backups {
+		selection.Backups = append(selection.Backups, b.Name)
+	}
+	return selection
+}
+
+// ImportBackupArchive 從封存檔匯入備份
+// onConflict 為名稱衝突的處理方式（skip / overwrite / rename）
+func (a *App) ImportBackupArchive(path string, passphrase string, onConflict string) ImportBackupsResult {
+	f, err := os.Open(path)
+	if err != nil {
+		return ImportBackupsResult{Success: false, Message: fmt.Sprintf("無法讀取封存檔: %v", err)}
+	}
+	defer f.Close()
+
+	results, err := backup.Import(f, backup.ImportOptions{Passphrase: passphrase, OnConflict: onConflict})
+	if results == nil {
+		results = []backup.ImportedBackup{}
+	}
+	if err != nil {
+		logging.Warn("backup import failed", "imported", len(results), "err", err)
+		return ImportBackupsResult{Success: false, Message: archiveErrorMessage(err), Backups: results}
+	}
+
+	imported, skipped := 0, 0
+	for _, r := range results {
+		if r.Action == backup.ImportActionSkipped {
+			skipped++
+		} else {
+			imported++
+		}
+	}
+	logging.Info("backups imported", "imported", imported, "skipped", skipped)
+	return ImportBackupsResult{
+		Success: true,
+		Message: fmt.Sprintf("已匯入 %d 個備份，略過 %d 個", imported, skipped),
+		Backups: results,
+	}
+}
+
 // GetCurrentMachineID 取得當前 Machine ID
 // 如果有自訂 Machine ID，返回自訂 ID（不論 Patch 狀態）
 // 否則返回系統原始 Machine ID
@@ -342,8 +1186,6 @@ func (a *App) EnsureOriginalBackup() Result {
 	return Result{Success: true, Message: "原始備份已存在"}
 }
 
-
-
 // GetAppInfo 取得應用資訊
 func (a *App) GetAppInfo() map[string]string {
 	return map[string]string{
@@ -425,6 +1267,7 @@ func (a *App) GetCurrentUsageInfo() *CurrentUsageInfo {
 			CurrentUsage:      usageInfo.CurrentUsage,
 			Balance:           usageInfo.Balance,
 			IsLowBalance:      isLowBalance,
+			Breakdown:         usageInfo.Breakdown,
 		}
 		backup.WriteUsageCache(backupName, cache)
 	}
@@ -459,6 +1302,103 @@ func (a *App) findBackupByMachineID(machineID string) string {
 	return ""
 }
 
+const (
+	// EventKiroShutdownProgress 關閉 Kiro 時每個階段發送的事件（資料為 kiroprocess.ShutdownProgress）
+	EventKiroShutdownProgress = "kiro:shutdown-progress"
+	// ErrorCodeKiroStillRunning Kiro 未在寬限期內結束，前端應詢問使用者是否強制關閉
+	ErrorCodeKiroStillRunning = "kiroStillRunning"
+)
+
+// closeKiro 分段關閉 Kiro：先要求正常結束並等待設定的寬限期，force 為 true 時逾時後強制終止
+func (a *App) closeKiro(force bool) error {
+	return kiroprocess.Shutdown(a.context(), kiroprocess.ShutdownOptions{
+		GracePeriod: settings.GetKiroShutdownGracePeriod(),
+		Force:       force,
+		OnProgress:  a.emitShutdownProgress,
+	})
+}
+
+// emitShutdownProgress 將關閉 Kiro 的進度通知前端
+func (a *App) emitShutdownProgress(progress kiroprocess.ShutdownProgress) {
+	a.emit(EventKiroShutdownProgress, progress)
+}
+
+// ensureKiroClosed 在修改 Kiro 的檔案前關閉 Kiro，回傳 nil 表示可以繼續
+// 寬限期後仍未結束時不會強制終止（可能有未儲存的編輯內容），而是回傳 ErrorCode 為
+// kiroStillRunning 的結果，由前端詢問使用者後呼叫 ForceCloseKiro 再重試
+func (a *App) ensureKiroClosed() *Result {
+	err := a.closeKiro(false)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, kiroprocess.ErrStillRunning) {
+		logging.Warn("kiro did not exit within the grace period")
+		return &Result{Success: false, Message: "Kiro 未在時間內關閉，可能有尚未儲存的變更", ErrorCode: ErrorCodeKiroStillRunning}
+	}
+	return &Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
+}
+
+// ForceCloseKiro 強制關閉 Kiro（使用者確認捨棄未儲存的變更後呼叫）
+func (a *App) ForceCloseKiro() Result {
+	if err := kiroprocess.ForceKill(a.context(), a.emitShutdownProgress); err != nil {
+		logging.Error("failed to force close kiro", "err", err)
+		return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
+	}
+	logging.Warn("kiro force closed by user")
+	return Result{Success: true, Message: "已強制關閉 Kiro"}
+}
+
+// ErrorCodeNoKiroToRelaunch 操作前 Kiro 沒有在執行（或未啟用設定），沒有需要重新啟動的 Kiro
+const ErrorCodeNoKiroToRelaunch = "noKiroToRelaunch"
+
+// captureKiroLaunch 在關閉 Kiro 前記錄其命令列與工作區（需啟用 RelaunchKiro 設定）
+// Kiro 未執行時保留先前的記錄，讓強制關閉後的重試仍能重新啟動
+func (a *App) captureKiroLaunch() {
+	if !settings.IsRelaunchKiroEnabled() {
+		return
+	}
+	state, err := kiroprocess.CaptureLaunchState()
+	if err != nil {
+		logging.Warn("failed to capture kiro launch state", "err", err)
+		return
+	}
+	if state == nil {
+		return
+	}
+	a.launchMu.Lock()
+	a.pendingLaunch = state
+	a.launchMu.Unlock()
+}
+
+// clearPendingLaunch 捨棄記錄的啟動狀態（例如改用另一個安裝時）
+func (a *App) clearPendingLaunch() {
+	a.launchMu.Lock()
+	a.pendingLaunch = nil
+	a.launchMu.Unlock()
+}
+
+// RelaunchKiro 以關閉前的命令列與工作區重新啟動 Kiro
+// 在 SwitchToBackup 或 RestoreSoftReset 成功後由前端呼叫
+func (a *App) RelaunchKiro() Result {
+	a.launchMu.Lock()
+	state := a.pendingLaunch
+	a.pendingLaunch = nil
+	a.launchMu.Unlock()
+
+	if state == nil || !settings.IsRelaunchKiroEnabled() {
+		return Result{Success: false, Message: "沒有需要重新啟動的 Kiro", ErrorCode: ErrorCodeNoKiroToRelaunch}
+	}
+	if kiroprocess.IsKiroRunning() {
+		return Result{Success: true, Message: "Kiro 已在執行"}
+	}
+	if err := kiroprocess.Relaunch(state); err != nil {
+		logging.Error("failed to relaunch kiro", "err", err)
+		return Result{Success: false, Message: fmt.Sprintf("重新啟動 Kiro 失敗: %v", err)}
+	}
+	logging.Info("kiro relaunched", "folders", len(state.Folders))
+	return Result{Success: true, Message: "已重新啟動 Kiro"}
+}
+
 // IsKiroRunning 檢查 Kiro 是否正在運行
 func (a *App) IsKiroRunning() bool {
 	return kiroprocess.IsKiroRunning()
@@ -473,6 +1413,79 @@ func (a *App) GetKiroProcesses() []kiroprocess.ProcessInfo {
 	return processes
 }
 
+// ============================================================================
+// 監看目前登入的帳號
+// ============================================================================
+
+const (
+	// EventTokenLogin Kiro 登入（原本沒有 token），資料為 TokenChangeEvent
+	EventTokenLogin = "sso:login"
+	// EventTokenLogout Kiro 登出（token 被刪除），資料為 TokenChangeEvent
+	EventTokenLogout = "sso:logout"
+	// EventTokenRefresh 同一帳號的 token 被刷新，資料為 TokenChangeEvent
+	EventTokenRefresh = "sso:token-refresh"
+	// EventAccountChange Kiro 換成另一個帳號，資料為 TokenChangeEvent
+	EventAccountChange = "sso:account-change"
+)
+
+// tokenEventNames awssso.TokenEventKind 對應的 Wails 事件名稱
+var tokenEventNames = map[awssso.TokenEventKind]string{
+	awssso.TokenEventLogin:         EventTokenLogin,
+	awssso.TokenEventLogout:        EventTokenLogout,
+	awssso.TokenEventRefresh:       EventTokenRefresh,
+	awssso.TokenEventAccountChange: EventAccountChange,
+}
+
+// TokenChangeEvent 目前帳號變化事件（前端用）
+type TokenChangeEvent struct {
+	Kind        string `json:"kind"`        // login / logout / refresh / accountChange
+	Provider    string `json:"provider"`    // 目前帳號的登入方式（登出時為空）
+	ExpiresAt   string `json:"expiresAt"`   // 目前 token 到期時間（登出時為空）
+	BackupName  string `json:"backupName"`  // 目前帳號對應的備份，沒有時為空
+	OfferBackup bool   `json:"offerBackup"` // 新登入的帳號尚未備份，前端應提示建立備份
+}
+
+// newTokenChangeEvent 將 awssso.TokenEvent 轉為前端事件，並查詢目前帳號是否已有備份
+func newTokenChangeEvent(event awssso.TokenEvent) TokenChangeEvent {
+	result := TokenChangeEvent{Kind: string(event.Kind)}
+	if event.Current == nil {
+		return result
+	}
+	result.Provider = event.Current.Provider
+	result.ExpiresAt = event.Current.ExpiresAtString()
+
+	name, err := backup.FindBackupByToken(event.Current)
+	if err != nil {
+		// 加密備份尚未解鎖時無法判斷，不提示建立備份
+		logging.Warn("failed to match current account with backups", "err", err)
+		return result
+	}
+	result.BackupName = name
+	// 刷新不會換帳號，只在登入或切換帳號時提示
+	result.OfferBackup = name == "" && (event.Kind == awssso.TokenEventLogin || event.Kind == awssso.TokenEventAccountChange)
+	return result
+}
+
+// startTokenWatcher 開始監看 kiro-auth-token.json，在 Kiro 內登入、登出、刷新或切換帳號時通知前端
+func (a *App) startTokenWatcher(ctx context.Context) {
+	watcher, err := awssso.NewWatcher()
+	if err != nil {
+		logging.Warn("failed to watch kiro auth token", "err", err)
+		return
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	a.watchCancel = cancel
+	go func() {
+		err := watcher.Run(watchCtx, func(event awssso.TokenEvent) {
+			logging.Info("kiro auth token changed", "kind", event.Kind)
+			a.emit(tokenEventNames[event.Kind], newTokenChangeEvent(event))
+		})
+		if err != nil {
+			logging.Warn("kiro auth token watcher stopped", "err", err)
+		}
+	}()
+}
 
 // ============================================================================
 // 軟一鍵新機功能（跨平台）
@@ -489,15 +1502,9 @@ type SoftResetStatus struct {
 
 // SoftResetToNewMachine 軟一鍵新機（跨平台，不需要管理員權限）
 func (a *App) SoftResetToNewMachine() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	// 關閉 Kiro（先要求正常結束，逾時需使用者確認才會強制關閉）
+	if result := a.ensureKiroClosed(); result != nil {
+		return *result
 	}
 
 	result, err := softreset.SoftResetEnvironment()
@@ -505,10 +1512,12 @@ func (a *App) SoftResetToNewMachine() Result {
 		return Result{Success: false, Message: err.Error()}
 	}
 
-	return Result{
-		Success: true,
-		Message: fmt.Sprintf("軟重置成功！新 Machine ID: %s", result.NewMachineID[:8]+"..."),
+	message := fmt.Sprintf("軟重置成功！新 Machine ID: %s", result.NewMachineID[:8]+"...")
+	if result.TrashDir != "" {
+		logging.Info("kiro sso cache moved to trash", "dir", result.TrashDir)
+		message += fmt.Sprintf("\n已清除的 Kiro 登入檔案移至 %s", result.TrashDir)
 	}
+	return Result{Success: true, Message: message}
 }
 
 // GetSoftResetStatus 取得軟重置狀態
@@ -534,15 +1543,10 @@ func (a *App) GetSoftResetStatus() SoftResetStatus {
 
 // RestoreSoftReset 還原軟重置（恢復系統原始 Machine ID）
 func (a *App) RestoreSoftReset() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	// 關閉 Kiro（先要求正常結束，逾時需使用者確認才會強制關閉）
+	a.captureKiroLaunch()
+	if result := a.ensureKiroClosed(); result != nil {
+		return *result
 	}
 
 	// 執行還原（刪除自訂 Machine ID、還原 extension.js）
@@ -579,15 +1583,9 @@ func (a *App) RestoreSoftReset() Result {
 
 // RepatchExtension 重新 Patch extension.js（Kiro 更新後使用）
 func (a *App) RepatchExtension() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	// 關閉 Kiro（先要求正常結束，逾時需使用者確認才會強制關閉）
+	if result := a.ensureKiroClosed(); result != nil {
+		return *result
 	}
 
 	if err := softreset.PatchExtensionJS(); err != nil {
@@ -599,15 +1597,9 @@ func (a *App) RepatchExtension() Result {
 
 // UnpatchExtension 移除 Patch（還原 extension.js）
 func (a *App) UnpatchExtension() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	// 關閉 Kiro（先要求正常結束，逾時需使用者確認才會強制關閉）
+	if result := a.ensureKiroClosed(); result != nil {
+		return *result
 	}
 
 	if err := softreset.UnpatchExtensionJS(); err != nil {
@@ -623,37 +1615,254 @@ func (a *App) UnpatchExtension() Result {
 
 // AppSettings 應用設定（前端用）
 type AppSettings struct {
-	LowBalanceThreshold   float64 `json:"lowBalanceThreshold"`   // 低餘額閾值（0.0 ~ 1.0）
-	KiroVersion           string  `json:"kiroVersion"`           // Kiro IDE 版本號
-	UseAutoDetect         bool    `json:"useAutoDetect"`         // 是否使用自動偵測版本號
-	CustomKiroInstallPath string  `json:"customKiroInstallPath"` // 自定義 Kiro 安裝路徑
+	LowBalanceThreshold      float64 `json:"lowBalanceThreshold"`      // 低餘額閾值（0.0 ~ 1.0）
+	KiroVersion              string  `json:"kiroVersion"`              // Kiro IDE 版本號
+	UseAutoDetect            bool    `json:"useAutoDetect"`            // 是否使用自動偵測版本號
+	CustomKiroInstallPath    string  `json:"customKiroInstallPath"`    // 自定義 Kiro 安裝路徑
+	TrashSSOCache            bool    `json:"trashSsoCache"`            // 一鍵新機時將 Kiro 的 SSO cache 移至資源回收區
+	KiroShutdownGraceSeconds int     `json:"kiroShutdownGraceSeconds"` // 關閉 Kiro 時等待正常結束的秒數（0 表示預設值）
+	RelaunchKiro             bool    `json:"relaunchKiro"`             // 切換帳號後自動重新啟動 Kiro
 }
 
 // GetSettings 取得全域設定
 func (a *App) GetSettings() AppSettings {
 	s := settings.GetCurrentSettings()
 	return AppSettings{
-		LowBalanceThreshold:   s.LowBalanceThreshold,
-		KiroVersion:           s.KiroVersion,
-		UseAutoDetect:         s.UseAutoDetect,
-		CustomKiroInstallPath: s.CustomKiroInstallPath,
+		LowBalanceThreshold:      s.LowBalanceThreshold,
+		KiroVersion:              s.KiroVersion,
+		UseAutoDetect:            s.UseAutoDetect,
+		CustomKiroInstallPath:    s.CustomKiroInstallPath,
+		TrashSSOCache:            s.TrashSSOCache,
+		KiroShutdownGraceSeconds: s.KiroShutdownGraceSeconds,
+		RelaunchKiro:             s.RelaunchKiro,
 	}
 }
 
 // SaveSettings 儲存全域設定
+// 僅覆寫前端可編輯的欄位，其餘設定（例如日誌等級）維持原值
 func (a *App) SaveSettings(appSettings AppSettings) Result {
-	s := &settings.Settings{
-		LowBalanceThreshold:   appSettings.LowBalanceThreshold,
-		KiroVersion:           appSettings.KiroVersion,
-		UseAutoDetect:         appSettings.UseAutoDetect,
-		CustomKiroInstallPath: appSettings.CustomKiroInstallPath,
-	}
-	if err := settings.SaveSettings(s); err != nil {
+	s := *settings.GetCurrentSettings()
+	s.LowBalanceThreshold = appSettings.LowBalanceThreshold
+	s.KiroVersion = appSettings.KiroVersion
+	s.UseAutoDetect = appSettings.UseAutoDetect
+	s.CustomKiroInstallPath = appSettings.CustomKiroInstallPath
+	s.TrashSSOCache = appSettings.TrashSSOCache
+	s.KiroShutdownGraceSeconds = appSettings.KiroShutdownGraceSeconds
+	s.RelaunchKiro = appSettings.RelaunchKiro
+	if err := settings.SaveSettings(&s); err != nil {
 		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
 	}
 	return Result{Success: true, Message: "設定已儲存"}
 }
 
+// ============================================================================
+// 網路設定
+// ============================================================================
+
+// NetworkSettings 對外連線設定（前端用）
+type NetworkSettings struct {
+	ProxyURL              string `json:"proxyUrl"`              // 代理伺服器網址
+	NoProxy               string `json:"noProxy"`               // 不經過代理的主機清單
+	CABundlePath          string `json:"caBundlePath"`          // 額外信任的 CA 憑證檔
+	ConnectTimeoutSeconds int    `json:"connectTimeoutSeconds"` // 建立連線超時（秒）
+	RequestTimeoutSeconds int    `json:"requestTimeoutSeconds"` // API 請求超時（秒）
+	RetryMaxAttempts      int    `json:"retryMaxAttempts"`      // 最多嘗試次數（含第一次）
+	RetryBaseDelayMillis  int    `json:"retryBaseDelayMillis"`  // 第一次重試前的等待時間（毫秒）
+	RetryMaxDelaySeconds  int    `json:"retryMaxDelaySeconds"`  // 單次重試等待上限（秒）
+}
+
+// connectionTestTargets 測試連線時檢查的 API 端點
+var connectionTestTargets = []string{
+	usage.DefaultBaseURL,
+	tokenrefresh.SocialRefreshURL,
+	tokenrefresh.IdCRefreshURL,
+}
+
+// networkConfig 將網路設定轉為 httpclient 設定
+func networkConfig(ns NetworkSettings) httpclient.Config {
+	return httpclient.Config{
+		ProxyURL:       ns.ProxyURL,
+		NoProxy:        ns.NoProxy,
+		CABundlePath:   ns.CABundlePath,
+		ConnectTimeout: time.Duration(ns.ConnectTimeoutSeconds) * time.Second,
+		RequestTimeout: time.Duration(ns.RequestTimeoutSeconds) * time.Second,
+		Retry: httpclient.RetryPolicy{
+			MaxAttempts: ns.RetryMaxAttempts,
+			BaseDelay:   time.Duration(ns.RetryBaseDelayMillis) * time.Millisecond,
+			MaxDelay:    time.Duration(ns.RetryMaxDelaySeconds) * time.Second,
+		},
+	}
+}
+
+// currentNetworkSettings 從全域設定取出網路設定
+func currentNetworkSettings() NetworkSettings {
+	s := settings.GetCurrentSettings()
+	return NetworkSettings{
+		ProxyURL:              s.ProxyURL,
+		NoProxy:               s.NoProxy,
+		CABundlePath:          s.CABundlePath,
+		ConnectTimeoutSeconds: s.ConnectTimeoutSeconds,
+		RequestTimeoutSeconds: s.RequestTimeoutSeconds,
+		RetryMaxAttempts:      s.RetryMaxAttempts,
+		RetryBaseDelayMillis:  s.RetryBaseDelayMillis,
+		RetryMaxDelaySeconds:  s.RetryMaxDelaySeconds,
+	}
+}
+
+// initNetwork 依設定檔套用代理、CA 與超時，所有對外 API 請求皆經由此設定
+func initNetwork() {
+	if err := httpclient.Configure(networkConfig(currentNetworkSettings())); err != nil {
+		logging.Warn("invalid network settings, using defaults", "err", err)
+	}
+}
+
+// networkErrorMessage 將網路設定錯誤轉為使用者可讀的訊息
+func networkErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, httpclient.ErrInvalidProxyURL):
+		return "代理伺服器網址格式錯誤（支援 http、https、socks5）"
+	case errors.Is(err, httpclient.ErrInvalidCABundle):
+		return fmt.Sprintf("無法載入 CA 憑證: %v", err)
+	}
+	return err.Error()
+}
+
+// apiErrorCode 將 API 錯誤分類為前端可比對的代碼，無法分類時返回空字串
+func apiErrorCode(err error) string {
+	switch {
+	case errors.Is(err, httpclient.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, httpclient.ErrRateLimited):
+		return "rateLimited"
+	case errors.Is(err, httpclient.ErrServerUnavailable):
+		return "serverUnavailable"
+	case errors.Is(err, httpclient.ErrNetwork):
+		return "network"
+	}
+	return ""
+}
+
+// apiErrorMessage 將用量 API 錯誤轉為使用者可讀的訊息
+func apiErrorMessage(err error) string {
+	switch apiErrorCode(err) {
+	case "unauthorized":
+		return "Token 已失效，請重新登入 Kiro"
+	case "rateLimited":
+		return "請求過於頻繁，請稍後再試"
+	case "serverUnavailable":
+		return "伺服器暫時無法使用，請稍後再試"
+	case "network":
+		return "網路連線失敗，請檢查網路或代理設定"
+	}
+	return fmt.Sprintf("API 呼叫失敗: %v", err)
+}
+
+// GetNetworkSettings 取得網路設定
+func (a *App) GetNetworkSettings() NetworkSettings {
+	return currentNetworkSettings()
+}
+
+// SaveNetworkSettings 儲存網路設定並立即套用
+func (a *App) SaveNetworkSettings(ns NetworkSettings) Result {
+	if err := httpclient.Configure(networkConfig(ns)); err != nil {
+		return Result{Success: false, Message: networkErrorMessage(err)}
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.ProxyURL = ns.ProxyURL
+	s.NoProxy = ns.NoProxy
+	s.CABundlePath = ns.CABundlePath
+	s.ConnectTimeoutSeconds = ns.ConnectTimeoutSeconds
+	s.RequestTimeoutSeconds = ns.RequestTimeoutSeconds
+	s.RetryMaxAttempts = ns.RetryMaxAttempts
+	s.RetryBaseDelayMillis = ns.RetryBaseDelayMillis
+	s.RetryMaxDelaySeconds = ns.RetryMaxDelaySeconds
+	if err := settings.SaveSettings(&s); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
+	}
+	logging.Info("network settings updated", "proxy", httpclient.RedactProxyURL(ns.ProxyURL), "caBundle", ns.CABundlePath)
+	return Result{Success: true, Message: "網路設定已儲存"}
+}
+
+// TestConnection 以指定的網路設定（尚未儲存亦可）測試能否連上 Kiro API
+func (a *App) TestConnection(ns NetworkSettings) Result {
+	cfg := networkConfig(ns)
+	transport, err := httpclient.NewTransport(cfg)
+	if err != nil {
+		return Result{Success: false, Message: networkErrorMessage(err)}
+	}
+	defer transport.CloseIdleConnections()
+
+	timeout := cfg.RequestTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var lines []string
+	for _, target := range connectionTestTargets {
+		host := target
+		if u, err := url.Parse(target); err == nil {
+			host = u.Host
+		}
+		status, elapsed, err := httpclient.Probe(ctx, transport, target, timeout)
+		if err != nil {
+			logging.Warn("connection test failed", "target", host, "err", err)
+			return Result{Success: false, Message: fmt.Sprintf("無法連線 %s: %v", host, err)}
+		}
+		lines = append(lines, fmt.Sprintf("%s（HTTP %d，%d ms）", host, status, elapsed.Milliseconds()))
+	}
+	return Result{Success: true, Message: "連線正常：" + strings.Join(lines, "、")}
+}
+
+// ============================================================================
+// 備份加密
+// ============================================================================
+
+// GetBackupEncryptionStatus 取得備份加密狀態
+func (a *App) GetBackupEncryptionStatus() backup.EncryptionStatus {
+	status, err := backup.GetEncryptionStatus()
+	if err != nil {
+		return backup.EncryptionStatus{KeySource: backup.KeySourceKeyring}
+	}
+	return *status
+}
+
+// UnlockBackups 以密語解鎖備份（密語模式）
+func (a *App) UnlockBackups(passphrase string) Result {
+	if err := backup.Unlock(passphrase); err != nil {
+		if errors.Is(err, backup.ErrWrongPassphrase) {
+			return Result{Success: false, Message: "密語錯誤"}
+		}
+		return Result{Success: false, Message: fmt.Sprintf("解鎖失敗: %v", err)}
+	}
+
+	// 解鎖後補做舊版明文備份的遷移
+	if _, err := backup.MigratePlaintextBackups(); err != nil {
+		return Result{Success: true, Message: fmt.Sprintf("已解鎖，但舊備份加密失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已解鎖"}
+}
+
+// SetBackupKeySource 切換備份加密的金鑰來源（keyring / passphrase）
+// 會以新金鑰重新加密所有備份
+func (a *App) SetBackupKeySource(source string, passphrase string) Result {
+	if err := backup.SetKeySource(source, passphrase); err != nil {
+		switch {
+		case errors.Is(err, backup.ErrPassphraseRequired):
+			return Result{Success: false, Message: "請先輸入密語解鎖備份"}
+		case errors.Is(err, backup.ErrKeyringUnavailable):
+			return Result{Success: false, Message: "無法存取系統金鑰圈，請改用密語模式"}
+		}
+		return Result{Success: false, Message: fmt.Sprintf("切換金鑰來源失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已重新加密所有備份"}
+}
+
 // GetDetectedKiroInstallPath 自動偵測 Kiro 安裝路徑
 func (a *App) GetDetectedKiroInstallPath() Result {
 	path, err := kiropath.GetKiroInstallPathAutoDetect()
@@ -663,6 +1872,130 @@ func (a *App) GetDetectedKiroInstallPath() Result {
 	return Result{Success: true, Message: path}
 }
 
+// GetKiroInstallations 列出所有偵測到的 Kiro 安裝，供設定頁選擇 CustomKiroInstallPath
+func (a *App) GetKiroInstallations() []kiropath.Installation {
+	return kiropath.DiscoverInstallations()
+}
+
+// ============================================================================
+// Kiro 安裝設定檔
+// ============================================================================
+
+// KiroProfileStatus 安裝設定檔與其目前狀態（前端顯示用）
+// 名稱為空字串的項目代表預設安裝（自定義路徑或自動偵測）
+type KiroProfileStatus struct {
+	Name            string `json:"name"`
+	InstallPath     string `json:"installPath"`
+	KiroVersion     string `json:"kiroVersion"`     // 設定檔的自定義版本號
+	DetectedVersion string `json:"detectedVersion"` // 從安裝讀取的版本號，無法讀取時為空字串
+	Exists          bool   `json:"exists"`          // 安裝路徑是否存在
+	Running         bool   `json:"running"`
+	ProcessCount    int    `json:"processCount"`
+	Active          bool   `json:"active"` // 目前所有操作使用的安裝
+}
+
+// GetKiroProfiles 列出預設安裝與所有安裝設定檔，以及各自的版本與執行狀態
+func (a *App) GetKiroProfiles() []KiroProfileStatus {
+	s := settings.GetCurrentSettings()
+
+	// 預設安裝：與未選擇設定檔時 kiropath.GetKiroInstallPath 的邏輯相同
+	defaultPath := s.CustomKiroInstallPath
+	if _, err := os.Stat(defaultPath); defaultPath == "" || err != nil {
+		defaultPath, _ = kiropath.GetKiroInstallPathAutoDetect()
+	}
+
+	statuses := []KiroProfileStatus{kiroProfileStatus("", defaultPath, "")}
+	for _, p := range s.KiroProfiles {
+		statuses = append(statuses, kiroProfileStatus(p.Name, p.InstallPath, p.KiroVersion))
+	}
+
+	active := settings.GetActiveKiroProfile()
+	for i := range statuses {
+		if active == nil {
+			statuses[i].Active = statuses[i].Name == ""
+		} else {
+			statuses[i].Active = statuses[i].Name == active.Name
+		}
+	}
+	return statuses
+}
+
+// kiroProfileStatus 讀取一個安裝的版本與執行中的進程數
+func kiroProfileStatus(name, installPath, kiroVersion string) KiroProfileStatus {
+	status := KiroProfileStatus{Name: name, InstallPath: installPath, KiroVersion: kiroVersion}
+	if installPath == "" {
+		return status
+	}
+	if _, err := os.Stat(installPath); err != nil {
+		return status
+	}
+	status.Exists = true
+	if version, err := kiroversion.GetKiroVersionAt(installPath); err == nil {
+		status.DetectedVersion = version
+	}
+	if processes, err := kiroprocess.GetKiroProcessesAt(installPath); err == nil {
+		status.ProcessCount = len(processes)
+		status.Running = len(processes) > 0
+	}
+	return status
+}
+
+// kiroProfileErrorMessage 將安裝設定檔的錯誤轉為使用者可讀的訊息
+func kiroProfileErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, settings.ErrKiroProfileNameRequired):
+		return "請輸入設定檔名稱"
+	case errors.Is(err, settings.ErrInstallPathNotFound):
+		return "安裝路徑不存在"
+	case errors.Is(err, settings.ErrKiroProfileExists):
+		return "設定檔名稱已存在"
+	case errors.Is(err, settings.ErrKiroProfileNotFound):
+		return "找不到設定檔"
+	}
+	return fmt.Sprintf("儲存設定失敗: %v", err)
+}
+
+// SaveKiroProfile 新增或更新安裝設定檔
+// originalName 為空字串時新增，否則更新該名稱的設定檔（可同時更名）
+func (a *App) SaveKiroProfile(originalName string, profile settings.KiroProfile) Result {
+	if err := settings.SaveKiroProfile(originalName, profile); err != nil {
+		return Result{Success: false, Message: kiroProfileErrorMessage(err)}
+	}
+	logging.Info("kiro profile saved", "name", profile.Name, "installPath", profile.InstallPath)
+	return Result{Success: true, Message: "設定檔已儲存"}
+}
+
+// DeleteKiroProfile 刪除安裝設定檔，刪除使用中的設定檔時改回預設安裝
+func (a *App) DeleteKiroProfile(name string) Result {
+	active := settings.GetActiveKiroProfile()
+	wasActive := active != nil && strings.EqualFold(active.Name, name)
+	if err := settings.DeleteKiroProfile(name); err != nil {
+		return Result{Success: false, Message: kiroProfileErrorMessage(err)}
+	}
+	if wasActive {
+		a.clearPendingLaunch()
+	}
+	logging.Info("kiro profile deleted", "name", name)
+	return Result{Success: true, Message: "設定檔已刪除"}
+}
+
+// SetActiveKiroProfile 選擇之後所有操作（切換帳號、一鍵新機、Patch、進程偵測與版本號）使用的安裝
+// name 為空字串時改回預設安裝
+func (a *App) SetActiveKiroProfile(name string) Result {
+	if err := settings.SetActiveKiroProfile(name); err != nil {
+		return Result{Success: false, Message: kiroProfileErrorMessage(err)}
+	}
+	// 記錄的啟動狀態屬於先前的安裝，不再適用
+	a.clearPendingLaunch()
+	active := settings.GetActiveKiroProfile()
+	if active == nil {
+		logging.Info("active kiro profile changed", "name", "")
+		return Result{Success: true, Message: "已改用預設安裝"}
+	}
+	logging.Info("active kiro profile changed", "name", active.Name, "installPath", active.InstallPath)
+	return Result{Success: true, Message: fmt.Sprintf("已改用「%s」", active.Name)}
+}
+
 // GetDetectedKiroVersion 自動偵測 Kiro IDE 執行檔的版本號
 func (a *App) GetDetectedKiroVersion() Result {
 	version, err := kiroversion.GetKiroVersion()
@@ -708,6 +2041,100 @@ func (a *App) OpenSSOCacheFolder() Result {
 	return openFolder(cachePath)
 }
 
+// ============================================================================
+// 日誌
+// ============================================================================
+
+// initDataDir 決定資料目錄（--data-dir、KIRO_MANAGER_HOME、可攜模式或使用者資料目錄），
+// 搬移舊版存放在執行檔同層的資料後初始化日誌
+func initDataDir(args []string) {
+	loc, migrated, err := datadir.Init(datadir.ArgValue(args))
+	initLogging()
+	if err != nil {
+		logging.Warn("failed to prepare data directory", "dir", loc.Dir, "err", err)
+	}
+	if len(migrated) > 0 {
+		logging.Info("migrated data from the executable directory", "dir", loc.Dir, "items", migrated)
+	}
+	logging.Debug("data directory resolved", "dir", loc.Dir, "source", loc.Source)
+}
+
+// DataDirInfo 資料目錄資訊（前端顯示用）
+type DataDirInfo struct {
+	Dir    string `json:"dir"`
+	Source string `json:"source"` // flag / env / portable / user / execDir
+}
+
+// GetDataDir 取得目前使用的資料目錄
+func (a *App) GetDataDir() DataDirInfo {
+	loc, err := datadir.Resolve()
+	if err != nil {
+		logging.Warn("failed to resolve data directory", "err", err)
+	}
+	return DataDirInfo{Dir: loc.Dir, Source: loc.Source}
+}
+
+// OpenDataFolder 打開資料目錄
+func (a *App) OpenDataFolder() Result {
+	dir, err := datadir.Dir()
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法取得資料目錄: %v", err)}
+	}
+	return openFolder(dir)
+}
+
+// initLogging 開啟 settings.json 同層的日誌檔，等級依設定檔
+func initLogging() {
+	settingsPath, err := settings.GetSettingsPath()
+	if err != nil {
+		return
+	}
+	level := logging.ParseLevel(settings.GetLogLevel())
+	if err := logging.Init(filepath.Dir(settingsPath), level); err != nil {
+		logging.Warn("failed to open log file", "err", err)
+	}
+}
+
+// OpenLogFolder 打開日誌檔所在的文件夾
+func (a *App) OpenLogFolder() Result {
+	logPath := logging.LogFilePath()
+	if logPath == "" {
+		return Result{Success: false, Message: "日誌檔尚未建立"}
+	}
+	return openFolder(filepath.Dir(logPath))
+}
+
+// ExportLogs 將近期日誌匯出為單一文字檔（敏感資料已遮蔽），供回報問題使用
+func (a *App) ExportLogs() Result {
+	defaultName := fmt.Sprintf("kiro-manager-logs-%s.txt", time.Now().Format("20060102-150405"))
+	savePath, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "匯出日誌",
+		DefaultFilename: defaultName,
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "Log (*.txt)", Pattern: "*.txt"},
+		},
+	})
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("無法開啟儲存對話框: %v", err)}
+	}
+	if savePath == "" {
+		return Result{Success: false, Message: "已取消匯出"}
+	}
+
+	f, err := os.OpenFile(savePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出日誌失敗: %v", err)}
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "Kiro Manager %s (%s/%s)\nExported at %s\n\n",
+		a.GetAppInfo()["version"], runtime.GOOS, runtime.GOARCH, time.Now().Format(time.RFC3339))
+	if err := logging.Export(f); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出日誌失敗: %v", err)}
+	}
+	return Result{Success: true, Message: savePath}
+}
+
 // openFolder 使用系統檔案管理器打開指定文件夾
 func openFolder(folderPath string) Result {
 	var cmd *exec.Cmd
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/kiropath"
+	"kiro-manager/settings"
+	"kiro-manager/sysenv"
+)
+
+// TestMain 以暫存目錄與固定 Machine ID 執行測試，見 sysenv.RunIsolated
+func TestMain(m *testing.M) {
+	os.Exit(sysenv.RunIsolated(m))
+}
+
+// TestRefreshAll_Report 測試批次刷新的結果彙總與進度回報
+func TestRefreshAll_Report(t *testing.T) {
+	names := []string{"ok-1", "expired", "fail", "ok-2"}
+	refresh := func(ctx context.Context, name string) UsageCacheResult {
+		switch name {
+		case "expired":
+			return UsageCacheResult{Success: false, Message: "Token 已失效，請重新登入 Kiro", ErrorCode: "refreshTokenExpired"}
+		case "fail":
+			return UsageCacheResult{Success: false, Message: "伺服器暫時無法使用，請稍後再試", ErrorCode: "serverUnavailable"}
+		}
+		return UsageCacheResult{Success: true, Balance: 10}
+	}
+
+	var progress []RefreshProgress
+	report := refreshAll(context.Background(), names, 2, refresh, func(p RefreshProgress) {
+		progress = append(progress, p)
+	})
+
+	if report.Success {
+		t.Error("report should not be successful when some backups failed")
+	}
+	if !reflect.DeepEqual(report.Succeeded, []string{"ok-1", "ok-2"}) {
+		t.Errorf("Succeeded = %v", report.Succeeded)
+	}
+	if !reflect.DeepEqual(report.Expired, []string{"expired"}) || !reflect.DeepEqual(report.Failed, []string{"fail"}) {
+		t.Errorf("Expired = %v, Failed = %v", report.Expired, report.Failed)
+	}
+	for i, res := range report.Results {
+		if res.Name != names[i] {
+			t.Errorf("Results[%d] = %s, want input order", i, res.Name)
+		}
+	}
+
+	if len(progress) != len(names) {
+		t.Fatalf("expected %d progress events, got %d", len(names), len(progress))
+	}
+	for i, p := range progress {
+		if p.Done != i+1 || p.Total != len(names) {
+			t.Errorf("progress[%d] = %d/%d", i, p.Done, p.Total)
+		}
+	}
+}
+
+// TestRefreshAll_BoundedConcurrency 測試同時進行的刷新數量不超過 workers
+func TestRefreshAll_BoundedConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	refresh := func(ctx context.Context, name string) UsageCacheResult {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		return UsageCacheResult{Success: true}
+	}
+
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = fmt.Sprintf("backup-%d", i)
+	}
+	report := refreshAll(context.Background(), names, 3, refresh, nil)
+
+	if !report.Success || len(report.Succeeded) != len(names) {
+		t.Errorf("expected all succeeded, got %+v", report.Message)
+	}
+	if peak.Load() > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak.Load())
+	}
+}
+
+// TestRefreshAll_Cancel 測試取消後尚未完成的備份標記為已取消
+func TestRefreshAll_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	refresh := func(ctx context.Context, name string) UsageCacheResult {
+		if name == "slow" {
+			once.Do(cancel)
+			<-ctx.Done()
+			return UsageCacheResult{Success: false, Message: ctx.Err().Error()}
+		}
+		return UsageCacheResult{Success: true}
+	}
+
+	names := []string{"first", "slow", "later-1", "later-2"}
+	report := refreshAll(ctx, names, 1, refresh, nil)
+
+	if !reflect.DeepEqual(report.Succeeded, []string{"first"}) {
+		t.Errorf("Succeeded = %v", report.Succeeded)
+	}
+	if !reflect.DeepEqual(report.Canceled, []string{"slow", "later-1", "later-2"}) {
+		t.Errorf("Canceled = %v", report.Canceled)
+	}
+	if len(report.Failed) != 0 {
+		t.Errorf("canceled refreshes should not count as failures: %v", report.Failed)
+	}
+}
+
+// TestCancelRefreshAll_NotRunning 測試沒有批次刷新時取消會回傳失敗
+func TestCancelRefreshAll_NotRunning(t *testing.T) {
+	if result := NewApp().CancelRefreshAll(); result.Success {
+		t.Error("expected failure when no batch refresh is running")
+	}
+}
+
+// TestFilterBackupItems 測試備份列表的篩選與排序
+func TestFilterBackupItems(t *testing.T) {
+	items := []BackupItem{
+		{Name: "bravo", Provider: "Github", Tags: []string{"team-a"}, Balance: 40, CachedAt: "2026-01-01T00:00:00Z", TokenExpiresAt: "2026-03-01T00:00:00Z"},
+		{Name: "alpha", Provider: "Google", Tags: []string{"personal"}, Balance: 5, CachedAt: "2026-01-01T00:00:00Z", IsLowBalance: true, IsTokenExpired: true, TokenExpiresAt: "2026-01-01T00:00:00Z"},
+		{Name: "charlie", Provider: "Github", Tags: []string{"team-a", "ci"}, DisplayName: "CI Runner", Email: "ci@example.com"},
+		{Name: "delta"},
+	}
+	names := func(items []BackupItem) []string {
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		query BackupQuery
+		want  []string
+	}{
+		{"default sorts by name", BackupQuery{}, []string{"alpha", "bravo", "charlie", "delta"}},
+		{"tag", BackupQuery{Tags: []string{"TEAM-A"}}, []string{"bravo", "charlie"}},
+		{"all tags", BackupQuery{Tags: []string{"team-a", "ci"}}, []string{"charlie"}},
+		{"provider", BackupQuery{Provider: "github"}, []string{"bravo", "charlie"}},
+		{"search email", BackupQuery{Search: "CI@EXAMPLE"}, []string{"charlie"}},
+		{"search display name", BackupQuery{Search: "runner"}, []string{"charlie"}},
+		{"expired", BackupQuery{TokenState: TokenStateExpired}, []string{"alpha"}},
+		{"low balance", BackupQuery{LowBalanceOnly: true}, []string{"alpha"}},
+		{"balance keeps uncached last", BackupQuery{SortBy: SortByBalance}, []string{"alpha", "bravo", "charlie", "delta"}},
+		{"balance desc", BackupQuery{SortBy: SortByBalance, Desc: true}, []string{"bravo", "alpha", "charlie", "delta"}},
+		{"expiry", BackupQuery{SortBy: SortByExpiry, Desc: true}, []string{"bravo", "alpha", "charlie", "delta"}},
+		{"tag sort", BackupQuery{SortBy: SortByTag}, []string{"alpha", "bravo", "charlie", "delta"}},
+		{"provider sort desc", BackupQuery{SortBy: SortByProvider, Desc: true}, []string{"alpha", "bravo", "charlie", "delta"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterBackupItems(items, tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("got %v, want %v", names(got), tt.want)
+			}
+		})
+	}
+
+	if _, err := filterBackupItems(items, BackupQuery{SortBy: "size"}); err == nil {
+		t.Error("expected an error for an unknown sort field")
+	}
+	if _, err := filterBackupItems(items, BackupQuery{TokenState: "soon"}); err == nil {
+		t.Error("expected an error for an unknown token state")
+	}
+}
+
+// TestKiroProfiles 測試安裝設定檔的新增、更名、選擇與刪除，以及選擇後安裝路徑與版本號跟著切換
+func TestKiroProfiles(t *testing.T) {
+	original := *settings.GetCurrentSettings()
+	t.Cleanup(func() { settings.SaveSettings(&original) })
+
+	stable, preview := t.TempDir(), t.TempDir()
+	a := NewApp()
+
+	if r := a.SaveKiroProfile("", settings.KiroProfile{Name: " Stable ", InstallPath: stable}); !r.Success {
+		t.Fatalf("add stable: %s", r.Message)
+	}
+	if r := a.SaveKiroProfile("", settings.KiroProfile{Name: "Preview", InstallPath: preview, KiroVersion: "0.9.0"}); !r.Success {
+		t.Fatalf("add preview: %s", r.Message)
+	}
+	if r := a.SaveKiroProfile("", settings.KiroProfile{Name: "stable", InstallPath: preview}); r.Success {
+		t.Error("expected duplicate names (case-insensitive) to be rejected")
+	}
+	if r := a.SaveKiroProfile("", settings.KiroProfile{Name: "Missing", InstallPath: filepath.Join(stable, "missing")}); r.Success {
+		t.Error("expected a missing install path to be rejected")
+	}
+	if r := a.SetActiveKiroProfile("unknown"); r.Success {
+		t.Error("expected an unknown profile to be rejected")
+	}
+
+	if r := a.SetActiveKiroProfile("preview"); !r.Success {
+		t.Fatalf("set active: %s", r.Message)
+	}
+	if got := settings.GetCustomKiroInstallPath(); got != preview {
+		t.Errorf("GetCustomKiroInstallPath() = %q, want %q", got, preview)
+	}
+	if got, err := kiropath.GetKiroInstallPath(); err != nil || got != preview {
+		t.Errorf("GetKiroInstallPath() = %q, %v, want %q", got, err, preview)
+	}
+	if got := settings.GetKiroVersion(); got != "0.9.0" {
+		t.Errorf("GetKiroVersion() = %q, want the profile version", got)
+	}
+
+	// 更名使用中的設定檔時維持選擇
+	if r := a.SaveKiroProfile("Preview", settings.KiroProfile{Name: "Insiders", InstallPath: preview}); !r.Success {
+		t.Fatalf("rename: %s", r.Message)
+	}
+	if active := settings.GetActiveKiroProfile(); active == nil || active.Name != "Insiders" {
+		t.Errorf("active profile after rename = %+v", active)
+	}
+
+	var names []string
+	for _, p := range a.GetKiroProfiles() {
+		names = append(names, p.Name)
+		if p.Active != (p.Name == "Insiders") {
+			t.Errorf("profile %q Active = %v", p.Name, p.Active)
+		}
+		if p.Name != "" && !p.Exists {
+			t.Errorf("profile %q should exist", p.Name)
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"", "Stable", "Insiders"}) {
+		t.Errorf("profiles = %v", names)
+	}
+
+	// 選擇的安裝不存在時不可改用其他安裝
+	if err := os.Remove(preview); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kiropath.GetKiroInstallPath(); err != kiropath.ErrKiroNotFound {
+		t.Errorf("GetKiroInstallPath() error = %v, want ErrKiroNotFound", err)
+	}
+
+	if r := a.DeleteKiroProfile("insiders"); !r.Success {
+		t.Fatalf("delete: %s", r.Message)
+	}
+	if active := settings.GetActiveKiroProfile(); active != nil {
+		t.Errorf("deleting the active profile should select the default install, got %+v", active)
+	}
+	if got := settings.GetCurrentSettings().KiroProfiles; len(got) != 1 || got[0].Name != "Stable" {
+		t.Errorf("remaining profiles = %+v", got)
+	}
+}
+
+// TestGetUsageTrend_UsesEnvClock 測試用量趨勢以 sysenv 的時鐘決定統計區間
+func TestGetUsageTrend_UsesEnvClock(t *testing.T) {
+	home, _ := sysenv.HomeDir()
+	data, _ := sysenv.DataDir()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	restore := sysenv.Set(sysenv.Fixed{Home: home, Data: data, RawMachineID: sysenv.TestMachineID, Time: now})
+	t.Cleanup(restore)
+
+	const name = "trend-clock"
+	dir, err := backup.GetBackupPath(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for _, e := range []backup.UsageHistoryEntry{
+		{Time: now.AddDate(0, 0, -9), UsageLimit: 100, CurrentUsage: 10, Balance: 90},
+		{Time: now.AddDate(0, 0, -5), UsageLimit: 100, CurrentUsage: 30, Balance: 70},
+		{Time: now.AddDate(0, 0, -1), UsageLimit: 100, CurrentUsage: 40, Balance: 60},
+		{Time: now.AddDate(0, 0, 2), UsageLimit: 100, CurrentUsage: 50, Balance: 50},
+	} {
+		if err := backup.AppendUsageHistory(name, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trend, err := (&App{}).GetUsageTrend(name, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trend.Samples != 2 || trend.To != now.AddDate(0, 0, -1).Format(time.RFC3339) || trend.Consumed != 30 {
+		t.Errorf("trend = %+v", trend)
+	}
+}
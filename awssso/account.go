@@ -0,0 +1,91 @@
+package awssso
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// AccountCacheFiles 找出 SSO cache 中屬於 token 所屬帳號的所有檔案（第一個固定為 kiro-auth-token.json）
+// 關聯規則：
+//   - <clientIdHash>.json（BuilderId / IdC 的 client 註冊檔）
+//   - startUrl 與 token 相同的檔案（以 startUrl 雜湊命名的 session 與註冊檔）
+//   - clientId 與上述檔案相同的註冊檔
+//
+// 其他檔案（例如 AWS CLI 其他 session）不會被列入
+func AccountCacheFiles(token *KiroAuthToken) ([]string, error) {
+	cachePath, err := GetSSOCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return accountCacheFiles(cachePath, token)
+}
+
+// accountCacheFiles 在 cachePath 中找出屬於 token 所屬帳號的檔案
+func accountCacheFiles(cachePath string, token *KiroAuthToken) ([]string, error) {
+	files := []string{KiroAuthTokenFile}
+	if token == nil {
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return files, nil
+		}
+		return nil, err
+	}
+
+	// 讀取其餘的 JSON 檔案；無法解析的檔案不屬於任何帳號
+	caches := make(map[string]*SSOCacheFile)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" || name == KiroAuthTokenFile {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cachePath, name))
+		if err != nil {
+			continue
+		}
+		var cache SSOCacheFile
+		if err := json.Unmarshal(data, &cache); err != nil {
+			continue
+		}
+		caches[name] = &cache
+	}
+
+	selected := make(map[string]bool)
+	clientIDs := make(map[string]bool)
+	sel := func(name string) {
+		selected[name] = true
+		if id := caches[name].ClientID; id != "" {
+			clientIDs[id] = true
+		}
+	}
+
+	if token.ClientIdHash != "" {
+		if _, ok := caches[token.ClientIdHash+".json"]; ok {
+			sel(token.ClientIdHash + ".json")
+		}
+	}
+	if token.StartURL != "" {
+		for name, cache := range caches {
+			if cache.StartURL == token.StartURL {
+				sel(name)
+			}
+		}
+	}
+	for name, cache := range caches {
+		if !selected[name] && cache.ClientID != "" && clientIDs[cache.ClientID] {
+			selected[name] = true
+		}
+	}
+
+	related := make([]string, 0, len(selected))
+	for name := range selected {
+		related = append(related, name)
+	}
+	sort.Strings(related)
+	return append(files, related...), nil
+}
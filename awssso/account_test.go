@@ -0,0 +1,56 @@
+package awssso
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestAccountCacheFiles 測試依 clientIdHash、startUrl 與 clientId 找出同帳號的檔案
+func TestAccountCacheFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		KiroAuthTokenFile:                   `{"refreshToken":"rt","clientIdHash":"abc123","startUrl":"https://a.awsapps.com/start"}`,
+		"abc123.json":                       `{"clientId":"cid-a","clientSecret":"cs-a"}`,
+		"0f1e2d.json":                       `{"accessToken":"at","startUrl":"https://a.awsapps.com/start","region":"us-east-1"}`,
+		"botocore-client-id-us-east-1.json": `{"clientId":"cid-a","clientSecret":"cs-a"}`,
+		"cli-session.json":                  `{"accessToken":"at-cli","startUrl":"https://b.awsapps.com/start"}`,
+		"cli-registration.json":             `{"clientId":"cid-b","clientSecret":"cs-b"}`,
+		"broken.json":                       `{`,
+		"notes.txt":                         `abc123`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested.json"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	token := &KiroAuthToken{ClientIdHash: "abc123", StartURL: "https://a.awsapps.com/start"}
+	got, err := accountCacheFiles(dir, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{KiroAuthTokenFile, "0f1e2d.json", "abc123.json", "botocore-client-id-us-east-1.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IdC account files = %v, want %v", got, want)
+	}
+
+	// Social 登入沒有 clientIdHash 與 startUrl，只屬於 kiro-auth-token.json
+	got, err = accountCacheFiles(dir, &KiroAuthToken{Provider: "Github"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{KiroAuthTokenFile}) {
+		t.Errorf("social account files = %v", got)
+	}
+
+	// 快取目錄不存在時只回傳 token 檔
+	got, err = accountCacheFiles(filepath.Join(dir, "missing"), token)
+	if err != nil || !reflect.DeepEqual(got, []string{KiroAuthTokenFile}) {
+		t.Errorf("missing dir = %v, %v", got, err)
+	}
+}
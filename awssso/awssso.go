@@ -5,7 +5,8 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
-	"time"
+
+	"kiro-manager/sysenv"
 )
 
 const (
@@ -17,20 +18,6 @@ var (
 	ErrTokenNotFound = errors.New("kiro auth token not found")
 )
 
-// KiroAuthToken 代表 Kiro 的認證 token 結構
-type KiroAuthToken struct {
-	AccessToken  string `json:"accessToken,omitempty"`
-	ExpiresAt    string `json:"expiresAt,omitempty"`
-	Provider     string `json:"provider,omitempty"`
-	AuthMethod   string `json:"authMethod,omitempty"`
-	RefreshToken string `json:"refreshToken,omitempty"`
-	TokenType    string `json:"tokenType,omitempty"`
-	Region       string `json:"region,omitempty"`
-	StartURL     string `json:"startUrl,omitempty"`
-	ProfileArn   string `json:"profileArn,omitempty"`
-	ClientIdHash string `json:"clientIdHash,omitempty"` // BuilderId (IdC) 用於關聯 clientId/clientSecret 文件
-}
-
 // SSOCacheFile 代表通用的 SSO 快取檔案結構
 type SSOCacheFile struct {
 	AccessToken  string `json:"accessToken,omitempty"`
@@ -47,14 +34,13 @@ type SSOCacheFile struct {
 
 // GetSSOCachePath 取得 AWS SSO 快取目錄路徑 (~/.aws/sso/cache)
 func GetSSOCachePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := sysenv.HomeDir()
 	if err != nil {
 		return "", err
 	}
 	return filepath.Join(homeDir, ".aws", "sso", "cache"), nil
 }
 
-
 // SSOCacheExists 檢查 SSO 快取目錄是否存在
 func SSOCacheExists() bool {
 	path, err := GetSSOCachePath()
@@ -89,12 +75,7 @@ func ReadKiroAuthToken() (*KiroAuthToken, error) {
 		return nil, err
 	}
 
-	var token KiroAuthToken
-	if err := json.Unmarshal(data, &token); err != nil {
-		return nil, err
-	}
-
-	return &token, nil
+	return ParseKiroAuthToken(data)
 }
 
 // ListCacheFiles 列出 SSO 快取目錄中的所有 JSON 檔案
@@ -170,22 +151,11 @@ func ReadCacheFileRaw(filename string) (map[string]interface{}, error) {
 	return raw, nil
 }
 
-
 // IsTokenExpired 檢查 token 是否已過期
 func IsTokenExpired(token *KiroAuthToken) bool {
-	if token == nil || token.ExpiresAt == "" {
+	if token == nil || token.ExpiresAt.IsZero() {
 		return true
 	}
 
-	// 解析 ISO 8601 格式的時間字串
-	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
-	if err != nil {
-		// 嘗試其他可能的格式
-		expiresAt, err = time.Parse("2006-01-02T15:04:05.000Z", token.ExpiresAt)
-		if err != nil {
-			return true
-		}
-	}
-
-	return time.Now().After(expiresAt)
+	return sysenv.Now().After(token.ExpiresAt)
 }
@@ -0,0 +1,201 @@
+package awssso
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CacheFileKind SSO cache 檔案的分類
+type CacheFileKind string
+
+const (
+	CacheFileKiroToken  CacheFileKind = "kiroToken"  // kiro-auth-token.json
+	CacheFileKiroClient CacheFileKind = "kiroClient" // Kiro 的 client 註冊檔（<clientIdHash>.json 等）
+	CacheFileThirdParty CacheFileKind = "thirdParty" // AWS CLI / SDK 等其他工具的 session 與註冊檔
+)
+
+// kiroScopePrefix Kiro（CodeWhisperer）client 註冊時申請的 scope 前綴
+const kiroScopePrefix = "codewhisperer:"
+
+// CacheFile SSO cache 中的單一檔案
+type CacheFile struct {
+	Name string        `json:"name"`
+	Kind CacheFileKind `json:"kind"`
+}
+
+// KiroOwned 是否屬於 Kiro（可由 Kiro Manager 清除或覆寫）
+func (f CacheFile) KiroOwned() bool {
+	return f.Kind == CacheFileKiroToken || f.Kind == CacheFileKiroClient
+}
+
+// cacheEntry 分類時需要的欄位
+type cacheEntry struct {
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// Classify 依檔名與內容分類 SSO cache 檔案，files 為檔名對應的原始內容
+// Kiro client 註冊檔的判斷規則：
+//   - kiro-auth-token.json 中 clientIdHash 對應的 <clientIdHash>.json
+//   - scopes 含有 codewhisperer: 前綴的註冊檔（token 已刪除時仍可辨識）
+//   - clientId 與上述註冊檔相同的註冊檔
+//
+// 其餘檔案（包含 startUrl 相同的 session 檔）都視為第三方，結果依檔名排序
+func Classify(files map[string][]byte) []CacheFile {
+	var token KiroAuthToken
+	if data, ok := files[KiroAuthTokenFile]; ok {
+		_ = json.Unmarshal(data, &token)
+	}
+
+	entries := make(map[string]cacheEntry, len(files))
+	for name, data := range files {
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) == nil {
+			entries[name] = entry
+		}
+	}
+
+	kiroClientIDs := make(map[string]bool)
+	isKiroClient := func(name string) bool {
+		entry := entries[name]
+		if token.ClientIdHash != "" && name == token.ClientIdHash+".json" {
+			return true
+		}
+		if entry.ClientSecret == "" {
+			return false
+		}
+		for _, scope := range entry.Scopes {
+			if strings.HasPrefix(scope, kiroScopePrefix) {
+				return true
+			}
+		}
+		return false
+	}
+	for name, entry := range entries {
+		if name != KiroAuthTokenFile && isKiroClient(name) && entry.ClientID != "" {
+			kiroClientIDs[entry.ClientID] = true
+		}
+	}
+
+	result := make([]CacheFile, 0, len(files))
+	for name := range files {
+		kind := CacheFileThirdParty
+		entry := entries[name]
+		switch {
+		case name == KiroAuthTokenFile:
+			kind = CacheFileKiroToken
+		case isKiroClient(name):
+			kind = CacheFileKiroClient
+		case entry.ClientSecret != "" && kiroClientIDs[entry.ClientID]:
+			kind = CacheFileKiroClient
+		}
+		result = append(result, CacheFile{Name: name, Kind: kind})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ClassifyCacheFiles 分類目前 SSO cache 目錄中的 JSON 檔案
+func ClassifyCacheFiles() ([]CacheFile, error) {
+	cachePath, err := GetSSOCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return classifyDir(cachePath)
+}
+
+// classifyDir 讀取並分類 cachePath 中的 JSON 檔案，目錄不存在時回傳空清單
+func classifyDir(cachePath string) ([]CacheFile, error) {
+	entries, err := os.ReadDir(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cachePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = data
+	}
+	return Classify(files), nil
+}
+
+// ClearKiroCacheFiles 清除 SSO cache 中屬於 Kiro 的檔案，第三方 session 不受影響
+// trashDir 非空時改為將檔案移至該目錄，方便日後復原；回傳被清除的檔名
+func ClearKiroCacheFiles(trashDir string) ([]string, error) {
+	cachePath, err := GetSSOCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return clearKiroCacheFiles(cachePath, trashDir)
+}
+
+// clearKiroCacheFiles 清除 cachePath 中屬於 Kiro 的檔案
+func clearKiroCacheFiles(cachePath, trashDir string) ([]string, error) {
+	files, err := classifyDir(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, f := range files {
+		if !f.KiroOwned() {
+			continue
+		}
+		if trashDir == "" {
+			err = os.Remove(filepath.Join(cachePath, f.Name))
+		} else {
+			err = moveToTrash(filepath.Join(cachePath, f.Name), trashDir)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to clear %s: %w", f.Name, err)
+		}
+		removed = append(removed, f.Name)
+	}
+	return removed, nil
+}
+
+// moveToTrash 將檔案移至 trashDir，跨磁碟無法改名時改為複製後刪除
+func moveToTrash(src, trashDir string) error {
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		return err
+	}
+	dst := filepath.Join(trashDir, filepath.Base(src))
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,93 @@
+package awssso
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// classifyTestFiles Kiro 與 AWS CLI 共用的 SSO cache 內容
+var classifyTestFiles = map[string]string{
+	KiroAuthTokenFile:                   `{"refreshToken":"rt","clientIdHash":"abc123"}`,
+	"abc123.json":                       `{"clientId":"cid-kiro","clientSecret":"cs"}`,
+	"kiro-dup-registration.json":        `{"clientId":"cid-kiro","clientSecret":"cs"}`,
+	"orphan-kiro.json":                  `{"clientId":"cid-old","clientSecret":"cs","scopes":["codewhisperer:completions"]}`,
+	"cli-session.json":                  `{"accessToken":"at","startUrl":"https://b.awsapps.com/start","clientId":"cid-kiro"}`,
+	"botocore-client-id-us-east-1.json": `{"clientId":"cid-cli","clientSecret":"cs","scopes":["sso:account:access"]}`,
+	"broken.json":                       `{`,
+}
+
+// TestClassify 測試 Kiro token、Kiro client 註冊檔與第三方檔案的分類
+func TestClassify(t *testing.T) {
+	files := make(map[string][]byte)
+	for name, content := range classifyTestFiles {
+		files[name] = []byte(content)
+	}
+
+	got := make(map[string]CacheFileKind)
+	for _, f := range Classify(files) {
+		got[f.Name] = f.Kind
+	}
+	want := map[string]CacheFileKind{
+		KiroAuthTokenFile:                   CacheFileKiroToken,
+		"abc123.json":                       CacheFileKiroClient,
+		"kiro-dup-registration.json":        CacheFileKiroClient,
+		"orphan-kiro.json":                  CacheFileKiroClient,
+		"cli-session.json":                  CacheFileThirdParty,
+		"botocore-client-id-us-east-1.json": CacheFileThirdParty,
+		"broken.json":                       CacheFileThirdParty,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Classify = %v, want %v", got, want)
+	}
+}
+
+// TestClearKiroCacheFiles 測試只清除 Kiro 的檔案，並可移至資源回收區
+func TestClearKiroCacheFiles(t *testing.T) {
+	for _, useTrash := range []bool{false, true} {
+		cachePath := t.TempDir()
+		for name, content := range classifyTestFiles {
+			if err := os.WriteFile(filepath.Join(cachePath, name), []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var trashDir string
+		if useTrash {
+			trashDir = filepath.Join(t.TempDir(), "trash", "sso-cache")
+		}
+
+		removed, err := clearKiroCacheFiles(cachePath, trashDir)
+		if err != nil {
+			t.Fatalf("trash=%v: %v", useTrash, err)
+		}
+		wantRemoved := []string{"abc123.json", "kiro-auth-token.json", "kiro-dup-registration.json", "orphan-kiro.json"}
+		if !reflect.DeepEqual(removed, wantRemoved) {
+			t.Errorf("trash=%v: removed = %v", useTrash, removed)
+		}
+
+		for name, content := range classifyTestFiles {
+			_, err := os.Stat(filepath.Join(cachePath, name))
+			kept := err == nil
+			wantKept := !contains(wantRemoved, name)
+			if kept != wantKept {
+				t.Errorf("trash=%v: %s kept = %v", useTrash, name, kept)
+			}
+			if useTrash && !wantKept {
+				data, err := os.ReadFile(filepath.Join(trashDir, name))
+				if err != nil || string(data) != content {
+					t.Errorf("trash=%v: %s not recoverable: %v", useTrash, name, err)
+				}
+			}
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
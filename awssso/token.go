@@ -0,0 +1,259 @@
+package awssso
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// KiroTimeLayout Kiro 寫入 expiresAt 時使用的格式（UTC 毫秒）
+const KiroTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// ErrInvalidToken token 檔案不是 JSON 物件
+var ErrInvalidToken = errors.New("kiro auth token must be a JSON object")
+
+// KiroAuthToken 代表 Kiro 的認證 token（kiro-auth-token.json）
+// 讀取時保留所有欄位與原始 key 順序，寫回時只改動被修改的欄位，未知欄位原樣保留
+type KiroAuthToken struct {
+	AccessToken  string
+	ExpiresAt    time.Time // 無法解析或缺少時為零值
+	Provider     string
+	AuthMethod   string
+	RefreshToken string
+	TokenType    string
+	Region       string
+	StartURL     string
+	ProfileArn   string
+	ClientIdHash string // BuilderId (IdC) 用於關聯 clientId/clientSecret 文件
+
+	keys []string                   // 原始 key 順序
+	raw  map[string]json.RawMessage // 原始值（含未知欄位）
+}
+
+// tokenStringKeys 字串欄位的 JSON key，順序即新欄位寫入的順序
+var tokenStringKeys = []string{
+	"accessToken", "refreshToken", "profileArn", "authMethod", "provider",
+	"tokenType", "region", "startUrl", "clientIdHash",
+}
+
+// stringField 回傳 key 對應的字串欄位
+func (t *KiroAuthToken) stringField(key string) *string {
+	switch key {
+	case "accessToken":
+		return &t.AccessToken
+	case "refreshToken":
+		return &t.RefreshToken
+	case "profileArn":
+		return &t.ProfileArn
+	case "authMethod":
+		return &t.AuthMethod
+	case "provider":
+		return &t.Provider
+	case "tokenType":
+		return &t.TokenType
+	case "region":
+		return &t.Region
+	case "startUrl":
+		return &t.StartURL
+	case "clientIdHash":
+		return &t.ClientIdHash
+	}
+	return nil
+}
+
+// ParseKiroAuthToken 解析 kiro-auth-token.json 的內容
+func ParseKiroAuthToken(data []byte) (*KiroAuthToken, error) {
+	var token KiroAuthToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// UnmarshalJSON 解析 token 並記錄 key 順序與原始值
+func (t *KiroAuthToken) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return ErrInvalidToken
+	}
+
+	parsed := KiroAuthToken{raw: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if _, dup := parsed.raw[key]; !dup {
+			parsed.keys = append(parsed.keys, key)
+		}
+		parsed.raw[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	// 型別不符的已知欄位保持零值，寫回時沿用原始內容
+	for _, key := range tokenStringKeys {
+		if value, ok := parsed.raw[key]; ok {
+			_ = json.Unmarshal(value, parsed.stringField(key))
+		}
+	}
+	parsed.ExpiresAt = parsed.rawExpiresAt()
+
+	*t = parsed
+	return nil
+}
+
+// rawExpiresAt 解析原始的 expiresAt，無法解析時回傳零值
+func (t *KiroAuthToken) rawExpiresAt() time.Time {
+	var s string
+	if err := json.Unmarshal(t.raw["expiresAt"], &s); err != nil || s == "" {
+		return time.Time{}
+	}
+	// RFC3339 同時接受秒與毫秒格式
+	expiresAt, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiresAt
+}
+
+// MarshalJSON 依原始 key 順序輸出；未修改的欄位沿用原始內容，新欄位附加在最後
+func (t KiroAuthToken) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	written := make(map[string]bool, len(t.keys)+len(tokenStringKeys)+1)
+	write := func(key string, value []byte) {
+		if len(written) > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+		written[key] = true
+	}
+
+	for _, key := range t.keys {
+		value, err := t.encodeField(key)
+		if err != nil {
+			return nil, err
+		}
+		write(key, value)
+	}
+
+	// 原始內容沒有的欄位：依 accessToken、refreshToken、profileArn、expiresAt、authMethod… 的順序附加
+	for _, key := range tokenStringKeys {
+		if key == "authMethod" && !written["expiresAt"] && !t.ExpiresAt.IsZero() {
+			value, _ := t.encodeField("expiresAt")
+			write("expiresAt", value)
+		}
+		if !written[key] && *t.stringField(key) != "" {
+			value, _ := json.Marshal(*t.stringField(key))
+			write(key, value)
+		}
+	}
+	if !written["expiresAt"] && !t.ExpiresAt.IsZero() {
+		value, _ := t.encodeField("expiresAt")
+		write("expiresAt", value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encodeField 輸出單一欄位：值未變動時回傳原始內容，否則重新編碼
+func (t *KiroAuthToken) encodeField(key string) ([]byte, error) {
+	original, hasOriginal := t.raw[key]
+
+	if key == "expiresAt" {
+		if hasOriginal && t.ExpiresAt.Equal(t.rawExpiresAt()) {
+			return original, nil
+		}
+		if t.ExpiresAt.IsZero() {
+			return []byte(`""`), nil
+		}
+		return json.Marshal(t.ExpiresAt.UTC().Format(KiroTimeLayout))
+	}
+
+	field := t.stringField(key)
+	if field == nil {
+		if !hasOriginal {
+			return nil, fmt.Errorf("unknown token field %q", key)
+		}
+		return original, nil
+	}
+	if hasOriginal {
+		var s string
+		err := json.Unmarshal(original, &s)
+		if err == nil && s == *field {
+			return original, nil
+		}
+		if err != nil && *field == "" {
+			// 原始值不是字串且未被修改
+			return original, nil
+		}
+	}
+	return json.Marshal(*field)
+}
+
+// Marshal 輸出與 Kiro 相同縮排格式的 JSON
+func (t *KiroAuthToken) Marshal() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// ExpiresAtString 回傳 expiresAt 的字串：未修改時為檔案中的原始字串，否則為 Kiro 的 UTC 毫秒格式
+func (t *KiroAuthToken) ExpiresAtString() string {
+	if t == nil {
+		return ""
+	}
+	if original, ok := t.raw["expiresAt"]; ok && t.ExpiresAt.Equal(t.rawExpiresAt()) {
+		var s string
+		if json.Unmarshal(original, &s) == nil {
+			return s
+		}
+	}
+	if t.ExpiresAt.IsZero() {
+		return ""
+	}
+	return t.ExpiresAt.UTC().Format(KiroTimeLayout)
+}
+
+// AccountID 回傳 token 所屬使用者的識別（accessToken 為 JWT 時的 sub，沒有時為 email）
+// profileArn 與 clientIdHash 分別代表 Kiro 服務與用戶端註冊，不能用來區分使用者；
+// 不透明的 accessToken 沒有使用者識別，此時回傳空字串
+func (t *KiroAuthToken) AccountID() string {
+	if t == nil {
+		return ""
+	}
+	parts := strings.Split(t.AccessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Sub   string `json:"sub"`
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	if claims.Sub != "" {
+		return claims.Sub
+	}
+	return strings.ToLower(claims.Email)
+}
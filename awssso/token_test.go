@@ -0,0 +1,86 @@
+package awssso
+
+import (
+	"testing"
+	"time"
+)
+
+// TestKiroAuthToken_RoundTrip 測試未修改的 token 寫回時內容與 key 順序不變，未知欄位原樣保留
+func TestKiroAuthToken_RoundTrip(t *testing.T) {
+	original := `{"provider":"BuilderId","expiresAt":"2025-12-09T15:30:00.123Z","accessToken":"at","x-extra":{"nested":[1,2]},"refreshToken":"rt","region":"us-east-1"}`
+	token, err := ParseKiroAuthToken([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 12, 9, 15, 30, 0, 123e6, time.UTC); !token.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", token.ExpiresAt, want)
+	}
+	data, err := token.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Errorf("round trip = %s", data)
+	}
+}
+
+// TestKiroAuthToken_Update 測試只改寫被修改的欄位，新欄位附加在最後
+func TestKiroAuthToken_Update(t *testing.T) {
+	token, err := ParseKiroAuthToken([]byte(`{"refreshToken":"rt","expiresAt":"2025-12-09T15:30:00Z","custom":true,"accessToken":"old"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.AccessToken = "new"
+	token.ExpiresAt = time.Date(2025, 12, 10, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	token.ProfileArn = "arn:aws:kiro::1:profile/p"
+
+	data, err := token.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"refreshToken":"rt","expiresAt":"2025-12-10T00:00:00.000Z","custom":true,"accessToken":"new","profileArn":"arn:aws:kiro::1:profile/p"}`
+	if string(data) != want {
+		t.Errorf("updated = %s, want %s", data, want)
+	}
+	if got := token.ExpiresAtString(); got != "2025-12-10T00:00:00.000Z" {
+		t.Errorf("ExpiresAtString = %q", got)
+	}
+}
+
+// TestKiroAuthToken_New 測試新建的 token 依 Kiro 的 key 順序輸出
+func TestKiroAuthToken_New(t *testing.T) {
+	token := &KiroAuthToken{
+		AccessToken:  "at",
+		RefreshToken: "rt",
+		ExpiresAt:    time.Date(2025, 12, 9, 15, 30, 0, 0, time.UTC),
+		AuthMethod:   "social",
+		Provider:     "Github",
+	}
+	data, err := token.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"accessToken":"at","refreshToken":"rt","expiresAt":"2025-12-09T15:30:00.000Z","authMethod":"social","provider":"Github"}`
+	if string(data) != want {
+		t.Errorf("new token = %s, want %s", data, want)
+	}
+}
+
+// TestKiroAuthToken_InvalidExpiresAt 測試無法解析的 expiresAt 視為過期且寫回時保留原值
+func TestKiroAuthToken_InvalidExpiresAt(t *testing.T) {
+	original := `{"accessToken":"at","expiresAt":"soon"}`
+	token, err := ParseKiroAuthToken([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !token.ExpiresAt.IsZero() || !IsTokenExpired(token) {
+		t.Errorf("ExpiresAt = %v, expired = %v", token.ExpiresAt, IsTokenExpired(token))
+	}
+	if data, _ := token.MarshalJSON(); string(data) != original {
+		t.Errorf("round trip = %s", data)
+	}
+
+	if _, err := ParseKiroAuthToken([]byte(`[1]`)); err == nil {
+		t.Error("expected error for non-object token")
+	}
+}
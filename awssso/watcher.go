@@ -0,0 +1,204 @@
+package awssso
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TokenEventKind kiro-auth-token.json 變化的種類
+type TokenEventKind string
+
+const (
+	TokenEventLogin         TokenEventKind = "login"         // 原本沒有 token，登入後建立
+	TokenEventLogout        TokenEventKind = "logout"        // token 檔案被刪除
+	TokenEventRefresh       TokenEventKind = "refresh"       // 同一帳號的 token 刷新（accessToken、expiresAt 與 refreshToken 更新）
+	TokenEventAccountChange TokenEventKind = "accountChange" // 換成另一個帳號的 token
+)
+
+const (
+	// DefaultPollInterval 輪詢間隔；檔案系統通知可用時輪詢只作為備援
+	DefaultPollInterval = 5 * time.Second
+	// defaultDebounce 檔案事件後等待寫入完成的時間，避免讀到寫到一半的檔案
+	defaultDebounce = 300 * time.Millisecond
+)
+
+// TokenEvent kiro-auth-token.json 的一次變化
+type TokenEvent struct {
+	Kind     TokenEventKind
+	Previous *KiroAuthToken // 登入時為 nil
+	Current  *KiroAuthToken // 登出時為 nil
+}
+
+// SameAccount 判斷兩個 token 是否屬於同一帳號
+// 以登入方式、startUrl 與使用者識別（AccountID）判斷；refreshToken 會在刷新時換發，
+// profileArn 與 clientIdHash 為多個使用者共用，都不列入比對。
+// 任一方沒有使用者識別時無法確認，一律視為不同帳號
+func SameAccount(a, b *KiroAuthToken) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Provider != b.Provider || a.AuthMethod != b.AuthMethod || a.StartURL != b.StartURL {
+		return false
+	}
+	id := a.AccountID()
+	return id != "" && id == b.AccountID()
+}
+
+// diffTokens 比較前後兩個 token，沒有值得通知的變化時回傳 false
+func diffTokens(prev, cur *KiroAuthToken) (TokenEvent, bool) {
+	event := TokenEvent{Previous: prev, Current: cur}
+	switch {
+	case prev == nil && cur == nil:
+		return event, false
+	case prev == nil:
+		event.Kind = TokenEventLogin
+	case cur == nil:
+		event.Kind = TokenEventLogout
+	case prev.AccessToken == cur.AccessToken && prev.RefreshToken == cur.RefreshToken &&
+		prev.ExpiresAt.Equal(cur.ExpiresAt) && prev.Provider == cur.Provider:
+		// 憑證沒有變化（例如檔案被重新寫入），沒有使用者識別時也不視為切換帳號
+		return event, false
+	case SameAccount(prev, cur):
+		event.Kind = TokenEventRefresh
+	default:
+		event.Kind = TokenEventAccountChange
+	}
+	return event, true
+}
+
+// Watcher 監看 kiro-auth-token.json，偵測登入、登出、刷新與切換帳號
+// 優先使用檔案系統通知；無法使用或 SSO cache 目錄尚未建立時，改由定期輪詢偵測
+type Watcher struct {
+	Path         string
+	PollInterval time.Duration // 0 時使用 DefaultPollInterval
+	Debounce     time.Duration // 0 時使用 defaultDebounce
+
+	pollOnly bool // 測試用：停用檔案系統通知
+
+	loaded   bool // 是否已讀取基準狀態
+	last     *KiroAuthToken
+	lastData []byte
+}
+
+// NewWatcher 建立監看目前 SSO cache 中 kiro-auth-token.json 的 Watcher
+func NewWatcher() (*Watcher, error) {
+	tokenPath, err := GetKiroAuthTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{Path: tokenPath}, nil
+}
+
+// Run 監看 token 直到 ctx 結束，每次偵測到變化時呼叫 onEvent
+// 啟動時的 token 視為基準狀態，不會發送事件
+func (w *Watcher) Run(ctx context.Context, onEvent func(TokenEvent)) error {
+	pollInterval := w.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+
+	if !w.loaded {
+		w.check(nil)
+	}
+
+	var fsw *fsnotify.Watcher
+	if !w.pollOnly {
+		// 建立失敗時（例如 inotify 數量已達上限）只使用輪詢
+		if created, err := fsnotify.NewWatcher(); err == nil {
+			fsw = created
+			defer fsw.Close()
+		}
+	}
+
+	// 監看所在目錄而非檔案本身：Kiro 以取代檔案的方式寫入，且登出時會刪除檔案
+	dir := filepath.Dir(w.Path)
+	var fsEvents <-chan fsnotify.Event
+	var fsErrors <-chan error
+	watching := false
+	addWatch := func() {
+		if fsw == nil || watching {
+			return
+		}
+		if fsw.Add(dir) == nil {
+			watching = true
+			fsEvents, fsErrors = fsw.Events, fsw.Errors
+		}
+	}
+	addWatch()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fsEvents:
+			if !ok {
+				fsEvents = nil
+				continue
+			}
+			if ev.Name == dir && ev.Has(fsnotify.Remove|fsnotify.Rename) {
+				// 目錄被刪除後監看失效，等目錄重新建立時再加回
+				watching = false
+				fsEvents, fsErrors = nil, nil
+			}
+			if ev.Name == dir || filepath.Base(ev.Name) == KiroAuthTokenFile {
+				timer.Reset(debounce)
+			}
+		case _, ok := <-fsErrors:
+			// 通知遺失時由輪詢補上
+			if !ok {
+				fsErrors = nil
+			}
+		case <-timer.C:
+			w.check(onEvent)
+		case <-ticker.C:
+			addWatch()
+			w.check(onEvent)
+		}
+	}
+}
+
+// check 讀取 token 並與上次的狀態比較，onEvent 為 nil 時只更新基準狀態
+func (w *Watcher) check(onEvent func(TokenEvent)) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		data = nil
+	}
+	if w.loaded && (data == nil) == (w.lastData == nil) && bytes.Equal(data, w.lastData) {
+		return
+	}
+
+	var cur *KiroAuthToken
+	if data != nil {
+		if cur, err = ParseKiroAuthToken(data); err != nil {
+			// 檔案可能正在寫入，等下一次事件或輪詢
+			return
+		}
+	}
+
+	prev := w.last
+	w.last, w.lastData, w.loaded = cur, data, true
+	if onEvent == nil {
+		return
+	}
+	if event, ok := diffTokens(prev, cur); ok {
+		onEvent(event)
+	}
+}
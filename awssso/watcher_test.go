@@ -0,0 +1,141 @@
+package awssso
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testAccessToken 建立 payload 含指定 claims 的 JWT 形式 accessToken（不含有效簽章）
+func testAccessToken(claims string) string {
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
+
+// TestDiffTokens 測試登入、登出、刷新與切換帳號的判斷
+func TestDiffTokens(t *testing.T) {
+	expires := time.Date(2025, 12, 9, 15, 30, 0, 0, time.UTC)
+	arn := "arn:aws:codewhisperer:us-east-1:000000000000:profile/KIRO"
+	a := &KiroAuthToken{AccessToken: testAccessToken(`{"sub":"user-a","iat":1}`), RefreshToken: "rt-a", Provider: "Github", ProfileArn: arn, ExpiresAt: expires}
+	aRefreshed := &KiroAuthToken{AccessToken: testAccessToken(`{"sub":"user-a","iat":2}`), RefreshToken: "rt-a-2", Provider: "Github", ProfileArn: arn, ExpiresAt: expires.Add(time.Hour)}
+	b := &KiroAuthToken{AccessToken: testAccessToken(`{"sub":"user-b","iat":1}`), RefreshToken: "rt-b", Provider: "Github", ProfileArn: arn, ExpiresAt: expires}
+	opaque := &KiroAuthToken{AccessToken: "aoa-1", RefreshToken: "rt-c", Provider: "Github", ProfileArn: arn, ExpiresAt: expires}
+	opaqueRefreshed := &KiroAuthToken{AccessToken: "aoa-2", RefreshToken: "rt-c-2", Provider: "Github", ProfileArn: arn, ExpiresAt: expires.Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		prev, cur *KiroAuthToken
+		want      TokenEventKind
+		notify    bool
+	}{
+		{"nothing", nil, nil, "", false},
+		{"login", nil, a, TokenEventLogin, true},
+		{"logout", a, nil, TokenEventLogout, true},
+		{"refresh", a, aRefreshed, TokenEventRefresh, true},
+		{"unchanged", a, a, "", false},
+		{"unchanged without identity", opaque, opaque, "", false},
+		{"account change", a, b, TokenEventAccountChange, true},
+		{"no identity", opaque, opaqueRefreshed, TokenEventAccountChange, true},
+	}
+	for _, tt := range tests {
+		event, ok := diffTokens(tt.prev, tt.cur)
+		if ok != tt.notify || (ok && event.Kind != tt.want) {
+			t.Errorf("%s: diffTokens = %v, %v", tt.name, event.Kind, ok)
+		}
+	}
+}
+
+// TestSameAccount 測試以使用者識別判斷帳號：共用 profileArn 與 clientIdHash 的不同使用者視為不同帳號
+func TestSameAccount(t *testing.T) {
+	arn := "arn:aws:codewhisperer:us-east-1:000000000000:profile/KIRO"
+	social := func(claims, refreshToken string) *KiroAuthToken {
+		return &KiroAuthToken{AccessToken: testAccessToken(claims), RefreshToken: refreshToken, Provider: "Google", AuthMethod: "social", ProfileArn: arn}
+	}
+	idc := func(claims, refreshToken string) *KiroAuthToken {
+		return &KiroAuthToken{AccessToken: testAccessToken(claims), RefreshToken: refreshToken, Provider: "BuilderId", AuthMethod: "IdC",
+			StartURL: "https://view.awsapps.com/start", ProfileArn: arn, ClientIdHash: "hash-1"}
+	}
+
+	tests := []struct {
+		name string
+		a, b *KiroAuthToken
+		want bool
+	}{
+		{"social refresh token rotated", social(`{"sub":"user-a"}`, "rt-1"), social(`{"sub":"user-a"}`, "rt-2"), true},
+		{"idc refresh token rotated", idc(`{"sub":"user-a"}`, "rt-1"), idc(`{"sub":"user-a"}`, "rt-2"), true},
+		{"email identity", social(`{"email":"A@example.com"}`, "rt-1"), social(`{"email":"a@example.com"}`, "rt-2"), true},
+		{"different users sharing profileArn", social(`{"sub":"user-a"}`, "rt-1"), social(`{"sub":"user-b"}`, "rt-1"), false},
+		{"different users sharing clientIdHash", idc(`{"sub":"user-a"}`, "rt-1"), idc(`{"sub":"user-b"}`, "rt-1"), false},
+		{"different provider", social(`{"sub":"user-a"}`, "rt-1"), &KiroAuthToken{AccessToken: testAccessToken(`{"sub":"user-a"}`), Provider: "Github", AuthMethod: "social", ProfileArn: arn}, false},
+		{"opaque access token", &KiroAuthToken{AccessToken: "aoa", RefreshToken: "rt-1", Provider: "Google", ProfileArn: arn}, &KiroAuthToken{AccessToken: "aoa", RefreshToken: "rt-1", Provider: "Google", ProfileArn: arn}, false},
+		{"nil", social(`{"sub":"user-a"}`, "rt-1"), nil, false},
+	}
+	for _, tt := range tests {
+		if got := SameAccount(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: SameAccount = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestWatcher_Run 測試檔案系統通知與輪詢兩種模式都能偵測到 token 變化
+func TestWatcher_Run(t *testing.T) {
+	for _, pollOnly := range []bool{false, true} {
+		dir := filepath.Join(t.TempDir(), "sso", "cache")
+		w := &Watcher{
+			Path:         filepath.Join(dir, KiroAuthTokenFile),
+			PollInterval: 50 * time.Millisecond,
+			Debounce:     10 * time.Millisecond,
+			pollOnly:     pollOnly,
+		}
+
+		// 先讀取基準狀態（目錄尚不存在），Run 啟動時不會再視為變化
+		w.check(nil)
+		events := make(chan TokenEvent, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- w.Run(ctx, func(e TokenEvent) { events <- e }) }()
+
+		expect := func(step string, want TokenEventKind) {
+			t.Helper()
+			select {
+			case e := <-events:
+				if e.Kind != want {
+					t.Errorf("pollOnly=%v %s: got %s, want %s", pollOnly, step, e.Kind, want)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("pollOnly=%v %s: no event", pollOnly, step)
+			}
+		}
+		write := func(content string) {
+			t.Helper()
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(w.Path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		token := func(claims, refreshToken, provider string) string {
+			return fmt.Sprintf(`{"accessToken":%q,"refreshToken":%q,"provider":%q}`, testAccessToken(claims), refreshToken, provider)
+		}
+		write(token(`{"sub":"user-a","iat":1}`, "rt-a", "Github"))
+		expect("login", TokenEventLogin)
+		write(token(`{"sub":"user-a","iat":2}`, "rt-a-2", "Github"))
+		expect("refresh", TokenEventRefresh)
+		write(token(`{"sub":"user-b","iat":1}`, "rt-b", "Google"))
+		expect("account change", TokenEventAccountChange)
+		if err := os.Remove(w.Path); err != nil {
+			t.Fatal(err)
+		}
+		expect("logout", TokenEventLogout)
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run = %v", err)
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kiro-manager/sysenv"
+)
+
+const (
+	// ArchiveFormat 封存檔 manifest 的格式識別
+	ArchiveFormat = "kiro-manager-archive"
+	// ArchiveVersion 目前的封存檔版本
+	ArchiveVersion = 1
+	// ArchiveManifestName 封存檔中的 manifest 檔名
+	ArchiveManifestName = "manifest.json"
+
+	// archiveBackupsDir 封存檔中存放備份的目錄
+	archiveBackupsDir = "backups"
+	// archiveKeySource 封存檔內加密檔案的金鑰來源標記
+	archiveKeySource = "archive-passphrase"
+	// archiveKDF 封存檔密語使用的金鑰推導函式
+	archiveKDF = "scrypt"
+	// maxArchiveSize 匯入時允許的封存檔大小上限
+	maxArchiveSize = 64 << 20
+)
+
+// 匯入時名稱衝突的處理方式
+const (
+	ConflictSkip      = "skip"      // 略過已存在的備份（預設）
+	ConflictOverwrite = "overwrite" // 以封存檔內容取代已存在的備份
+	ConflictRename    = "rename"    // 以新名稱匯入（name-2、name-3…）
+)
+
+// 匯入結果
+const (
+	ImportActionCreated     = "created"
+	ImportActionOverwritten = "overwritten"
+	ImportActionRenamed     = "renamed"
+	ImportActionSkipped     = "skipped"
+)
+
+var (
+	ErrInvalidArchive      = errors.New("invalid backup archive")
+	ErrUnsupportedArchive  = errors.New("unsupported backup archive version")
+	ErrArchiveChecksum     = errors.New("backup archive checksum mismatch")
+	ErrInvalidConflictMode = errors.New("invalid conflict mode")
+)
+
+// archivePlainFiles 匯出時一併帶走的非憑證檔案
+var archivePlainFiles = []string{MachineIDFileName, UsageCacheFileName, UsageHistoryFileName, MetaFileName}
+
+// ArchiveManifest 封存檔的 manifest.json
+type ArchiveManifest struct {
+	Format    string          `json:"format"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Encrypted bool            `json:"encrypted"`          // 檔案內容是否以密語加密
+	KDF       string          `json:"kdf,omitempty"`      // 密語的金鑰推導函式
+	Salt      string          `json:"salt,omitempty"`     // 金鑰推導的 salt（base64）
+	Verifier  string          `json:"verifier,omitempty"` // 用於驗證密語的加密字串
+	Backups   []ArchiveBackup `json:"backups"`
+}
+
+// ArchiveBackup 封存檔中的單一備份
+type ArchiveBackup struct {
+	Name  string        `json:"name"`
+	Files []ArchiveFile `json:"files"`
+}
+
+// ArchiveFile 封存檔中的單一檔案
+type ArchiveFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`   // 封存檔中內容的大小（加密時為密文）
+	SHA256 string `json:"sha256"` // 封存檔中內容的 SHA-256（加密時為密文）
+}
+
+// ExportOptions 匯出選項
+type ExportOptions struct {
+	Passphrase string // 非空時以密語加密封存檔內的所有檔案
+}
+
+// ImportOptions 匯入選項
+type ImportOptions struct {
+	Passphrase string // 加密封存檔的密語
+	OnConflict string // 名稱衝突的處理方式（skip / overwrite / rename），空字串為 skip
+}
+
+// ImportedBackup 單一備份的匯入結果
+type ImportedBackup struct {
+	Name       string `json:"name"`       // 匯入後的備份名稱
+	SourceName string `json:"sourceName"` // 封存檔中的備份名稱
+	Action     string `json:"action"`     // created / overwritten / renamed / skipped
+}
+
+// Export 將指定備份匯出為單一封存檔
+// 憑證檔案會先以本機金鑰解密，再依 opts 決定是否以密語重新加密
+func Export(names []string, w io.Writer, opts ExportOptions) error {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return err
+	}
+	return exportArchive(rootPath, names, w, opts)
+}
+
+// Import 從封存檔匯入備份
+// 發生錯誤時，已匯入的備份會保留並連同錯誤一起返回
+func Import(r io.Reader, opts ImportOptions) ([]ImportedBackup, error) {
+	data, err := readArchiveData(r)
+	if err != nil {
+		return nil, err
+	}
+	rootPath, err := ensureBackupRoot()
+	if err != nil {
+		return nil, err
+	}
+	return importArchive(rootPath, data, opts)
+}
+
+// InspectArchive 讀取封存檔的 manifest（不需要密語）
+func InspectArchive(r io.Reader) (*ArchiveManifest, error) {
+	data, err := readArchiveData(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return readManifest(zr)
+}
+
+// readArchiveData 讀取整個封存檔（超過大小上限視為無效）
+func readArchiveData(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArchiveSize {
+		return nil, fmt.Errorf("%w: archive exceeds %d bytes", ErrInvalidArchive, maxArchiveSize)
+	}
+	return data, nil
+}
+
+// exportArchive 將 rootPath 下的備份寫入封存檔
+func exportArchive(rootPath string, names []string, w io.Writer, opts ExportOptions) error {
+	if len(names) == 0 {
+		return ErrInvalidBackupName
+	}
+
+	manifest := ArchiveManifest{
+		Format:    ArchiveFormat,
+		Version:   ArchiveVersion,
+		CreatedAt: sysenv.Now().UTC(),
+		Backups:   []ArchiveBackup{},
+	}
+
+	var key []byte
+	if opts.Passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		var err error
+		if key, err = deriveKey(opts.Passphrase, salt); err != nil {
+			return err
+		}
+		verifier, err := seal(key, archiveKeySource, []byte(verifierPlaintext))
+		if err != nil {
+			return err
+		}
+		manifest.Encrypted = true
+		manifest.KDF = archiveKDF
+		manifest.Salt = base64.StdEncoding.EncodeToString(salt)
+		manifest.Verifier = string(verifier)
+	}
+
+	// 先讀取所有內容，manifest 放在封存檔最前面
+	contents := map[string][]byte{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		n, err := NewBackupName(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		backupPath := n.dir(rootPath)
+		if info, err := os.Stat(backupPath); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s: %w", name, ErrBackupNotFound)
+		}
+
+		entry := ArchiveBackup{Name: name, Files: []ArchiveFile{}}
+		for _, fileName := range archiveFileNames(backupPath) {
+			data, err := readArchiveSource(filepath.Join(backupPath, fileName))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return fmt.Errorf("%s/%s: %w", name, fileName, err)
+			}
+			if key != nil {
+				if data, err = seal(key, archiveKeySource, data); err != nil {
+					return err
+				}
+			}
+
+			sum := sha256.Sum256(data)
+			entry.Files = append(entry.Files, ArchiveFile{
+				Name:   fileName,
+				Size:   int64(len(data)),
+				SHA256: hex.EncodeToString(sum[:]),
+			})
+			contents[archiveEntryPath(name, fileName)] = data
+		}
+		manifest.Backups = append(manifest.Backups, entry)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeZipEntry(zw, ArchiveManifestName, manifestData, manifest.CreatedAt); err != nil {
+		return err
+	}
+	for _, b := range manifest.Backups {
+		for _, f := range b.Files {
+			entryPath := archiveEntryPath(b.Name, f.Name)
+			if err := writeZipEntry(zw, entryPath, contents[entryPath], manifest.CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// importArchive 將封存檔內容匯入 rootPath
+func importArchive(rootPath string, data []byte, opts ImportOptions) ([]ImportedBackup, error) {
+	mode := opts.OnConflict
+	if mode == "" {
+		mode = ConflictSkip
+	}
+	if mode != ConflictSkip && mode != ConflictOverwrite && mode != ConflictRename {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidConflictMode, opts.OnConflict)
+	}
+
+	manifest, contents, err := readArchive(data, opts.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []ImportedBackup{}
+	for _, b := range manifest.Backups {
+		result := ImportedBackup{Name: b.Name, SourceName: b.Name, Action: ImportActionCreated}
+		replace := false
+		// 名稱已在 readManifest 驗證過，衝突檢查不分大小寫
+		if existing, ok := findBackupFold(rootPath, BackupName(b.Name)); ok {
+			switch mode {
+			case ConflictSkip:
+				result.Action = ImportActionSkipped
+				result.Name = existing
+				results = append(results, result)
+				continue
+			case ConflictOverwrite:
+				// 覆蓋時沿用既有備份的名稱
+				result.Action = ImportActionOverwritten
+				result.Name = existing
+				replace = true
+			case ConflictRename:
+				result.Action = ImportActionRenamed
+				result.Name = uniqueBackupName(rootPath, b.Name)
+			}
+		}
+
+		if err := writeImportedBackup(rootPath, result.Name, contents[b.Name], replace); err != nil {
+			return results, fmt.Errorf("%s: %w", b.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// readArchive 解析封存檔並驗證所有檔案的 checksum，返回各備份的明文內容
+func readArchive(data []byte, passphrase string) (*ArchiveManifest, map[string]map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	manifest, err := readManifest(zr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var key []byte
+	if manifest.Encrypted {
+		if manifest.KDF != archiveKDF {
+			return nil, nil, fmt.Errorf("%w: unsupported kdf %q", ErrUnsupportedArchive, manifest.KDF)
+		}
+		info := &encryptionInfo{Salt: manifest.Salt, Verifier: manifest.Verifier}
+		if key, err = deriveAndVerify(info, passphrase); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+
+	contents := map[string]map[string][]byte{}
+	for _, b := range manifest.Backups {
+		files := map[string][]byte{}
+		for _, f := range b.Files {
+			entryPath := archiveEntryPath(b.Name, f.Name)
+			zf, ok := entries[entryPath]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, entryPath)
+			}
+			content, err := readZipEntry(zf, f.Size)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", entryPath, err)
+			}
+			sum := sha256.Sum256(content)
+			if int64(len(content)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+				return nil, nil, fmt.Errorf("%w: %s", ErrArchiveChecksum, entryPath)
+			}
+
+			if key != nil {
+				var envelope encryptedEnvelope
+				if err := json.Unmarshal(content, &envelope); err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", entryPath, ErrDecryptFailed)
+				}
+				if content, err = open(key, &envelope); err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", entryPath, err)
+				}
+			}
+			files[f.Name] = content
+		}
+		contents[b.Name] = files
+	}
+	return manifest, contents, nil
+}
+
+// readManifest 讀取並驗證 manifest.json
+func readManifest(zr *zip.Reader) (*ArchiveManifest, error) {
+	var manifestFile *zip.File
+	for _, f := range zr.File {
+		if f.Name == ArchiveManifestName {
+			manifestFile = f
+			break
+		}
+	}
+	if manifestFile == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, ArchiveManifestName)
+	}
+
+	data, err := readZipEntry(manifestFile, -1)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if manifest.Format != ArchiveFormat || manifest.Version < 1 {
+		return nil, ErrInvalidArchive
+	}
+	if manifest.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedArchive, manifest.Version)
+	}
+
+	seen := map[string]bool{}
+	for _, b := range manifest.Backups {
+		key := strings.ToLower(b.Name)
+		if _, err := NewBackupName(b.Name); err != nil || seen[key] {
+			return nil, fmt.Errorf("%w: invalid backup name %q", ErrInvalidArchive, b.Name)
+		}
+		seen[key] = true
+		for _, f := range b.Files {
+			if !validArchiveFileName(f.Name) {
+				return nil, fmt.Errorf("%w: invalid file name %q", ErrInvalidArchive, f.Name)
+			}
+		}
+	}
+	return &manifest, nil
+}
+
+// writeImportedBackup 先寫入暫存目錄再改名，避免留下不完整的備份
+// replace 為 true 時取代已存在的備份
+func writeImportedBackup(rootPath, name string, files map[string][]byte, replace bool) error {
+	staging, err := os.MkdirTemp(rootPath, ".import-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	for fileName, data := range files {
+		filePath := filepath.Join(staging, fileName)
+		if isCredentialFileName(fileName) {
+			err = writeBackupFile(filePath, data)
+		} else {
+			err = atomicWriteFile(filePath, data, 0600)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", fileName, err)
+		}
+	}
+
+	finalPath := filepath.Join(rootPath, name)
+	if !replace {
+		return os.Rename(staging, finalPath)
+	}
+
+	old := staging + ".old"
+	if err := os.Rename(finalPath, old); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, finalPath); err != nil {
+		os.Rename(old, finalPath)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// archiveFileNames 匯出時包含的檔案（不保證存在）
+func archiveFileNames(backupPath string) []string {
+	return append(credentialFileNames(backupPath), archivePlainFiles...)
+}
+
+// readArchiveSource 讀取要匯出的檔案，憑證檔案會先解密
+func readArchiveSource(filePath string) ([]byte, error) {
+	if isCredentialFileName(filepath.Base(filePath)) {
+		return readBackupFile(filePath)
+	}
+	return os.ReadFile(filePath)
+}
+
+// validArchiveFileName 檢查封存檔中的檔名，避免路徑穿越
+// 備份名稱以 NewBackupName 驗證（原始備份只屬於建立它的機器，不允許匯出或匯入）
+func validArchiveFileName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\:`)
+}
+
+// uniqueBackupName 產生不與現有備份衝突的名稱（name-2、name-3…）
+func uniqueBackupName(rootPath, name string) string {
+	for i := 2; ; i++ {
+		// 加上後綴後仍需符合長度上限
+		suffix := fmt.Sprintf("-%d", i)
+		base := []rune(name)
+		if max := MaxBackupNameLength - len(suffix); len(base) > max {
+			base = []rune(strings.TrimRight(string(base[:max]), " ."))
+		}
+		candidate := string(base) + suffix
+		if _, exists := findBackupFold(rootPath, BackupName(candidate)); !exists {
+			return candidate
+		}
+	}
+}
+
+// dirExists 檢查目錄是否存在
+func dirExists(dirPath string) bool {
+	info, err := os.Stat(dirPath)
+	return err == nil && info.IsDir()
+}
+
+// archiveEntryPath 封存檔中檔案的路徑（zip 一律使用 /）
+func archiveEntryPath(backupName, fileName string) string {
+	return path.Join(archiveBackupsDir, backupName, fileName)
+}
+
+// writeZipEntry 寫入單一 zip 檔案
+func writeZipEntry(zw *zip.Writer, name string, data []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// readZipEntry 讀取單一 zip 檔案；size >= 0 時最多讀取 size+1 位元組，避免解壓縮炸彈
+func readZipEntry(f *zip.File, size int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	limit := int64(maxArchiveSize)
+	if size >= 0 && size < limit {
+		limit = size + 1
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return data, nil
+}
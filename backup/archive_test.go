@@ -0,0 +1,219 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createArchiveTestBackup 在 rootPath 下建立含憑證、Machine ID 與餘額緩存的備份
+func createArchiveTestBackup(t *testing.T, rootPath, name, refreshToken string) {
+	t.Helper()
+	backupPath := filepath.Join(rootPath, name)
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBackupFile(filepath.Join(backupPath, KiroAuthTokenFile), []byte(`{"refreshToken":"`+refreshToken+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBackupFile(filepath.Join(backupPath, "abc123.json"), []byte(`{"clientSecret":"cs-`+name+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(backupPath, MachineIDFileName), []byte(`{"machineId":"mid-`+name+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readPlain 讀取備份檔案的明文內容
+func readPlain(t *testing.T, filePath string) string {
+	t.Helper()
+	data, err := readArchiveSource(filePath)
+	if err != nil {
+		t.Fatalf("read %s: %v", filePath, err)
+	}
+	return string(data)
+}
+
+// TestArchive_RoundTrip 測試匯出後匯入，憑證在封存檔外仍為加密狀態
+func TestArchive_RoundTrip(t *testing.T) {
+	useFakeKeyring(t)
+	src, dst := t.TempDir(), t.TempDir()
+	createArchiveTestBackup(t, src, "work", "rt-work")
+
+	var buf bytes.Buffer
+	if err := exportArchive(src, []string{"work"}, &buf, ExportOptions{}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	results, err := importArchive(dst, buf.Bytes(), ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Action != ImportActionCreated {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	backupPath := filepath.Join(dst, "work")
+	if got := readPlain(t, filepath.Join(backupPath, KiroAuthTokenFile)); got != `{"refreshToken":"rt-work"}` {
+		t.Errorf("token = %q", got)
+	}
+	if got := readPlain(t, filepath.Join(backupPath, "abc123.json")); got != `{"clientSecret":"cs-work"}` {
+		t.Errorf("client secret = %q", got)
+	}
+	if got := readPlain(t, filepath.Join(backupPath, MachineIDFileName)); got != `{"machineId":"mid-work"}` {
+		t.Errorf("machine id = %q", got)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(backupPath, KiroAuthTokenFile)); bytes.Contains(raw, []byte("rt-work")) {
+		t.Error("imported token must be encrypted at rest")
+	}
+
+	if err := exportArchive(src, []string{OriginalBackupName}, io.Discard, ExportOptions{}); !errors.Is(err, ErrInvalidBackupName) {
+		t.Errorf("exporting the original backup should fail, got %v", err)
+	}
+	if err := exportArchive(src, []string{"missing"}, io.Discard, ExportOptions{}); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("expected ErrBackupNotFound, got %v", err)
+	}
+}
+
+// TestArchive_Passphrase 測試密語加密的封存檔
+func TestArchive_Passphrase(t *testing.T) {
+	useFakeKeyring(t)
+	src, dst := t.TempDir(), t.TempDir()
+	createArchiveTestBackup(t, src, "work", "rt-secret")
+
+	var buf bytes.Buffer
+	if err := exportArchive(src, []string{"work"}, &buf, ExportOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// zip 內容經過壓縮，逐一解開確認沒有明文
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		data, _ := readZipEntry(f, -1)
+		if bytes.Contains(data, []byte("rt-secret")) || bytes.Contains(data, []byte("mid-work")) {
+			t.Errorf("%s contains plaintext", f.Name)
+		}
+	}
+
+	manifest, err := InspectArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil || !manifest.Encrypted || len(manifest.Backups) != 1 {
+		t.Fatalf("unexpected manifest %+v (err %v)", manifest, err)
+	}
+
+	if _, err := importArchive(dst, buf.Bytes(), ImportOptions{}); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := importArchive(dst, buf.Bytes(), ImportOptions{Passphrase: "battery staple"}); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := importArchive(dst, buf.Bytes(), ImportOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if got := readPlain(t, filepath.Join(dst, "work", KiroAuthTokenFile)); got != `{"refreshToken":"rt-secret"}` {
+		t.Errorf("token = %q", got)
+	}
+}
+
+// TestArchive_Conflicts 測試名稱衝突時的 skip / overwrite / rename
+func TestArchive_Conflicts(t *testing.T) {
+	useFakeKeyring(t)
+	src, dst := t.TempDir(), t.TempDir()
+	createArchiveTestBackup(t, src, "work", "rt-new")
+	createArchiveTestBackup(t, dst, "work", "rt-old")
+
+	var buf bytes.Buffer
+	if err := exportArchive(src, []string{"work"}, &buf, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tokenPath := filepath.Join(dst, "work", KiroAuthTokenFile)
+
+	results, err := importArchive(dst, buf.Bytes(), ImportOptions{OnConflict: ConflictSkip})
+	if err != nil || results[0].Action != ImportActionSkipped {
+		t.Fatalf("skip: %+v (err %v)", results, err)
+	}
+	if got := readPlain(t, tokenPath); got != `{"refreshToken":"rt-old"}` {
+		t.Errorf("skip should keep the existing backup, got %q", got)
+	}
+
+	for _, want := range []string{"work-2", "work-3"} {
+		results, err = importArchive(dst, buf.Bytes(), ImportOptions{OnConflict: ConflictRename})
+		if err != nil || results[0].Action != ImportActionRenamed || results[0].Name != want {
+			t.Fatalf("rename: %+v (err %v), want %s", results, err, want)
+		}
+	}
+
+	results, err = importArchive(dst, buf.Bytes(), ImportOptions{OnConflict: ConflictOverwrite})
+	if err != nil || results[0].Action != ImportActionOverwritten {
+		t.Fatalf("overwrite: %+v (err %v)", results, err)
+	}
+	if got := readPlain(t, tokenPath); got != `{"refreshToken":"rt-new"}` {
+		t.Errorf("overwrite should replace the backup, got %q", got)
+	}
+
+	entries, _ := os.ReadDir(dst)
+	for _, e := range entries {
+		if e.Name()[0] == '.' {
+			t.Errorf("staging directory %s left behind", e.Name())
+		}
+	}
+
+	if _, err := importArchive(dst, buf.Bytes(), ImportOptions{OnConflict: "merge"}); !errors.Is(err, ErrInvalidConflictMode) {
+		t.Errorf("expected ErrInvalidConflictMode, got %v", err)
+	}
+}
+
+// TestArchive_Tampered 測試內容被修改或名稱不合法的封存檔會被拒絕
+func TestArchive_Tampered(t *testing.T) {
+	useFakeKeyring(t)
+	src := t.TempDir()
+	createArchiveTestBackup(t, src, "work", "rt-work")
+
+	var buf bytes.Buffer
+	if err := exportArchive(src, []string{"work"}, &buf, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打包，替換其中一個檔案的內容
+	rewrite := func(replace map[string][]byte) []byte {
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		zw := zip.NewWriter(&out)
+		for _, f := range zr.File {
+			data, _ := readZipEntry(f, -1)
+			if r, ok := replace[f.Name]; ok {
+				data = r
+			}
+			w, _ := zw.Create(f.Name)
+			w.Write(data)
+		}
+		zw.Close()
+		return out.Bytes()
+	}
+
+	tampered := rewrite(map[string][]byte{archiveEntryPath("work", MachineIDFileName): []byte(`{"machineId":"evil"}`)})
+	if _, err := importArchive(t.TempDir(), tampered, ImportOptions{}); !errors.Is(err, ErrArchiveChecksum) {
+		t.Errorf("expected ErrArchiveChecksum, got %v", err)
+	}
+
+	traversal := rewrite(map[string][]byte{ArchiveManifestName: []byte(
+		`{"format":"kiro-manager-archive","version":1,"backups":[{"name":"../evil","files":[]}]}`)})
+	if _, err := importArchive(t.TempDir(), traversal, ImportOptions{}); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected ErrInvalidArchive, got %v", err)
+	}
+
+	future := rewrite(map[string][]byte{ArchiveManifestName: []byte(
+		`{"format":"kiro-manager-archive","version":99,"backups":[]}`)})
+	if _, err := importArchive(t.TempDir(), future, ImportOptions{}); !errors.Is(err, ErrUnsupportedArchive) {
+		t.Errorf("expected ErrUnsupportedArchive, got %v", err)
+	}
+}
@@ -4,21 +4,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/logging"
 	"kiro-manager/machineid"
+	"kiro-manager/sysenv"
+	"kiro-manager/usage"
 )
 
 const (
-	BackupDirName       = "backups"
-	MachineIDFileName   = "machine-id.json"
-	KiroAuthTokenFile   = "kiro-auth-token.json"
-	UsageCacheFileName  = "usage-cache.json"
+	BackupDirName      = "backups"
+	MachineIDFileName  = "machine-id.json"
+	KiroAuthTokenFile  = "kiro-auth-token.json"
+	UsageCacheFileName = "usage-cache.json"
 )
 
 var (
@@ -36,11 +38,12 @@ type MachineIDBackup struct {
 
 // BackupInfo 代表備份的基本資訊
 type BackupInfo struct {
-	Name       string    `json:"name"`
-	Path       string    `json:"path"`
-	BackupTime time.Time `json:"backupTime"`
-	HasToken   bool      `json:"hasToken"`
-	HasMachineID bool    `json:"hasMachineId"`
+	Name         string     `json:"name"`
+	Path         string     `json:"path"`
+	BackupTime   time.Time  `json:"backupTime"`
+	HasToken     bool       `json:"hasToken"`
+	HasMachineID bool       `json:"hasMachineId"`
+	Meta         BackupMeta `json:"meta"` // 使用者描述資料（meta.json）
 }
 
 // UsageCache 餘額緩存結構
@@ -51,41 +54,42 @@ type UsageCache struct {
 	Balance           float64   `json:"balance"`
 	IsLowBalance      bool      `json:"isLowBalance"`
 	CachedAt          time.Time `json:"cachedAt"`
+	// Breakdown 各額度來源的明細（舊版緩存沒有此欄位）
+	Breakdown []usage.BreakdownItem `json:"breakdown,omitempty"`
 }
 
-// GetBackupRootPath 取得備份根目錄（執行檔同層的 backups 資料夾）
+// GetBackupRootPath 取得備份根目錄（資料目錄下的 backups 資料夾）
 func GetBackupRootPath() (string, error) {
-	execPath, err := os.Executable()
+	dir, err := sysenv.DataDir()
 	if err != nil {
 		return "", err
 	}
-	execDir := filepath.Dir(execPath)
-	return filepath.Join(execDir, BackupDirName), nil
+	return filepath.Join(dir, BackupDirName), nil
 }
 
-
 // ensureBackupRoot 確保備份根目錄存在
 func ensureBackupRoot() (string, error) {
 	rootPath, err := GetBackupRootPath()
 	if err != nil {
 		return "", err
 	}
-	if err := os.MkdirAll(rootPath, 0755); err != nil {
+	if err := os.MkdirAll(rootPath, 0700); err != nil {
 		return "", err
 	}
 	return rootPath, nil
 }
 
-// GetBackupPath 取得指定備份的完整路徑
+// GetBackupPath 取得指定備份的完整路徑（名稱不合法時返回 ErrInvalidBackupName，避免路徑穿越）
 func GetBackupPath(name string) (string, error) {
-	if name == "" {
-		return "", ErrInvalidBackupName
+	n, err := ParseBackupName(name)
+	if err != nil {
+		return "", err
 	}
 	rootPath, err := GetBackupRootPath()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(rootPath, name), nil
+	return n.dir(rootPath), nil
 }
 
 // BackupExists 檢查指定名稱的備份是否存在
@@ -116,7 +120,12 @@ func ListBackups() ([]BackupInfo, error) {
 
 	var backups []BackupInfo
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// 以 . 開頭的目錄為匯入時的暫存目錄
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := ParseBackupName(entry.Name()); err != nil {
+			logging.Warn("skipping backup directory with invalid name", "name", entry.Name(), "err", err)
 			continue
 		}
 
@@ -144,40 +153,42 @@ func ListBackups() ([]BackupInfo, error) {
 			}
 		}
 
+		info.Meta = loadMeta(backupPath)
+
 		backups = append(backups, info)
 	}
 
 	return backups, nil
 }
 
-
 // CreateBackup 創建一個新的備份
 func CreateBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
-	}
-
-	if BackupExists(name) {
-		return ErrBackupExists
+	n, err := NewBackupName(name)
+	if err != nil {
+		return err
 	}
 
 	// 確保備份根目錄存在
-	_, err := ensureBackupRoot()
+	rootPath, err := ensureBackupRoot()
 	if err != nil {
 		return fmt.Errorf("failed to create backup root: %w", err)
 	}
 
+	if err := checkNameAvailable(rootPath, n, ""); err != nil {
+		return err
+	}
+
 	// 創建備份資料夾
 	backupPath, err := GetBackupPath(name)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// 備份 kiro-auth-token.json
+	// 備份 kiro-auth-token.json（加密儲存）
 	tokenSrcPath, err := awssso.GetKiroAuthTokenPath()
 	if err != nil {
 		// 清理已創建的資料夾
@@ -191,28 +202,17 @@ func CreateBackup(name string) error {
 	}
 
 	tokenDstPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	if err := copyFile(tokenSrcPath, tokenDstPath); err != nil {
+	if err := encryptFile(tokenSrcPath, tokenDstPath); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to backup token: %w", err)
 	}
 
-	// 讀取 token 以檢查是否需要備份 IdC 的 clientIdHash 文件
+	// 備份同一帳號的其他 SSO cache 檔案（IdC 的 clientIdHash、startUrl 雜湊檔等）
 	token, err := awssso.ReadKiroAuthToken()
 	if err == nil && token != nil {
-		// 如果是 IdC 認證且有 clientIdHash，備份對應的 clientId/clientSecret 文件
-		if isIdCAuth(token.AuthMethod) && token.ClientIdHash != "" {
-			clientIdHashFile := token.ClientIdHash + ".json"
-			ssoCachePath, err := awssso.GetSSOCachePath()
-			if err == nil {
-				clientIdHashSrcPath := filepath.Join(ssoCachePath, clientIdHashFile)
-				if _, err := os.Stat(clientIdHashSrcPath); err == nil {
-					clientIdHashDstPath := filepath.Join(backupPath, clientIdHashFile)
-					if err := copyFile(clientIdHashSrcPath, clientIdHashDstPath); err != nil {
-						// 備份 clientIdHash 文件失敗不應該阻止整個備份流程，只記錄警告
-						fmt.Printf("Warning: failed to backup clientIdHash file: %v\n", err)
-					}
-				}
-			}
+		if err := backupAccountCacheFiles(name, backupPath, token); err != nil {
+			// 探索失敗不應該阻止整個備份流程，只記錄警告
+			logging.Warn("failed to discover sso cache files", "backup", name, "err", err)
 		}
 	}
 
@@ -225,7 +225,7 @@ func CreateBackup(name string) error {
 
 	machineIDBackup := MachineIDBackup{
 		MachineID:  rawMachineID,
-		BackupTime: time.Now().Format(time.RFC3339),
+		BackupTime: sysenv.Now().Format(time.RFC3339),
 	}
 
 	machineIDData, err := json.MarshalIndent(machineIDBackup, "", "  ")
@@ -235,7 +235,7 @@ func CreateBackup(name string) error {
 	}
 
 	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	if err := os.WriteFile(machineIDPath, machineIDData, 0644); err != nil {
+	if err := os.WriteFile(machineIDPath, machineIDData, 0600); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to write machine id: %w", err)
 	}
@@ -243,41 +243,42 @@ func CreateBackup(name string) error {
 	return nil
 }
 
-// isIdCAuth 判斷是否為 IdC 認證類型
-func isIdCAuth(authMethod string) bool {
-	if authMethod == "" {
-		return false
-	}
-	lower := strings.ToLower(authMethod)
-	return lower == "idc" || lower == "identitycenter"
-}
-
-// copyFile 複製檔案
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+// backupAccountCacheFiles 將 token 所屬帳號的其他 SSO cache 檔案加密複製到備份目錄
+func backupAccountCacheFiles(name, backupPath string, token *awssso.KiroAuthToken) error {
+	files, err := awssso.AccountCacheFiles(token)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(dst)
+	ssoCachePath, err := awssso.GetSSOCachePath()
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
+	for _, file := range files[1:] {
+		if !validSSOCacheFileName(file) {
+			logging.Warn("skipping sso cache file with unsafe name", "backup", name, "file", file)
+			continue
+		}
+		if err := encryptFile(filepath.Join(ssoCachePath, file), filepath.Join(backupPath, file)); err != nil {
+			// 單一檔案失敗不應該阻止整個備份流程，只記錄警告
+			logging.Warn("failed to backup sso cache file", "backup", name, "file", file, "err", err)
+		}
+	}
+	return nil
+}
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+// encryptFile 讀取明文檔案並加密寫入備份
+func encryptFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
 		return err
 	}
-
-	return dstFile.Sync()
+	return writeBackupFile(dst, data)
 }
 
-
-// RestoreBackup 恢復指定的備份
-func RestoreBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+// DeleteBackup 刪除指定的備份
+func DeleteBackup(name string) error {
+	if _, err := ParseBackupName(name); err != nil {
+		return err
 	}
 
 	if !BackupExists(name) {
@@ -289,72 +290,145 @@ func RestoreBackup(name string) error {
 		return err
 	}
 
-	// 恢復 kiro-auth-token.json
-	tokenSrcPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	if _, err := os.Stat(tokenSrcPath); os.IsNotExist(err) {
-		return fmt.Errorf("backup token file not found")
+	return os.RemoveAll(backupPath)
+}
+
+// RenameBackup 重新命名備份
+// 備份的 Machine ID、憑證、餘額緩存與用量歷史都存放在備份目錄內，改名只需一次目錄 rename
+func RenameBackup(oldName, newName string) error {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return err
 	}
+	return renameBackup(rootPath, oldName, newName)
+}
 
-	tokenDstPath, err := awssso.GetKiroAuthTokenPath()
+// CloneBackup 複製備份為新名稱
+// 先複製至暫存目錄再改名，過程中失敗不會留下不完整的備份；用量歷史不會被複製
+func CloneBackup(srcName, dstName string) error {
+	rootPath, err := GetBackupRootPath()
 	if err != nil {
-		return fmt.Errorf("failed to get token destination path: %w", err)
+		return err
 	}
+	return cloneBackup(rootPath, srcName, dstName)
+}
 
-	// 確保目標目錄存在
-	tokenDstDir := filepath.Dir(tokenDstPath)
-	if err := os.MkdirAll(tokenDstDir, 0755); err != nil {
-		return fmt.Errorf("failed to create token directory: %w", err)
+// renameBackup 在 rootPath 下重新命名備份
+func renameBackup(rootPath, oldName, newName string) error {
+	oldN, err := ParseBackupName(oldName)
+	if err != nil {
+		return err
+	}
+	newN, err := NewBackupName(newName)
+	if err != nil {
+		return err
+	}
+	// 原始備份用於還原出廠，不允許改名
+	if oldN.IsOriginal() {
+		return fmt.Errorf("%w: the original backup cannot be renamed", ErrInvalidBackupName)
 	}
 
-	if err := copyFile(tokenSrcPath, tokenDstPath); err != nil {
-		return fmt.Errorf("failed to restore token: %w", err)
+	oldPath := oldN.dir(rootPath)
+	newPath := newN.dir(rootPath)
+	oldInfo, err := os.Stat(oldPath)
+	if err != nil || !oldInfo.IsDir() {
+		return ErrBackupNotFound
+	}
+	if oldName == newName {
+		return nil
+	}
+	if err := checkNameAvailable(rootPath, newN, oldName); err != nil {
+		return err
 	}
 
-	// 讀取備份的 token 以檢查是否需要恢復 IdC 的 clientIdHash 文件
-	token, err := ReadBackupToken(name)
-	if err == nil && token != nil {
-		// 如果是 IdC 認證且有 clientIdHash，恢復對應的 clientId/clientSecret 文件
-		if isIdCAuth(token.AuthMethod) && token.ClientIdHash != "" {
-			clientIdHashFile := token.ClientIdHash + ".json"
-			clientIdHashSrcPath := filepath.Join(backupPath, clientIdHashFile)
-			if _, err := os.Stat(clientIdHashSrcPath); err == nil {
-				ssoCachePath, err := awssso.GetSSOCachePath()
-				if err == nil {
-					clientIdHashDstPath := filepath.Join(ssoCachePath, clientIdHashFile)
-					if err := copyFile(clientIdHashSrcPath, clientIdHashDstPath); err != nil {
-						// 恢復 clientIdHash 文件失敗不應該阻止整個恢復流程，只記錄警告
-						fmt.Printf("Warning: failed to restore clientIdHash file: %v\n", err)
-					}
-				}
-			}
+	if newInfo, err := os.Stat(newPath); err == nil {
+		// 不分大小寫的檔案系統上只改大小寫時，兩個路徑指向同一個目錄
+		if !os.SameFile(oldInfo, newInfo) {
+			return ErrBackupExists
+		}
+		tmpPath, err := os.MkdirTemp(rootPath, ".rename-")
+		if err != nil {
+			return err
+		}
+		os.Remove(tmpPath)
+		if err := os.Rename(oldPath, tmpPath); err != nil {
+			return fmt.Errorf("failed to rename backup: %w", err)
+		}
+		if err := os.Rename(tmpPath, newPath); err != nil {
+			os.Rename(tmpPath, oldPath)
+			return fmt.Errorf("failed to rename backup: %w", err)
 		}
+		return nil
 	}
 
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename backup: %w", err)
+	}
 	return nil
 }
 
-// DeleteBackup 刪除指定的備份
-func DeleteBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+// cloneBackup 在 rootPath 下複製備份
+func cloneBackup(rootPath, srcName, dstName string) error {
+	srcN, err := ParseBackupName(srcName)
+	if err != nil {
+		return err
+	}
+	dstN, err := NewBackupName(dstName)
+	if err != nil {
+		return err
+	}
+	if srcN.IsOriginal() {
+		return fmt.Errorf("%w: the original backup cannot be cloned", ErrInvalidBackupName)
 	}
 
-	if !BackupExists(name) {
+	srcPath := srcN.dir(rootPath)
+	if !dirExists(srcPath) {
 		return ErrBackupNotFound
 	}
+	dstPath := dstN.dir(rootPath)
+	if err := checkNameAvailable(rootPath, dstN, ""); err != nil {
+		return err
+	}
 
-	backupPath, err := GetBackupPath(name)
+	stagingPath, err := os.MkdirTemp(rootPath, ".clone-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingPath)
+
+	entries, err := os.ReadDir(srcPath)
 	if err != nil {
 		return err
 	}
+	for _, entry := range entries {
+		// 用量歷史屬於來源備份，複製後會在報表中重複計算
+		if !entry.Type().IsRegular() || entry.Name() == UsageHistoryFileName {
+			continue
+		}
+		// 憑證檔案原樣複製（仍為加密狀態）
+		data, err := os.ReadFile(filepath.Join(srcPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := atomicWriteFile(filepath.Join(stagingPath, entry.Name()), data, 0600); err != nil {
+			return err
+		}
+	}
 
-	return os.RemoveAll(backupPath)
+	// 改名前再確認一次，避免覆蓋期間建立的同名備份
+	if err := checkNameAvailable(rootPath, dstN, ""); err != nil {
+		return err
+	}
+	if err := os.Rename(stagingPath, dstPath); err != nil {
+		return fmt.Errorf("failed to create cloned backup: %w", err)
+	}
+	return nil
 }
 
 // GetBackupInfo 取得指定備份的詳細資訊
 func GetBackupInfo(name string) (*BackupInfo, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -389,13 +463,25 @@ func GetBackupInfo(name string) (*BackupInfo, error) {
 		}
 	}
 
+	info.Meta = loadMeta(backupPath)
+
 	return info, nil
 }
 
+// loadMeta 讀取備份的描述資料，失敗時記錄警告並返回空值（不影響列表）
+func loadMeta(backupPath string) BackupMeta {
+	meta, err := readMetaFile(filepath.Join(backupPath, MetaFileName))
+	if err != nil {
+		logging.Warn("failed to read backup metadata", "path", backupPath, "err", err)
+		return BackupMeta{}
+	}
+	return *meta
+}
+
 // ReadBackupMachineID 讀取備份中的 Machine ID
 func ReadBackupMachineID(name string) (*MachineIDBackup, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -427,27 +513,28 @@ const OriginalBackupName = "original"
 // CreateMachineIDOnlyBackup 僅備份 Machine ID（不備份 token）
 // 用於軟體啟動時確保原始 Machine ID 被保存
 func CreateMachineIDOnlyBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
-	}
-
-	if BackupExists(name) {
-		return ErrBackupExists
+	n, err := ParseBackupName(name)
+	if err != nil {
+		return err
 	}
 
 	// 確保備份根目錄存在
-	_, err := ensureBackupRoot()
+	rootPath, err := ensureBackupRoot()
 	if err != nil {
 		return fmt.Errorf("failed to create backup root: %w", err)
 	}
 
+	if err := checkNameAvailable(rootPath, n, ""); err != nil {
+		return err
+	}
+
 	// 創建備份資料夾
 	backupPath, err := GetBackupPath(name)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
@@ -460,7 +547,7 @@ func CreateMachineIDOnlyBackup(name string) error {
 
 	machineIDBackup := MachineIDBackup{
 		MachineID:  rawMachineID,
-		BackupTime: time.Now().Format(time.RFC3339),
+		BackupTime: sysenv.Now().Format(time.RFC3339),
 	}
 
 	machineIDData, err := json.MarshalIndent(machineIDBackup, "", "  ")
@@ -470,7 +557,7 @@ func CreateMachineIDOnlyBackup(name string) error {
 	}
 
 	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	if err := os.WriteFile(machineIDPath, machineIDData, 0644); err != nil {
+	if err := os.WriteFile(machineIDPath, machineIDData, 0600); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to write machine id: %w", err)
 	}
@@ -497,8 +584,8 @@ func EnsureOriginalBackup() (bool, error) {
 
 // ReadBackupToken 讀取備份中的 kiro-auth-token.json
 func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -511,28 +598,57 @@ func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
 	}
 
 	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	data, err := os.ReadFile(tokenPath)
+	data, err := readBackupFile(tokenPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read token file: %w", err)
 	}
 
-	var token awssso.KiroAuthToken
-	if err := json.Unmarshal(data, &token); err != nil {
+	token, err := awssso.ParseKiroAuthToken(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse token file: %w", err)
 	}
 
-	return &token, nil
+	return token, nil
 }
 
-// ReadBackupIdCCredentials 從備份目錄讀取 IdC 的 clientId 和 clientSecret
-// 根據 token 中的 clientIdHash 查找對應的 JSON 文件
-func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clientSecret string, err error) {
-	if name == "" {
-		return "", "", ErrInvalidBackupName
+// FindBackupByToken 尋找與 token 屬於同一帳號的備份，找不到時回傳空字串
+func FindBackupByToken(token *awssso.KiroAuthToken) (string, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return "", err
+	}
+
+	for _, b := range backups {
+		if !b.HasToken {
+			continue
+		}
+		backupToken, err := ReadBackupToken(b.Name)
+		if err != nil {
+			// 加密備份尚未解鎖時無法判斷，交由呼叫端決定
+			if errors.Is(err, ErrPassphraseRequired) || errors.Is(err, ErrKeyringUnavailable) {
+				return "", err
+			}
+			continue
+		}
+		if awssso.SameAccount(backupToken, token) {
+			return b.Name, nil
+		}
 	}
+	return "", nil
+}
 
-	if clientIdHash == "" {
-		return "", "", fmt.Errorf("clientIdHash is empty")
+// ReadBackupIdCCredentials 從備份目錄讀取 IdC 的 clientId 和 clientSecret
+// 優先使用 token 中 clientIdHash 對應的 JSON 文件，找不到時改從備份的其他 SSO cache 檔案
+// 尋找 startUrl 相同且含有 clientId/clientSecret 的註冊檔
+func ReadBackupIdCCredentials(name string, token *awssso.KiroAuthToken) (clientID, clientSecret string, err error) {
+	if _, err := ParseBackupName(name); err != nil {
+		return "", "", err
+	}
+	if token == nil {
+		return "", "", fmt.Errorf("token is nil")
+	}
+	if token.ClientIdHash != "" && !validClientIdHash(token.ClientIdHash) {
+		return "", "", fmt.Errorf("invalid clientIdHash %q", token.ClientIdHash)
 	}
 
 	if !BackupExists(name) {
@@ -545,34 +661,51 @@ func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clien
 	}
 
 	// 讀取 clientIdHash 對應的 JSON 文件
-	clientIdHashFile := clientIdHash + ".json"
-	clientIdHashPath := filepath.Join(backupPath, clientIdHashFile)
+	if token.ClientIdHash != "" {
+		cacheFile, err := readBackupCacheFile(filepath.Join(backupPath, token.ClientIdHash+".json"))
+		if err == nil && cacheFile.ClientID != "" && cacheFile.ClientSecret != "" {
+			return cacheFile.ClientID, cacheFile.ClientSecret, nil
+		}
+	}
 
-	data, err := os.ReadFile(clientIdHashPath)
+	// 回退：從備份的其他 SSO cache 檔案尋找
+	entries, err := os.ReadDir(backupPath)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read clientIdHash file: %w", err)
+		return "", "", err
+	}
+	for _, entry := range entries {
+		if !isSSOCacheFile(entry) {
+			continue
+		}
+		cacheFile, err := readBackupCacheFile(filepath.Join(backupPath, entry.Name()))
+		if err != nil || cacheFile.ClientID == "" || cacheFile.ClientSecret == "" {
+			continue
+		}
+		if token.StartURL == "" || cacheFile.StartURL == "" || cacheFile.StartURL == token.StartURL {
+			return cacheFile.ClientID, cacheFile.ClientSecret, nil
+		}
 	}
 
-	// 解析 JSON 文件
-	var cacheFile struct {
-		ClientID     string `json:"clientId"`
-		ClientSecret string `json:"clientSecret"`
+	return "", "", fmt.Errorf("clientId or clientSecret not found in backup")
+}
+
+// readBackupCacheFile 讀取並解析備份中的 SSO cache 檔案
+func readBackupCacheFile(filePath string) (*awssso.SSOCacheFile, error) {
+	data, err := readBackupFile(filePath)
+	if err != nil {
+		return nil, err
 	}
+	var cacheFile awssso.SSOCacheFile
 	if err := json.Unmarshal(data, &cacheFile); err != nil {
-		return "", "", fmt.Errorf("failed to parse clientIdHash file: %w", err)
-	}
-
-	if cacheFile.ClientID == "" || cacheFile.ClientSecret == "" {
-		return "", "", fmt.Errorf("clientId or clientSecret not found in file")
+		return nil, err
 	}
-
-	return cacheFile.ClientID, cacheFile.ClientSecret, nil
+	return &cacheFile, nil
 }
 
 // ReadUsageCache 讀取備份的餘額緩存
 func ReadUsageCache(name string) (*UsageCache, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -598,10 +731,10 @@ func ReadUsageCache(name string) (*UsageCache, error) {
 	return &cache, nil
 }
 
-// WriteUsageCache 寫入備份的餘額緩存
+// WriteUsageCache 寫入備份的餘額緩存，並附加一筆用量歷史紀錄
 func WriteUsageCache(name string, cache *UsageCache) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	if _, err := ParseBackupName(name); err != nil {
+		return err
 	}
 
 	if cache == nil {
@@ -618,7 +751,7 @@ func WriteUsageCache(name string, cache *UsageCache) error {
 	}
 
 	// 設定緩存時間
-	cache.CachedAt = time.Now()
+	cache.CachedAt = sysenv.Now()
 
 	cacheData, err := json.MarshalIndent(cache, "", "  ")
 	if err != nil {
@@ -626,32 +759,31 @@ func WriteUsageCache(name string, cache *UsageCache) error {
 	}
 
 	cachePath := filepath.Join(backupPath, UsageCacheFileName)
-	if err := os.WriteFile(cachePath, cacheData, 0644); err != nil {
+	if err := os.WriteFile(cachePath, cacheData, 0600); err != nil {
 		return fmt.Errorf("failed to write usage cache: %w", err)
 	}
 
-	return nil
-}
-
+	// 每次成功查詢都記錄到歷史檔，歷史寫入失敗不影響緩存
+	entry := UsageHistoryEntry{
+		Time:              cache.CachedAt,
+		SubscriptionTitle: cache.SubscriptionTitle,
+		UsageLimit:        cache.UsageLimit,
+		CurrentUsage:      cache.CurrentUsage,
+		Balance:           cache.Balance,
+	}
+	if err := appendUsageHistoryFile(filepath.Join(backupPath, UsageHistoryFileName), entry); err != nil {
+		logging.Warn("failed to record usage history", "backup", name, "err", err)
+	}
 
-// orderedKiroAuthToken 用於確保 JSON 輸出時 key 的順序
-// 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
-type orderedKiroAuthToken struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ProfileArn   string `json:"profileArn"`
-	ExpiresAt    string `json:"expiresAt"`
-	AuthMethod   string `json:"authMethod"`
-	Provider     string `json:"provider"`
+	return nil
 }
 
 // WriteBackupToken 將刷新後的 Token 寫入備份檔案
-// 保留原有欄位，僅更新 accessToken、expiresAt
-// 確保 JSON key 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
+// token 通常來自 ReadBackupToken，未修改的欄位、未知欄位與原始 key 順序都會保留
 // 需求: 3.1, 3.2, 3.3
-func WriteBackupToken(name string, accessToken string, expiresAt string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+func WriteBackupToken(name string, token *awssso.KiroAuthToken) error {
+	if _, err := ParseBackupName(name); err != nil {
+		return err
 	}
 
 	if !BackupExists(name) {
@@ -663,49 +795,14 @@ func WriteBackupToken(name string, accessToken string, expiresAt string) error {
 		return err
 	}
 
-	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
-
-	// 讀取現有 token 檔案以保留原始欄位
-	data, err := os.ReadFile(tokenPath)
-	if err != nil {
-		return fmt.Errorf("failed to read existing token file: %w", err)
-	}
-
-	// 先解析到 map 以讀取原始值
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
-		return fmt.Errorf("failed to parse existing token file: %w", err)
-	}
-
-	// 使用有序結構體來確保 key 順序
-	orderedToken := orderedKiroAuthToken{
-		AccessToken:  accessToken,
-		RefreshToken: getStringFromMap(tokenMap, "refreshToken"),
-		ProfileArn:   getStringFromMap(tokenMap, "profileArn"),
-		ExpiresAt:    expiresAt,
-		AuthMethod:   getStringFromMap(tokenMap, "authMethod"),
-		Provider:     getStringFromMap(tokenMap, "provider"),
-	}
-
-	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(orderedToken, "", "  ")
+	updatedData, err := token.Marshal()
 	if err != nil {
 		return fmt.Errorf("failed to marshal updated token: %w", err)
 	}
 
-	if err := os.WriteFile(tokenPath, updatedData, 0644); err != nil {
+	if err := writeBackupFile(filepath.Join(backupPath, KiroAuthTokenFile), updatedData); err != nil {
 		return fmt.Errorf("failed to write token file: %w", err)
 	}
 
 	return nil
 }
-
-// getStringFromMap 從 map 中安全地取得字串值
-func getStringFromMap(m map[string]interface{}, key string) string {
-	if v, ok := m[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
-		}
-	}
-	return ""
-}
@@ -2,13 +2,23 @@ package backup
 
 import (
 	"encoding/json"
+	"errors"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"testing"
 	"testing/quick"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/sysenv"
 )
 
+// TestMain 以暫存目錄與固定 Machine ID 執行測試，見 sysenv.RunIsolated
+func TestMain(m *testing.M) {
+	os.Exit(sysenv.RunIsolated(m))
+}
+
 // generateRandomString 生成指定長度的隨機字串
 func generateRandomString(r *rand.Rand, length int) string {
 	if length <= 0 {
@@ -107,7 +117,7 @@ func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
 
 		// 生成新的 accessToken 和 expiresAt
 		newAccessToken := generateRandomString(r, r.Intn(100)+10)
-		newExpiresAt := "2025-12-09T15:30:00Z"
+		newExpiresAt := "2025-12-09T15:30:00.000Z"
 
 		// 呼叫 WriteBackupToken（使用自訂路徑版本）
 		err = writeBackupTokenToPath(tokenPath, newAccessToken, newExpiresAt)
@@ -185,18 +195,19 @@ func writeBackupTokenToPath(tokenPath string, accessToken string, expiresAt stri
 		return err
 	}
 
-	// 使用 map 來保留所有原始欄位
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
+	token, err := awssso.ParseKiroAuthToken(data)
+	if err != nil {
 		return err
 	}
 
 	// 僅更新 accessToken 和 expiresAt 欄位
-	tokenMap["accessToken"] = accessToken
-	tokenMap["expiresAt"] = expiresAt
+	token.AccessToken = accessToken
+	if token.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+		return err
+	}
 
 	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(tokenMap, "", "  ")
+	updatedData, err := token.Marshal()
 	if err != nil {
 		return err
 	}
@@ -217,7 +228,7 @@ func compareValues(a, b interface{}) bool {
 
 // TestWriteBackupToken_InvalidBackupName 測試無效備份名稱的處理
 func TestWriteBackupToken_InvalidBackupName(t *testing.T) {
-	err := WriteBackupToken("", "new-token", "2025-12-09T15:30:00Z")
+	err := WriteBackupToken("", &awssso.KiroAuthToken{AccessToken: "new-token"})
 	if err != ErrInvalidBackupName {
 		t.Errorf("Expected ErrInvalidBackupName, got %v", err)
 	}
@@ -225,7 +236,7 @@ func TestWriteBackupToken_InvalidBackupName(t *testing.T) {
 
 // TestWriteBackupToken_BackupNotFound 測試備份不存在的處理
 func TestWriteBackupToken_BackupNotFound(t *testing.T) {
-	err := WriteBackupToken("non_existent_backup_xyz123", "new-token", "2025-12-09T15:30:00Z")
+	err := WriteBackupToken("non_existent_backup_xyz123", &awssso.KiroAuthToken{AccessToken: "new-token"})
 	if err != ErrBackupNotFound {
 		t.Errorf("Expected ErrBackupNotFound, got %v", err)
 	}
@@ -266,7 +277,7 @@ func TestWriteBackupToken_PreservesAllFields(t *testing.T) {
 
 	// 更新 token
 	newAccessToken := "new-access-token-12345"
-	newExpiresAt := "2025-12-09T18:00:00Z"
+	newExpiresAt := "2025-12-09T18:00:00.000Z"
 	err = writeBackupTokenToPath(tokenPath, newAccessToken, newExpiresAt)
 	if err != nil {
 		t.Fatalf("Failed to write backup token: %v", err)
@@ -310,3 +321,88 @@ func TestWriteBackupToken_PreservesAllFields(t *testing.T) {
 		t.Errorf("customField changed: got %v", updatedToken["customField"])
 	}
 }
+
+// TestRenameBackup 測試改名會保留所有檔案，並拒絕不合法的名稱
+func TestRenameBackup(t *testing.T) {
+	useFakeKeyring(t)
+	root := t.TempDir()
+	createArchiveTestBackup(t, root, "work", "rt-work")
+	createArchiveTestBackup(t, root, "home", "rt-home")
+	if err := os.MkdirAll(filepath.Join(root, OriginalBackupName), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := renameBackup(root, "work", "client-a"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if dirExists(filepath.Join(root, "work")) {
+		t.Error("old backup directory should be gone")
+	}
+	if got := readPlain(t, filepath.Join(root, "client-a", MachineIDFileName)); got != `{"machineId":"mid-work"}` {
+		t.Errorf("machine id = %q", got)
+	}
+	if got := readPlain(t, filepath.Join(root, "client-a", KiroAuthTokenFile)); got != `{"refreshToken":"rt-work"}` {
+		t.Errorf("token = %q", got)
+	}
+
+	tests := []struct {
+		oldName, newName string
+		want             error
+	}{
+		{"client-a", "home", ErrBackupExists},
+		{"missing", "other", ErrBackupNotFound},
+		{OriginalBackupName, "other", ErrInvalidBackupName},
+		{"client-a", OriginalBackupName, ErrInvalidBackupName},
+		{"client-a", "../escape", ErrInvalidBackupName},
+		{"client-a", ".hidden", ErrInvalidBackupName},
+		{"client-a", "trailing.", ErrInvalidBackupName},
+		{"client-a", "HOME", ErrBackupExists},
+		{"client-a", "Original", ErrInvalidBackupName},
+		{"../client-a", "other", ErrInvalidBackupName},
+		{"client-a", "", ErrInvalidBackupName},
+	}
+	for _, tt := range tests {
+		if err := renameBackup(root, tt.oldName, tt.newName); !errors.Is(err, tt.want) {
+			t.Errorf("renameBackup(%q, %q) = %v, want %v", tt.oldName, tt.newName, err, tt.want)
+		}
+	}
+}
+
+// TestCloneBackup 測試複製備份（不含用量歷史，不留下暫存目錄）
+func TestCloneBackup(t *testing.T) {
+	useFakeKeyring(t)
+	root := t.TempDir()
+	createArchiveTestBackup(t, root, "work", "rt-work")
+	if err := appendUsageHistoryFile(filepath.Join(root, "work", UsageHistoryFileName), UsageHistoryEntry{Balance: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cloneBackup(root, "work", "work-copy"); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	for _, name := range []string{"work", "work-copy"} {
+		if got := readPlain(t, filepath.Join(root, name, KiroAuthTokenFile)); got != `{"refreshToken":"rt-work"}` {
+			t.Errorf("%s token = %q", name, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "work-copy", UsageHistoryFileName)); !os.IsNotExist(err) {
+		t.Error("usage history should not be cloned")
+	}
+
+	if err := cloneBackup(root, "work", "work-copy"); !errors.Is(err, ErrBackupExists) {
+		t.Errorf("expected ErrBackupExists, got %v", err)
+	}
+	if err := cloneBackup(root, OriginalBackupName, "copy"); !errors.Is(err, ErrInvalidBackupName) {
+		t.Errorf("expected ErrInvalidBackupName, got %v", err)
+	}
+	if err := cloneBackup(root, "missing", "copy"); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("expected ErrBackupNotFound, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(root)
+	for _, e := range entries {
+		if e.Name()[0] == '.' {
+			t.Errorf("staging directory %s left behind", e.Name())
+		}
+	}
+}
@@ -0,0 +1,711 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+
+	"kiro-manager/sysenv"
+)
+
+const (
+	// EncryptionInfoFileName 備份根目錄中記錄金鑰來源的檔案
+	EncryptionInfoFileName = ".encryption.json"
+
+	// KeySourceKeyring 使用作業系統金鑰圈保存的隨機金鑰
+	KeySourceKeyring = "keyring"
+	// KeySourcePassphrase 使用使用者密語經 scrypt 推導的金鑰
+	KeySourcePassphrase = "passphrase"
+
+	encryptedFormat  = "kiro-manager-encrypted"
+	encryptedVersion = 1
+
+	keyringService = "kiro-manager"
+	keyringUser    = "backup-encryption-key"
+
+	keySize = 32 // AES-256
+
+	// scrypt 參數（2^15, 8, 1 為 scrypt 論文建議的互動式登入參數）
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// verifierPlaintext 用於驗證密語是否正確
+	verifierPlaintext = "kiro-manager-backup-key-check"
+
+	// rekeyJournalFileName 切換金鑰來源的日誌，存在即代表所有重新加密的檔案都已寫入暫存檔
+	rekeyJournalFileName = ".encryption.rekey.json"
+	// rekeyStagedSuffix 以新金鑰重新加密、尚未改名至正式位置的暫存檔
+	rekeyStagedSuffix = ".kiro-manager.rekey"
+)
+
+var (
+	ErrPassphraseRequired = errors.New("backup passphrase required")
+	ErrWrongPassphrase    = errors.New("wrong backup passphrase")
+	ErrDecryptFailed      = errors.New("failed to decrypt backup file")
+	ErrKeyringUnavailable = errors.New("os keyring unavailable")
+	ErrInvalidKeySource   = errors.New("invalid backup key source")
+)
+
+// keyring 存取（測試時可替換）
+var (
+	keyringGet = keyring.Get
+	keyringSet = keyring.Set
+)
+
+// encryptionInfo 備份根目錄的加密設定（.encryption.json）
+type encryptionInfo struct {
+	Version    int    `json:"version"`
+	KeySource  string `json:"keySource"`
+	KDF        string `json:"kdf,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+	Verifier   string `json:"verifier,omitempty"`
+	MigratedAt string `json:"migratedAt,omitempty"`
+}
+
+// encryptedEnvelope 加密後的檔案內容（取代原本的明文 JSON）
+type encryptedEnvelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KeySource  string `json:"keySource"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// EncryptionStatus 備份加密狀態（前端用）
+type EncryptionStatus struct {
+	KeySource string `json:"keySource"`
+	Locked    bool   `json:"locked"`   // 密語模式且尚未解鎖
+	Migrated  bool   `json:"migrated"` // 舊版明文備份是否已完成遷移
+}
+
+var (
+	keyMutex  sync.Mutex
+	cachedKey []byte // 目前金鑰來源對應的金鑰（解鎖或自金鑰圈讀取後快取）
+
+	// rekeyMutex 避免切換金鑰來源時，其他呼叫同時回復中斷的切換
+	rekeyMutex sync.Mutex
+)
+
+// rekeyJournal 切換金鑰來源的日誌
+// .encryption.json 與 Info 相同代表切換已提交，回復時將暫存檔改名至正式位置；否則捨棄暫存檔
+type rekeyJournal struct {
+	StartedAt string         `json:"startedAt"`
+	Info      encryptionInfo `json:"info"`
+	Files     []string       `json:"files"`
+}
+
+// credentialFileNames 回傳備份中需要加密的憑證檔案
+func credentialFileNames(backupPath string) []string {
+	files := []string{KiroAuthTokenFile}
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		if isSSOCacheFile(entry) {
+			files = append(files, entry.Name())
+		}
+	}
+	return files
+}
+
+// isSSOCacheFile 判斷是否為備份的 SSO cache 檔案
+func isSSOCacheFile(entry os.DirEntry) bool {
+	return !entry.IsDir() && isSSOCacheFileName(entry.Name())
+}
+
+// isSSOCacheFileName 依檔名判斷是否為備份的 SSO cache 檔案（kiro-auth-token.json 以外）
+// 包含 IdC 的 <clientIdHash>.json 與以 startUrl 雜湊命名的 session、註冊檔
+func isSSOCacheFileName(name string) bool {
+	if filepath.Ext(name) != ".json" {
+		return false
+	}
+	switch name {
+	case KiroAuthTokenFile, MachineIDFileName, UsageCacheFileName, MetaFileName:
+		return false
+	}
+	return true
+}
+
+// isCredentialFileName 判斷檔名是否為需要加密的憑證檔案
+func isCredentialFileName(name string) bool {
+	return name == KiroAuthTokenFile || isSSOCacheFileName(name)
+}
+
+// readEncryptionInfo 讀取 .encryption.json，不存在時返回預設（金鑰圈）
+// 若前一次切換金鑰來源中斷，先完成或回滾該次切換
+func readEncryptionInfo() (*encryptionInfo, error) {
+	if err := recoverInterruptedRekey(); err != nil {
+		return nil, fmt.Errorf("failed to recover interrupted key source switch: %w", err)
+	}
+	return loadEncryptionInfo()
+}
+
+// loadEncryptionInfo 讀取 .encryption.json，不存在時返回預設（金鑰圈）
+func loadEncryptionInfo() (*encryptionInfo, error) {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(rootPath, EncryptionInfoFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &encryptionInfo{Version: encryptedVersion, KeySource: KeySourceKeyring}, nil
+		}
+		return nil, err
+	}
+
+	var info encryptionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encryption info: %w", err)
+	}
+	if info.KeySource == "" {
+		info.KeySource = KeySourceKeyring
+	}
+	return &info, nil
+}
+
+// writeEncryptionInfo 寫入 .encryption.json
+func writeEncryptionInfo(info *encryptionInfo) error {
+	rootPath, err := ensureBackupRoot()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(filepath.Join(rootPath, EncryptionInfoFileName), data, 0600)
+}
+
+// GetEncryptionStatus 取得備份加密狀態
+func GetEncryptionStatus() (*EncryptionStatus, error) {
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	keyMutex.Lock()
+	unlocked := cachedKey != nil
+	keyMutex.Unlock()
+
+	return &EncryptionStatus{
+		KeySource: info.KeySource,
+		Locked:    info.KeySource == KeySourcePassphrase && !unlocked,
+		Migrated:  info.MigratedAt != "",
+	}, nil
+}
+
+// Unlock 以密語解鎖備份（僅密語模式需要）
+func Unlock(passphrase string) error {
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return err
+	}
+	if info.KeySource != KeySourcePassphrase {
+		return nil
+	}
+
+	key, err := deriveAndVerify(info, passphrase)
+	if err != nil {
+		return err
+	}
+
+	keyMutex.Lock()
+	cachedKey = key
+	keyMutex.Unlock()
+	return nil
+}
+
+// Lock 清除記憶體中的金鑰
+func Lock() {
+	keyMutex.Lock()
+	cachedKey = nil
+	keyMutex.Unlock()
+}
+
+// SetKeySource 切換金鑰來源，並以新金鑰重新加密所有備份的憑證檔案
+// passphrase 僅在切換為密語模式時使用
+// 以兩階段執行（見 rekeyFiles），任何一步失敗或中斷都不會留下無法解密的檔案
+func SetKeySource(source string, passphrase string) error {
+	if source != KeySourceKeyring && source != KeySourcePassphrase {
+		return ErrInvalidKeySource
+	}
+	if source == KeySourcePassphrase && passphrase == "" {
+		return ErrPassphraseRequired
+	}
+
+	// 先以舊金鑰讀出所有憑證（任何一個失敗都不變更設定）
+	plaintexts, err := readAllCredentialFiles()
+	if err != nil {
+		return err
+	}
+
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return err
+	}
+
+	newInfo := &encryptionInfo{
+		Version:    encryptedVersion,
+		KeySource:  source,
+		MigratedAt: info.MigratedAt,
+	}
+
+	var newKey []byte
+	switch source {
+	case KeySourceKeyring:
+		newKey, err = keyringKey()
+		if err != nil {
+			return err
+		}
+	case KeySourcePassphrase:
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		newKey, err = deriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		verifier, err := seal(newKey, source, []byte(verifierPlaintext))
+		if err != nil {
+			return err
+		}
+		newInfo.KDF = "scrypt"
+		newInfo.Salt = base64.StdEncoding.EncodeToString(salt)
+		newInfo.Verifier = string(verifier)
+	}
+
+	if err := rekeyFiles(info, newInfo, newKey, plaintexts); err != nil {
+		return err
+	}
+
+	keyMutex.Lock()
+	cachedKey = newKey
+	keyMutex.Unlock()
+	return nil
+}
+
+// rekeyFiles 以新金鑰重新加密 plaintexts（key 為檔案完整路徑）並切換為 newInfo
+// 1. 將重新加密的內容寫入各檔案旁的暫存檔並 fsync，再寫入日誌
+// 2. 寫入 newInfo（提交點），再將暫存檔改名至正式位置
+// 提交前失敗只需捨棄暫存檔；提交後改名失敗時還原已取代的檔案與 oldInfo。
+// 程式在途中結束時由 recoverInterruptedRekey 依日誌完成或回滾
+func rekeyFiles(oldInfo, newInfo *encryptionInfo, newKey []byte, plaintexts map[string][]byte) error {
+	rootPath, err := ensureBackupRoot()
+	if err != nil {
+		return err
+	}
+	journalPath := filepath.Join(rootPath, rekeyJournalFileName)
+
+	rekeyMutex.Lock()
+	defer rekeyMutex.Unlock()
+
+	// 保留原始內容，提交後失敗時用於還原
+	originals := make(map[string][]byte, len(plaintexts))
+	journal := rekeyJournal{StartedAt: sysenv.Now().Format(time.RFC3339), Info: *newInfo}
+	for path, data := range plaintexts {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		originals[path] = raw
+		sealed, err := seal(newKey, newInfo.KeySource, data)
+		if err != nil {
+			removeStagedFiles(journal.Files)
+			return err
+		}
+		if err := writeSyncedFile(path+rekeyStagedSuffix, sealed, 0600); err != nil {
+			os.Remove(path + rekeyStagedSuffix)
+			removeStagedFiles(journal.Files)
+			return fmt.Errorf("failed to re-encrypt %s: %w", filepath.Base(path), err)
+		}
+		journal.Files = append(journal.Files, path)
+	}
+
+	journalData, err := json.MarshalIndent(journal, "", "  ")
+	if err == nil {
+		err = atomicWriteFile(journalPath, journalData, 0600)
+	}
+	if err != nil {
+		removeStagedFiles(journal.Files)
+		return fmt.Errorf("failed to write key source journal: %w", err)
+	}
+
+	if err := writeEncryptionInfo(newInfo); err != nil {
+		removeStagedFiles(journal.Files)
+		os.Remove(journalPath)
+		return err
+	}
+
+	var replaced []string
+	for _, path := range journal.Files {
+		if err := renameFile(path+rekeyStagedSuffix, path); err != nil {
+			rollbackErr := rollbackRekey(oldInfo, originals, replaced, journal.Files)
+			if rollbackErr == nil {
+				os.Remove(journalPath)
+				return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+			}
+			// 日誌保留，下次讀取加密設定時再完成切換
+			return fmt.Errorf("failed to replace %s: %w (rollback failed: %v)", filepath.Base(path), err, rollbackErr)
+		}
+		replaced = append(replaced, path)
+	}
+
+	return os.Remove(journalPath)
+}
+
+// rollbackRekey 還原已以新金鑰取代的檔案與加密設定，並刪除剩餘的暫存檔
+func rollbackRekey(oldInfo *encryptionInfo, originals map[string][]byte, replaced, staged []string) error {
+	var firstErr error
+	for _, path := range replaced {
+		if err := atomicWriteFile(path, originals[path], 0600); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := writeEncryptionInfo(oldInfo); err != nil {
+		return err
+	}
+	removeStagedFiles(staged)
+	return nil
+}
+
+// recoverInterruptedRekey 依日誌完成或回滾中斷的金鑰來源切換
+// 新的加密設定已寫入時將剩餘的暫存檔改名至正式位置，否則捨棄暫存檔
+func recoverInterruptedRekey() error {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return err
+	}
+	journalPath := filepath.Join(rootPath, rekeyJournalFileName)
+	if _, err := os.Stat(journalPath); err != nil {
+		// 沒有日誌時不需回復（大多數呼叫只會走到這裡）
+		return nil
+	}
+
+	rekeyMutex.Lock()
+	defer rekeyMutex.Unlock()
+
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// 等待鎖的期間切換已完成
+			return nil
+		}
+		return err
+	}
+	var journal rekeyJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return fmt.Errorf("failed to parse key source journal: %w", err)
+	}
+
+	info, err := loadEncryptionInfo()
+	if err != nil {
+		return err
+	}
+	if info.KeySource != journal.Info.KeySource || info.Salt != journal.Info.Salt || info.Verifier != journal.Info.Verifier {
+		removeStagedFiles(journal.Files)
+		return os.Remove(journalPath)
+	}
+
+	for _, path := range journal.Files {
+		if err := renameFile(path+rekeyStagedSuffix, path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Remove(journalPath)
+}
+
+// removeStagedFiles 刪除重新加密的暫存檔
+func removeStagedFiles(paths []string) {
+	for _, path := range paths {
+		os.Remove(path + rekeyStagedSuffix)
+	}
+}
+
+// MigratePlaintextBackups 將舊版明文備份的憑證檔案加密（只執行一次）
+// 回傳本次加密的檔案數量
+func MigratePlaintextBackups() (int, error) {
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return 0, err
+	}
+	if info.MigratedAt != "" {
+		return 0, nil
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, b := range backups {
+		if err := os.Chmod(b.Path, 0700); err != nil {
+			return migrated, err
+		}
+		for _, name := range credentialFileNames(b.Path) {
+			path := filepath.Join(b.Path, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return migrated, err
+			}
+			if isEncrypted(data) {
+				continue
+			}
+			if err := writeBackupFile(path, data); err != nil {
+				return migrated, fmt.Errorf("failed to encrypt %s/%s: %w", b.Name, name, err)
+			}
+			migrated++
+		}
+	}
+
+	info.MigratedAt = sysenv.Now().Format(time.RFC3339)
+	if err := writeEncryptionInfo(info); err != nil {
+		return migrated, err
+	}
+	return migrated, nil
+}
+
+// readAllCredentialFiles 解密所有備份中的憑證檔案，key 為檔案完整路徑
+func readAllCredentialFiles() (map[string][]byte, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte)
+	for _, b := range backups {
+		for _, name := range credentialFileNames(b.Path) {
+			path := filepath.Join(b.Path, name)
+			data, err := readBackupFile(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("%s/%s: %w", b.Name, name, err)
+			}
+			result[path] = data
+		}
+	}
+	return result, nil
+}
+
+// readBackupFile 讀取備份檔案，加密格式時自動解密，舊版明文直接返回
+func readBackupFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isEncrypted(data) {
+		return data, nil
+	}
+
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, ErrDecryptFailed
+	}
+
+	key, err := currentKey(envelope.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, &envelope)
+}
+
+// writeBackupFile 以目前的金鑰加密並寫入備份檔案（權限 0600）
+func writeBackupFile(path string, data []byte) error {
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return err
+	}
+
+	key, err := currentKey(info.KeySource)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(key, info.KeySource, data)
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(path, sealed, 0600)
+}
+
+// isEncrypted 判斷檔案內容是否為加密格式
+func isEncrypted(data []byte) bool {
+	if !bytes.Contains(data, []byte(encryptedFormat)) {
+		return false
+	}
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return false
+	}
+	return envelope.Format == encryptedFormat
+}
+
+// currentKey 取得指定金鑰來源的金鑰
+func currentKey(source string) ([]byte, error) {
+	info, err := readEncryptionInfo()
+	if err != nil {
+		return nil, err
+	}
+	if source != info.KeySource {
+		// 檔案使用的金鑰來源與目前設定不一致（例如切換中斷）
+		return nil, fmt.Errorf("%w: file uses %s, store uses %s", ErrDecryptFailed, source, info.KeySource)
+	}
+
+	keyMutex.Lock()
+	defer keyMutex.Unlock()
+	if cachedKey != nil {
+		return cachedKey, nil
+	}
+
+	switch source {
+	case KeySourceKeyring:
+		key, err := keyringKey()
+		if err != nil {
+			return nil, err
+		}
+		cachedKey = key
+		return key, nil
+	case KeySourcePassphrase:
+		return nil, ErrPassphraseRequired
+	default:
+		return nil, ErrInvalidKeySource
+	}
+}
+
+// keyringKey 從作業系統金鑰圈讀取金鑰，不存在時生成並寫入
+func keyringKey() ([]byte, error) {
+	encoded, err := keyringGet(keyringService, keyringUser)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("%w: stored key is corrupted", ErrKeyringUnavailable)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keyringSet(keyringService, keyringUser, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+	return key, nil
+}
+
+// deriveAndVerify 以密語推導金鑰並用 verifier 驗證
+func deriveAndVerify(info *encryptionInfo, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	salt, err := base64.StdEncoding.DecodeString(info.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt in encryption info: %w", err)
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal([]byte(info.Verifier), &envelope); err != nil {
+		return nil, fmt.Errorf("invalid verifier in encryption info: %w", err)
+	}
+	plaintext, err := open(key, &envelope)
+	if err != nil || string(plaintext) != verifierPlaintext {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// deriveKey 使用 scrypt 從密語推導 AES-256 金鑰
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+}
+
+// seal 使用 AES-GCM 加密並包裝為 JSON
+func seal(key []byte, source string, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	envelope := encryptedEnvelope{
+		Format:     encryptedFormat,
+		Version:    encryptedVersion,
+		KeySource:  source,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, []byte(encryptedFormat))),
+	}
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// open 解密 JSON 包裝的 AES-GCM 內容
+func open(key []byte, envelope *encryptedEnvelope) ([]byte, error) {
+	if envelope.Format != encryptedFormat || envelope.Version != encryptedVersion {
+		return nil, ErrDecryptFailed
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil || len(nonce) != gcm.NonceSize() {
+		return nil, ErrDecryptFailed
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(encryptedFormat))
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,250 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// useFakeKeyring 以記憶體中的 map 取代作業系統金鑰圈
+func useFakeKeyring(t *testing.T) {
+	t.Helper()
+	store := make(map[string]string)
+	origGet, origSet := keyringGet, keyringSet
+	keyringGet = func(service, user string) (string, error) {
+		if v, ok := store[service+"/"+user]; ok {
+			return v, nil
+		}
+		return "", keyring.ErrNotFound
+	}
+	keyringSet = func(service, user, password string) error {
+		store[service+"/"+user] = password
+		return nil
+	}
+	Lock()
+	t.Cleanup(func() {
+		keyringGet, keyringSet = origGet, origSet
+		Lock()
+	})
+}
+
+// TestSealOpen_RoundTrip 測試加密後可正確解密
+func TestSealOpen_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+	plaintext := []byte(`{"accessToken":"secret","clientSecret":"s3cr3t"}`)
+
+	sealed, err := seal(key, KeySourceKeyring, plaintext)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Fatal("sealed data must not contain plaintext")
+	}
+	if !isEncrypted(sealed) {
+		t.Fatal("sealed data should be detected as encrypted")
+	}
+
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal(sealed, &envelope); err != nil {
+		t.Fatalf("failed to parse envelope: %v", err)
+	}
+	got, err := open(key, &envelope)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("plaintext mismatch: got %q", got)
+	}
+
+	// 錯誤的金鑰應解密失敗
+	wrongKey := bytes.Repeat([]byte{8}, keySize)
+	if _, err := open(wrongKey, &envelope); !errors.Is(err, ErrDecryptFailed) {
+		t.Errorf("expected ErrDecryptFailed with wrong key, got %v", err)
+	}
+}
+
+// TestReadBackupFile_PlaintextLegacy 測試舊版明文檔案可直接讀取
+func TestReadBackupFile_PlaintextLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KiroAuthTokenFile)
+	plaintext := []byte(`{"accessToken":"legacy"}`)
+	if err := os.WriteFile(path, plaintext, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readBackupFile(path)
+	if err != nil {
+		t.Fatalf("readBackupFile failed: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("plaintext mismatch: got %q", got)
+	}
+}
+
+// TestWriteBackupFile_KeyringRoundTrip 測試使用金鑰圈模式寫入後可透明讀取
+func TestWriteBackupFile_KeyringRoundTrip(t *testing.T) {
+	useFakeKeyring(t)
+
+	path := filepath.Join(t.TempDir(), KiroAuthTokenFile)
+	plaintext := []byte(`{"refreshToken":"rt-123"}`)
+	if err := writeBackupFile(path, plaintext); err != nil {
+		t.Fatalf("writeBackupFile failed: %v", err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, []byte("rt-123")) {
+		t.Fatal("file on disk must be encrypted")
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("encrypted file should not be group/world readable, got %v", info.Mode().Perm())
+	}
+
+	got, err := readBackupFile(path)
+	if err != nil {
+		t.Fatalf("readBackupFile failed: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("plaintext mismatch: got %q", got)
+	}
+}
+
+// TestDeriveAndVerify_WrongPassphrase 測試錯誤密語會被拒絕
+func TestDeriveAndVerify_WrongPassphrase(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key, err := deriveKey("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := seal(key, KeySourcePassphrase, []byte(verifierPlaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &encryptionInfo{
+		KeySource: KeySourcePassphrase,
+		Salt:      "MDEyMzQ1Njc4OWFiY2RlZg==",
+		Verifier:  string(verifier),
+	}
+
+	if _, err := deriveAndVerify(info, "correct horse"); err != nil {
+		t.Errorf("expected correct passphrase to verify, got %v", err)
+	}
+	if _, err := deriveAndVerify(info, "battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := deriveAndVerify(info, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+}
+
+// setupRekeyBackups 建立兩個以金鑰圈加密的備份，回傳所有憑證檔案的路徑與明文
+func setupRekeyBackups(t *testing.T) map[string]string {
+	t.Helper()
+	useFakeKeyring(t)
+	useTempEnv(t, "rekey-machine", time.Now())
+
+	rootPath, err := ensureBackupRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	createArchiveTestBackup(t, rootPath, "work", "rt-work")
+	createArchiveTestBackup(t, rootPath, "home", "rt-home")
+
+	files := make(map[string]string)
+	for _, name := range []string{"work", "home"} {
+		files[filepath.Join(rootPath, name, KiroAuthTokenFile)] = `{"refreshToken":"rt-` + name + `"}`
+		files[filepath.Join(rootPath, name, "abc123.json")] = `{"clientSecret":"cs-` + name + `"}`
+	}
+	return files
+}
+
+// assertRekeyFiles 檢查所有憑證檔案都能以目前的金鑰解密，且沒有殘留的暫存檔與日誌
+func assertRekeyFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for path, want := range files {
+		got, err := readBackupFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+		if _, err := os.Stat(path + rekeyStagedSuffix); !os.IsNotExist(err) {
+			t.Errorf("staged file left behind for %s", path)
+		}
+	}
+	rootPath, _ := GetBackupRootPath()
+	if _, err := os.Stat(filepath.Join(rootPath, rekeyJournalFileName)); !os.IsNotExist(err) {
+		t.Error("key source journal left behind")
+	}
+}
+
+// TestSetKeySource_RollbackOnReplaceFailure 測試改名途中失敗時，已取代的檔案與加密設定都會還原
+func TestSetKeySource_RollbackOnReplaceFailure(t *testing.T) {
+	files := setupRekeyBackups(t)
+
+	calls := 0
+	origRename := renameFile
+	renameFile = func(oldPath, newPath string) error {
+		if strings.HasSuffix(oldPath, rekeyStagedSuffix) {
+			calls++
+			if calls == 3 {
+				return errors.New("disk full")
+			}
+		}
+		return origRename(oldPath, newPath)
+	}
+	t.Cleanup(func() { renameFile = origRename })
+
+	if err := SetKeySource(KeySourcePassphrase, "correct horse"); err == nil {
+		t.Fatal("expected SetKeySource to fail")
+	}
+
+	status, err := GetEncryptionStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.KeySource != KeySourceKeyring || status.Locked {
+		t.Errorf("status = %+v, want the keyring source to be kept", status)
+	}
+	Lock()
+	assertRekeyFiles(t, files)
+}
+
+// TestSetKeySource_RecoverAfterCrash 測試提交後程式中斷時，下次讀取會依日誌完成切換
+func TestSetKeySource_RecoverAfterCrash(t *testing.T) {
+	files := setupRekeyBackups(t)
+
+	// 以 panic 模擬程式在改名途中結束
+	calls := 0
+	origRename := renameFile
+	renameFile = func(oldPath, newPath string) error {
+		if strings.HasSuffix(oldPath, rekeyStagedSuffix) {
+			calls++
+			if calls == 2 {
+				panic("crash")
+			}
+		}
+		return origRename(oldPath, newPath)
+	}
+	func() {
+		defer func() { recover() }()
+		SetKeySource(KeySourcePassphrase, "correct horse")
+	}()
+	renameFile = origRename
+
+	Lock()
+	if err := Unlock("correct horse"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if status, _ := GetEncryptionStatus(); status == nil || status.KeySource != KeySourcePassphrase {
+		t.Errorf("status = %+v, want the committed passphrase source", status)
+	}
+	assertRekeyFiles(t, files)
+}
@@ -0,0 +1,247 @@
+package backup
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/sysenv"
+)
+
+// useTempEnv 將家目錄與資料目錄指向 t.TempDir()，並固定 Machine ID 與時間
+func useTempEnv(t *testing.T, machineID string, now time.Time) sysenv.Fixed {
+	t.Helper()
+	root := t.TempDir()
+	env := sysenv.Fixed{
+		Home:         filepath.Join(root, "home"),
+		Data:         filepath.Join(root, "data"),
+		RawMachineID: machineID,
+		Time:         now,
+	}
+	t.Cleanup(sysenv.Set(env))
+	return env
+}
+
+// writeSSOFile 在暫存家目錄的 SSO cache 寫入檔案
+func writeSSOFile(t *testing.T, name, content string) {
+	t.Helper()
+	cachePath, err := awssso.GetSSOCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cachePath, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readSSOFile 讀取暫存家目錄 SSO cache 中的檔案
+func readSSOFile(t *testing.T, name string) string {
+	t.Helper()
+	cachePath, err := awssso.GetSSOCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(cachePath, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// TestBackupCycle 在暫存目錄中完整執行建立 → 切換 → 還原流程
+func TestBackupCycle(t *testing.T) {
+	useFakeKeyring(t)
+	now := time.Date(2025, 12, 7, 8, 30, 0, 0, time.UTC)
+	env := useTempEnv(t, "machine-under-test", now)
+
+	// 所有路徑都必須落在暫存目錄內
+	cachePath, _ := awssso.GetSSOCachePath()
+	rootPath, _ := GetBackupRootPath()
+	if !strings.HasPrefix(cachePath, env.Home) || !strings.HasPrefix(rootPath, env.Data) {
+		t.Fatalf("paths escaped temp dir: cache=%s backups=%s", cachePath, rootPath)
+	}
+
+	socialToken := `{"accessToken":"at-social","refreshToken":"rt-social","provider":"Github","authMethod":"social"}`
+	idcToken := `{"accessToken":"at-idc","refreshToken":"rt-idc","provider":"BuilderId","authMethod":"IdC","clientIdHash":"abc123"}`
+	idcClient := `{"clientId":"cid","clientSecret":"cs-idc"}`
+
+	// 建立：原始機器 + 兩個帳號
+	writeSSOFile(t, awssso.KiroAuthTokenFile, socialToken)
+	if created, err := EnsureOriginalBackup(); err != nil || !created {
+		t.Fatalf("EnsureOriginalBackup = %v, %v", created, err)
+	}
+	if err := CreateBackup("social"); err != nil {
+		t.Fatalf("create social: %v", err)
+	}
+
+	writeSSOFile(t, awssso.KiroAuthTokenFile, idcToken)
+	writeSSOFile(t, "abc123.json", idcClient)
+	if err := CreateBackup("idc"); err != nil {
+		t.Fatalf("create idc: %v", err)
+	}
+
+	for _, name := range []string{OriginalBackupName, "social", "idc"} {
+		mid, err := ReadBackupMachineID(name)
+		if err != nil {
+			t.Fatalf("read machine id of %s: %v", name, err)
+		}
+		if mid.MachineID != env.RawMachineID || mid.BackupTime != now.Format(time.RFC3339) {
+			t.Errorf("%s: machine id = %+v", name, mid)
+		}
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("expected 3 backups, got %+v", backups)
+	}
+
+	// 切換：恢復 social 帳號
+	if err := RestoreBackup("social"); err != nil {
+		t.Fatalf("switch to social: %v", err)
+	}
+	if got := readSSOFile(t, awssso.KiroAuthTokenFile); got != socialToken {
+		t.Errorf("after switch token = %s", got)
+	}
+
+	// 還原：切回 idc 帳號，連同 clientIdHash 檔案
+	writeSSOFile(t, "abc123.json", `{"clientId":"stale"}`)
+	if err := RestoreBackup("idc"); err != nil {
+		t.Fatalf("restore idc: %v", err)
+	}
+	if got := readSSOFile(t, awssso.KiroAuthTokenFile); got != idcToken {
+		t.Errorf("after restore token = %s", got)
+	}
+	if got := readSSOFile(t, "abc123.json"); got != idcClient {
+		t.Errorf("after restore client file = %s", got)
+	}
+
+	token, err := awssso.ReadKiroAuthToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := awssso.ParseKiroAuthToken([]byte(idcToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(token, want) {
+		t.Errorf("ReadKiroAuthToken = %+v", token)
+	}
+
+	// 交易完成後不應留下快照
+	if _, err := os.Stat(filepath.Join(cachePath, restoreSnapshotDirName)); !os.IsNotExist(err) {
+		t.Errorf("snapshot left behind: %v", err)
+	}
+}
+
+// TestBackupCycle_AccountCacheFiles 測試備份會帶走同帳號的所有 SSO cache 檔案，
+// 恢復時只覆寫 Kiro 的檔案，不影響其他 AWS CLI session
+func TestBackupCycle_AccountCacheFiles(t *testing.T) {
+	useFakeKeyring(t)
+	useTempEnv(t, "machine-under-test", time.Time{})
+
+	idcToken := `{"refreshToken":"rt-idc","authMethod":"IdC","provider":"Enterprise","startUrl":"https://a.awsapps.com/start"}`
+	session := `{"accessToken":"at-a","startUrl":"https://a.awsapps.com/start","clientId":"cid-a","clientSecret":"cs-a"}`
+	registration := `{"clientId":"cid-a","clientSecret":"cs-a","scopes":["sso:account:access"]}`
+	writeSSOFile(t, awssso.KiroAuthTokenFile, idcToken)
+	writeSSOFile(t, "0f1e2d.json", session)
+	writeSSOFile(t, "botocore-client-id-us-east-1.json", registration)
+	writeSSOFile(t, "cli-session.json", `{"accessToken":"at-cli","startUrl":"https://b.awsapps.com/start"}`)
+
+	if err := CreateBackup("enterprise"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	backupPath, _ := GetBackupPath("enterprise")
+	for _, name := range []string{"0f1e2d.json", "botocore-client-id-us-east-1.json"} {
+		if got := readPlain(t, filepath.Join(backupPath, name)); got == "" {
+			t.Errorf("%s not backed up", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(backupPath, "cli-session.json")); !os.IsNotExist(err) {
+		t.Errorf("unrelated session must not be backed up: %v", err)
+	}
+
+	// 沒有 clientIdHash 時從 startUrl 相同的檔案取得 IdC 憑證
+	token, err := awssso.ParseKiroAuthToken([]byte(idcToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, clientSecret, err := ReadBackupIdCCredentials("enterprise", token)
+	if err != nil || clientID != "cid-a" || clientSecret != "cs-a" {
+		t.Errorf("ReadBackupIdCCredentials = %q, %q, %v", clientID, clientSecret, err)
+	}
+
+	// 登入其他帳號後，AWS CLI 刷新了同一個 startUrl 的 session，註冊檔則被刪除
+	writeSSOFile(t, awssso.KiroAuthTokenFile, `{"refreshToken":"rt-social","provider":"Github"}`)
+	sessionRefreshed := `{"accessToken":"at-a-2","startUrl":"https://a.awsapps.com/start","clientId":"cid-a","clientSecret":"cs-a"}`
+	writeSSOFile(t, "0f1e2d.json", sessionRefreshed)
+	cliUpdated := `{"accessToken":"at-cli-2","startUrl":"https://b.awsapps.com/start"}`
+	writeSSOFile(t, "cli-session.json", cliUpdated)
+	cachePath, _ := awssso.GetSSOCachePath()
+	if err := os.Remove(filepath.Join(cachePath, "botocore-client-id-us-east-1.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 恢復時覆寫 Kiro 的 token，第三方檔案只在缺少時補回
+	if err := RestoreBackup("enterprise"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	for name, want := range map[string]string{
+		awssso.KiroAuthTokenFile:            idcToken,
+		"0f1e2d.json":                       sessionRefreshed,
+		"botocore-client-id-us-east-1.json": registration,
+		"cli-session.json":                  cliUpdated,
+	} {
+		if got := readSSOFile(t, name); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+// TestFindBackupByToken 測試以使用者識別找出同帳號的備份：刷新後的 token 仍視為同一帳號，
+// 共用 profileArn 的其他使用者或沒有使用者識別的 token 不會對應到備份
+func TestFindBackupByToken(t *testing.T) {
+	useFakeKeyring(t)
+	useTempEnv(t, "machine-under-test", time.Time{})
+
+	token := func(sub, refreshToken, provider string) string {
+		accessToken := "aoa-opaque"
+		if sub != "" {
+			accessToken = "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"`+sub+`"}`)) + ".sig"
+		}
+		return fmt.Sprintf(`{"accessToken":%q,"refreshToken":%q,"provider":%q,"profileArn":"arn:profile/KIRO"}`, accessToken, refreshToken, provider)
+	}
+	writeSSOFile(t, awssso.KiroAuthTokenFile, token("user-a", "rt-a", "Github"))
+	if err := CreateBackup("account-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		token string
+		want  string
+	}{
+		{token("user-a", "rt-a-2", "Github"), "account-a"},
+		{token("user-b", "rt-a", "Github"), ""},
+		{token("user-a", "rt-a", "Google"), ""},
+		{token("", "rt-a", "Github"), ""},
+	} {
+		token, err := awssso.ParseKiroAuthToken([]byte(tt.token))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := FindBackupByToken(token); err != nil || got != tt.want {
+			t.Errorf("FindBackupByToken(%s) = %q, %v, want %q", tt.token, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kiro-manager/logging"
+)
+
+// UsageHistoryFileName 用量歷史檔名（每行一筆 JSON，只會附加不會改寫）
+const UsageHistoryFileName = "usage-history.jsonl"
+
+// UsageHistoryEntry 單次成功查詢的用量紀錄
+type UsageHistoryEntry struct {
+	Time              time.Time `json:"time"`
+	SubscriptionTitle string    `json:"subscriptionTitle"`
+	UsageLimit        float64   `json:"usageLimit"`
+	CurrentUsage      float64   `json:"currentUsage"`
+	Balance           float64   `json:"balance"`
+}
+
+// AppendUsageHistory 將一筆用量紀錄附加到備份的歷史檔
+func AppendUsageHistory(name string, entry UsageHistoryEntry) error {
+	if _, err := ParseBackupName(name); err != nil {
+		return err
+	}
+	if !BackupExists(name) {
+		return ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return err
+	}
+	return appendUsageHistoryFile(filepath.Join(backupPath, UsageHistoryFileName), entry)
+}
+
+// ReadUsageHistory 讀取備份的所有用量紀錄（依時間排序）
+// 尚無歷史檔時返回空列表
+func ReadUsageHistory(name string) ([]UsageHistoryEntry, error) {
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
+	}
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return readUsageHistoryFile(filepath.Join(backupPath, UsageHistoryFileName))
+}
+
+// appendUsageHistoryFile 以 O_APPEND 寫入單行 JSON
+func appendUsageHistoryFile(path string, entry UsageHistoryEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage history: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append usage history: %w", err)
+	}
+	return f.Close()
+}
+
+// readUsageHistoryFile 逐行解析歷史檔
+// 無法解析的行（例如寫入中斷留下的半行）會被略過
+func readUsageHistoryFile(path string) ([]UsageHistoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []UsageHistoryEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open usage history: %w", err)
+	}
+	defer f.Close()
+
+	entries := []UsageHistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry UsageHistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logging.Warn("skipping malformed usage history line", "path", path, "line", lineNo, "err", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage history: %w", err)
+	}
+
+	// 附加寫入通常已依時間排序，仍保險排序一次（系統時間可能被調整）
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
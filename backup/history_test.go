@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestUsageHistoryFile_AppendAndRead 測試歷史紀錄附加後可依時間讀回，並略過損壞的行
+func TestUsageHistoryFile_AppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), UsageHistoryFileName)
+
+	entries, err := readUsageHistoryFile(path)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("missing file should return empty list, got %v (err %v)", entries, err)
+	}
+
+	base := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+	for _, e := range []UsageHistoryEntry{
+		{Time: base.Add(2 * time.Hour), UsageLimit: 100, CurrentUsage: 30, Balance: 70},
+		{Time: base, UsageLimit: 100, CurrentUsage: 10, Balance: 90},
+	} {
+		if err := appendUsageHistoryFile(path, e); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	// 模擬寫入中斷留下的半行
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2025-12-01T`)
+	f.Close()
+
+	entries, err = readUsageHistoryFile(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if !entries[0].Time.Equal(base) || entries[1].CurrentUsage != 30 {
+		t.Errorf("entries not sorted by time: %+v", entries)
+	}
+}
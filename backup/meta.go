@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kiro-manager/sysenv"
+)
+
+// MetaFileName 備份的使用者描述資料檔名
+const MetaFileName = "meta.json"
+
+// 描述資料欄位的長度上限（字元數）
+const (
+	maxDisplayNameLength = 100
+	maxEmailLength       = 254
+	maxTagLength         = 32
+	maxTagCount          = 20
+	maxNotesLength       = 2000
+)
+
+var ErrInvalidMeta = errors.New("invalid backup metadata")
+
+// BackupMeta 使用者為備份加上的描述資料（meta.json）
+type BackupMeta struct {
+	DisplayName string    `json:"displayName,omitempty"` // 顯示名稱
+	Email       string    `json:"email,omitempty"`       // 帳號 Email 或登入提示
+	Tags        []string  `json:"tags,omitempty"`        // 標籤，例如 team-a、personal
+	Notes       string    `json:"notes,omitempty"`       // 備註
+	UpdatedAt   time.Time `json:"updatedAt,omitempty"`
+}
+
+// ReadBackupMeta 讀取備份的描述資料，尚未設定時返回空的描述資料
+func ReadBackupMeta(name string) (*BackupMeta, error) {
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
+	}
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return readMetaFile(filepath.Join(backupPath, MetaFileName))
+}
+
+// WriteBackupMeta 寫入備份的描述資料（標籤會去除空白與重複）
+func WriteBackupMeta(name string, meta *BackupMeta) error {
+	if _, err := ParseBackupName(name); err != nil {
+		return err
+	}
+	if !BackupExists(name) {
+		return ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return err
+	}
+	return writeMetaFile(filepath.Join(backupPath, MetaFileName), meta)
+}
+
+// writeMetaFile 整理並寫入 meta.json
+func writeMetaFile(path string, meta *BackupMeta) error {
+	normalized, err := normalizeMeta(meta)
+	if err != nil {
+		return err
+	}
+	normalized.UpdatedAt = sysenv.Now()
+
+	data, err := json.MarshalIndent(normalized, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup metadata: %w", err)
+	}
+	return atomicWriteFile(path, data, 0600)
+}
+
+// readMetaFile 讀取 meta.json，檔案不存在時返回空的描述資料
+func readMetaFile(path string) (*BackupMeta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &BackupMeta{}, nil
+		}
+		return nil, err
+	}
+
+	var meta BackupMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse backup metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// normalizeMeta 去除前後空白、整理標籤並檢查長度
+func normalizeMeta(meta *BackupMeta) (*BackupMeta, error) {
+	if meta == nil {
+		return &BackupMeta{}, nil
+	}
+
+	normalized := &BackupMeta{
+		DisplayName: strings.TrimSpace(meta.DisplayName),
+		Email:       strings.TrimSpace(meta.Email),
+		Tags:        NormalizeTags(meta.Tags),
+		Notes:       strings.TrimSpace(meta.Notes),
+	}
+
+	switch {
+	case len([]rune(normalized.DisplayName)) > maxDisplayNameLength:
+		return nil, fmt.Errorf("%w: display name exceeds %d characters", ErrInvalidMeta, maxDisplayNameLength)
+	case len(normalized.Email) > maxEmailLength:
+		return nil, fmt.Errorf("%w: email exceeds %d characters", ErrInvalidMeta, maxEmailLength)
+	case len(normalized.Tags) > maxTagCount:
+		return nil, fmt.Errorf("%w: more than %d tags", ErrInvalidMeta, maxTagCount)
+	case len([]rune(normalized.Notes)) > maxNotesLength:
+		return nil, fmt.Errorf("%w: notes exceed %d characters", ErrInvalidMeta, maxNotesLength)
+	}
+	for _, tag := range normalized.Tags {
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("%w: tag %q exceeds %d characters", ErrInvalidMeta, tag, maxTagLength)
+		}
+	}
+	return normalized, nil
+}
+
+// NormalizeTags 去除標籤前後空白、空字串與重複（不分大小寫），保留原本順序
+func NormalizeTags(tags []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
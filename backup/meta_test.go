@@ -0,0 +1,55 @@
+package backup
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMetaFile_RoundTrip 測試描述資料的寫入、讀取與標籤整理
+func TestMetaFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), MetaFileName)
+
+	meta, err := readMetaFile(path)
+	if err != nil || !reflect.DeepEqual(*meta, BackupMeta{}) {
+		t.Fatalf("missing meta.json should read as empty, got %+v (err %v)", meta, err)
+	}
+
+	err = writeMetaFile(path, &BackupMeta{
+		DisplayName: "  Work account ",
+		Email:       "dev@example.com",
+		Tags:        []string{"team-a", " ", "Personal", "TEAM-A", " personal "},
+		Notes:       "shared with CI",
+	})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	meta, err = readMetaFile(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if meta.DisplayName != "Work account" || meta.Email != "dev@example.com" || meta.Notes != "shared with CI" {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+	if want := []string{"team-a", "Personal"}; !reflect.DeepEqual(meta.Tags, want) {
+		t.Errorf("tags = %v, want %v", meta.Tags, want)
+	}
+	if meta.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt should be set")
+	}
+
+	long := &BackupMeta{Tags: []string{strings.Repeat("x", maxTagLength+1)}}
+	if err := writeMetaFile(path, long); !errors.Is(err, ErrInvalidMeta) {
+		t.Errorf("expected ErrInvalidMeta, got %v", err)
+	}
+}
+
+// TestMetaFile_NotCredential 確認 meta.json 不會被當作憑證檔案加密
+func TestMetaFile_NotCredential(t *testing.T) {
+	if isSSOCacheFileName(MetaFileName) {
+		t.Errorf("%s must not be treated as an SSO cache file", MetaFileName)
+	}
+}
@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MaxBackupNameLength 備份名稱的長度上限（字元數）
+const MaxBackupNameLength = 64
+
+// backupNamePunctuation 備份名稱允許的標點符號（其餘只允許文字、數字與空白）
+const backupNamePunctuation = "-_.@+()[]#&,'~="
+
+// windowsReservedNames Windows 保留的裝置名稱，不論副檔名都無法作為目錄名稱
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// BackupName 已驗證的備份名稱，可安全地作為備份根目錄下的目錄名稱
+// 只能透過 ParseBackupName 或 NewBackupName 取得
+type BackupName string
+
+// String 返回備份名稱
+func (n BackupName) String() string {
+	return string(n)
+}
+
+// IsOriginal 是否為保留給原始 Machine ID 的備份
+func (n BackupName) IsOriginal() bool {
+	return string(n) == OriginalBackupName
+}
+
+// dir 返回備份在 rootPath 下的目錄
+func (n BackupName) dir(rootPath string) string {
+	return filepath.Join(rootPath, string(n))
+}
+
+// ParseBackupName 驗證既有備份的名稱
+// 只允許文字、數字、空白與 backupNamePunctuation 中的符號；
+// 不可以 . 或空白開頭、不可以 . 或空白結尾、不可為 Windows 保留名稱，長度不超過 MaxBackupNameLength
+func ParseBackupName(name string) (BackupName, error) {
+	if name == "" {
+		return "", ErrInvalidBackupName
+	}
+	if n := len([]rune(name)); n > MaxBackupNameLength {
+		return "", fmt.Errorf("%w: name exceeds %d characters", ErrInvalidBackupName, MaxBackupNameLength)
+	}
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, " ") {
+		return "", fmt.Errorf("%w: name must not start with a dot or space", ErrInvalidBackupName)
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return "", fmt.Errorf("%w: name must not end with a dot or space", ErrInvalidBackupName)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != ' ' && !strings.ContainsRune(backupNamePunctuation, r) {
+			return "", fmt.Errorf("%w: character %q is not allowed", ErrInvalidBackupName, r)
+		}
+	}
+	base, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimSpace(base))] {
+		return "", fmt.Errorf("%w: %q is reserved on Windows", ErrInvalidBackupName, name)
+	}
+	return BackupName(name), nil
+}
+
+// NewBackupName 驗證要建立（或改名、複製、匯入）的備份名稱
+// 除了 ParseBackupName 的規則外，不允許使用保留名稱 original（不分大小寫）
+func NewBackupName(name string) (BackupName, error) {
+	n, err := ParseBackupName(name)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(name, OriginalBackupName) {
+		return "", fmt.Errorf("%w: %q is reserved", ErrInvalidBackupName, name)
+	}
+	return n, nil
+}
+
+// findBackupFold 在 rootPath 下尋找與 name 只差在大小寫的既有備份
+// 不分大小寫的檔案系統（Windows、macOS 預設）上兩者會指向同一個目錄，
+// 分大小寫的檔案系統上也一併視為衝突，讓備份可以在不同系統間搬移
+func findBackupFold(rootPath string, name BackupName) (string, bool) {
+	entries, err := os.ReadDir(rootPath)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), string(name)) {
+			return entry.Name(), true
+		}
+	}
+	return "", false
+}
+
+// checkNameAvailable 確認 rootPath 下沒有同名（不分大小寫）的備份，except 為允許的既有名稱（改名時的來源）
+func checkNameAvailable(rootPath string, name BackupName, except string) error {
+	if existing, ok := findBackupFold(rootPath, name); ok && existing != except {
+		if existing == string(name) {
+			return ErrBackupExists
+		}
+		return fmt.Errorf("%w: conflicts with %q", ErrBackupExists, existing)
+	}
+	return nil
+}
+
+// validClientIdHash 檢查 token 中的 clientIdHash 是否可安全地作為檔名（只允許英數字、- 與 _）
+// clientIdHash 來自 token 內容，匯入的備份可能被竄改，不可直接拼接路徑
+func validClientIdHash(hash string) bool {
+	if hash == "" || len(hash) > 128 {
+		return false
+	}
+	for _, r := range hash {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSSOCacheFileName 檢查 SSO cache 檔名是否可安全地寫入 SSO cache 目錄
+// 檔名主體的規則與 clientIdHash 相同（AWS 以 SHA-1 雜湊或 botocore-client-id-<region> 命名）
+func validSSOCacheFileName(name string) bool {
+	return isSSOCacheFileName(name) && validClientIdHash(strings.TrimSuffix(name, ".json"))
+}
@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kiro-manager/awssso"
+)
+
+// TestParseBackupName 測試備份名稱的驗證（含路徑穿越等惡意輸入）
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"work", true},
+		{"client-a_2024 (test)", true},
+		{"dev@example.com", true},
+		{"工作帳號", true},
+		{"v1.2", true},
+		{OriginalBackupName, true},
+		{strings.Repeat("a", MaxBackupNameLength), true},
+
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../../.aws", false},
+		{"..\\..\\Windows", false},
+		{"a/b", false},
+		{"a\\b", false},
+		{"/etc", false},
+		{"C:", false},
+		{"C:\\Users", false},
+		{".hidden", false},
+		{" leading", false},
+		{"trailing ", false},
+		{"trailing.", false},
+		{"nul\x00byte", false},
+		{"line\nbreak", false},
+		{"tab\tname", false},
+		{"semi;colon", false},
+		{"star*", false},
+		{"quest?", false},
+		{"pipe|name", false},
+		{"<angle>", false},
+		{"quote\"name", false},
+		{"dollar$HOME", false},
+		{"back`tick`", false},
+		{"%2e%2e", false},
+		{"CON", false},
+		{"con.txt", false},
+		{"Lpt1", false},
+		{"aux.backup", false},
+		{strings.Repeat("a", MaxBackupNameLength+1), false},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseBackupName(tt.name)
+		if tt.valid && err != nil {
+			t.Errorf("ParseBackupName(%q) unexpected error: %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidBackupName) {
+			t.Errorf("ParseBackupName(%q) = %v, want ErrInvalidBackupName", tt.name, err)
+		}
+	}
+}
+
+// TestNewBackupName_Reserved 測試保留名稱不分大小寫
+func TestNewBackupName_Reserved(t *testing.T) {
+	for _, name := range []string{"original", "Original", "ORIGINAL"} {
+		if _, err := NewBackupName(name); !errors.Is(err, ErrInvalidBackupName) {
+			t.Errorf("NewBackupName(%q) = %v, want ErrInvalidBackupName", name, err)
+		}
+	}
+	if _, err := NewBackupName("original-2"); err != nil {
+		t.Errorf("NewBackupName(original-2) unexpected error: %v", err)
+	}
+}
+
+// TestCheckNameAvailable 測試只差在大小寫的名稱視為衝突
+func TestCheckNameAvailable(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "Work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkNameAvailable(root, "work", ""); !errors.Is(err, ErrBackupExists) {
+		t.Errorf("expected ErrBackupExists for a case-only collision, got %v", err)
+	}
+	if err := checkNameAvailable(root, "Work", ""); !errors.Is(err, ErrBackupExists) {
+		t.Errorf("expected ErrBackupExists, got %v", err)
+	}
+	if err := checkNameAvailable(root, "work", "Work"); err != nil {
+		t.Errorf("renaming Work to work should be allowed, got %v", err)
+	}
+	if err := checkNameAvailable(root, "home", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestValidClientIdHash 測試 token 中的 clientIdHash 不可用於路徑穿越
+func TestValidClientIdHash(t *testing.T) {
+	for hash, want := range map[string]bool{
+		"0123abcdef":             true,
+		"abc_DEF-123":            true,
+		"":                       false,
+		"../../.ssh/id":          false,
+		"a/b":                    false,
+		"a\\b":                   false,
+		"abc.json":               false,
+		strings.Repeat("a", 129): false,
+	} {
+		if got := validClientIdHash(hash); got != want {
+			t.Errorf("validClientIdHash(%q) = %v, want %v", hash, got, want)
+		}
+	}
+}
+
+// TestExportedFunctions_RejectTraversal 確認所有以名稱存取備份的函式都會拒絕不合法的名稱
+func TestExportedFunctions_RejectTraversal(t *testing.T) {
+	for _, name := range []string{"../../.aws", "..", "a/../../b", "C:\\Windows"} {
+		calls := map[string]func() error{
+			"GetBackupPath":             func() error { _, err := GetBackupPath(name); return err },
+			"CreateBackup":              func() error { return CreateBackup(name) },
+			"CreateMachineIDOnlyBackup": func() error { return CreateMachineIDOnlyBackup(name) },
+			"DeleteBackup":              func() error { return DeleteBackup(name) },
+			"RenameBackup":              func() error { return RenameBackup(name, "safe") },
+			"RenameBackup (target)":     func() error { return RenameBackup("safe", name) },
+			"CloneBackup":               func() error { return CloneBackup("safe", name) },
+			"GetBackupInfo":             func() error { _, err := GetBackupInfo(name); return err },
+			"ReadBackupMachineID":       func() error { _, err := ReadBackupMachineID(name); return err },
+			"ReadBackupToken":           func() error { _, err := ReadBackupToken(name); return err },
+			"ReadUsageCache":            func() error { _, err := ReadUsageCache(name); return err },
+			"WriteUsageCache":           func() error { return WriteUsageCache(name, &UsageCache{}) },
+			"ReadUsageHistory":          func() error { _, err := ReadUsageHistory(name); return err },
+			"ReadBackupMeta":            func() error { _, err := ReadBackupMeta(name); return err },
+			"WriteBackupMeta":           func() error { return WriteBackupMeta(name, &BackupMeta{}) },
+			"WriteBackupToken":          func() error { return WriteBackupToken(name, &awssso.KiroAuthToken{}) },
+			"RestoreBackup":             func() error { return RestoreBackup(name) },
+		}
+		for fn, call := range calls {
+			if err := call(); !errors.Is(err, ErrInvalidBackupName) {
+				t.Errorf("%s(%q) = %v, want ErrInvalidBackupName", fn, name, err)
+			}
+		}
+	}
+}
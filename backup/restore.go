@@ -0,0 +1,410 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/logging"
+	"kiro-manager/sysenv"
+)
+
+// RestoreStage 恢復交易的階段
+type RestoreStage string
+
+const (
+	RestoreStagePrepare  RestoreStage = "prepare"  // 讀取並解密備份檔案
+	RestoreStageSnapshot RestoreStage = "snapshot" // 快照目前的 SSO cache 檔案
+	RestoreStageWrite    RestoreStage = "write"    // 寫入暫存檔並 fsync
+	RestoreStageCommit   RestoreStage = "commit"   // 將暫存檔改名至正式位置
+)
+
+const (
+	restoreSnapshotDirName = ".kiro-manager-restore"
+	restoreJournalFileName = "journal.json"
+	restoreTempSuffix      = ".kiro-manager.tmp"
+)
+
+// RestoreError 恢復失敗時的錯誤，記錄失敗的階段與回滾結果
+// prepare、snapshot、write 階段失敗時 SSO cache 尚未被修改
+type RestoreError struct {
+	Stage       RestoreStage
+	Err         error
+	RolledBack  bool  // commit 階段失敗後是否已回滾至恢復前的狀態
+	RollbackErr error // 回滾失敗的原因（快照會保留，下次啟動時再次嘗試）
+}
+
+func (e *RestoreError) Error() string {
+	msg := fmt.Sprintf("restore failed at %s stage: %v", e.Stage, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(" (rollback failed: %v)", e.RollbackErr)
+	}
+	return msg
+}
+
+func (e *RestoreError) Unwrap() error {
+	return e.Err
+}
+
+// restoreFile 待寫入 SSO cache 的檔案
+type restoreFile struct {
+	Name string
+	Data []byte
+	// KeepExisting 第三方檔案（例如 AWS CLI 的 session）已存在時保留目前內容，只在缺少時寫入
+	KeepExisting bool
+}
+
+// restoreJournal 快照日誌，存在即代表快照已完整寫入
+type restoreJournal struct {
+	Backup    string         `json:"backup"`
+	StartedAt string         `json:"startedAt"`
+	Files     []journalEntry `json:"files"`
+}
+
+// journalEntry 快照中單一檔案的原始狀態
+type journalEntry struct {
+	Name    string      `json:"name"`
+	Existed bool        `json:"existed"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+}
+
+// renameFile 將暫存檔改名至正式位置（測試時可替換以模擬失敗）
+var renameFile = os.Rename
+
+// RestoreBackup 恢復指定的備份
+// 以交易方式執行：快照目前的 SSO cache 檔案 → 寫入暫存檔並 fsync → 改名至正式位置
+// 任一步驟失敗都會自動回滾，並回傳帶有失敗階段的 *RestoreError
+func RestoreBackup(name string) error {
+	files, err := prepareRestoreFiles(name)
+	if err != nil {
+		return &RestoreError{Stage: RestoreStagePrepare, Err: err}
+	}
+
+	ssoCachePath, err := awssso.GetSSOCachePath()
+	if err != nil {
+		return &RestoreError{Stage: RestoreStagePrepare, Err: fmt.Errorf("failed to get sso cache path: %w", err)}
+	}
+
+	return restoreFiles(ssoCachePath, name, files)
+}
+
+// RecoverInterruptedRestore 檢查是否有中斷的恢復交易（例如程式在切換途中崩潰）
+// 若有則回滾至恢復前的狀態，回傳 true 表示已執行回滾
+func RecoverInterruptedRestore() (bool, error) {
+	ssoCachePath, err := awssso.GetSSOCachePath()
+	if err != nil {
+		return false, err
+	}
+	return recoverRestore(ssoCachePath)
+}
+
+// prepareRestoreFiles 讀取並解密備份中需要恢復的檔案
+func prepareRestoreFiles(name string) ([]restoreFile, error) {
+	if _, err := ParseBackupName(name); err != nil {
+		return nil, err
+	}
+
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenData, err := readBackupFile(filepath.Join(backupPath, KiroAuthTokenFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("backup token file not found")
+		}
+		return nil, fmt.Errorf("failed to read backup token: %w", err)
+	}
+
+	// 先確認 token 內容可解析，避免以損毀的檔案覆蓋目前的登入
+	if _, err := awssso.ParseKiroAuthToken(tokenData); err != nil {
+		return nil, fmt.Errorf("failed to parse backup token: %w", err)
+	}
+
+	files := []restoreFile{{Name: KiroAuthTokenFile, Data: tokenData}}
+
+	// 一併恢復備份時記錄的同帳號 SSO cache 檔案，其他檔案（例如 AWS CLI 其他 session）不受影響
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !isSSOCacheFile(entry) {
+			continue
+		}
+		if !validSSOCacheFileName(entry.Name()) {
+			logging.Warn("skipping sso cache file with unsafe name", "backup", name, "file", entry.Name())
+			continue
+		}
+		data, err := readBackupFile(filepath.Join(backupPath, entry.Name()))
+		if err != nil {
+			// 恢復附屬檔案失敗不應該阻止整個恢復流程，只記錄警告
+			logging.Warn("failed to read sso cache file", "backup", name, "file", entry.Name(), "err", err)
+			continue
+		}
+		files = append(files, restoreFile{Name: entry.Name(), Data: data})
+	}
+
+	// 只有 Kiro 的檔案會被覆寫，其他工具的 session 只在缺少時補回
+	contents := make(map[string][]byte, len(files))
+	for _, f := range files {
+		contents[f.Name] = f.Data
+	}
+	owned := make(map[string]bool, len(files))
+	for _, c := range awssso.Classify(contents) {
+		owned[c.Name] = c.KiroOwned()
+	}
+	for i := range files {
+		files[i].KeepExisting = !owned[files[i].Name]
+	}
+
+	return files, nil
+}
+
+// restoreFiles 以交易方式將檔案寫入 ssoDir
+func restoreFiles(ssoDir, backupName string, files []restoreFile) error {
+	if err := os.MkdirAll(ssoDir, 0755); err != nil {
+		return &RestoreError{Stage: RestoreStageSnapshot, Err: fmt.Errorf("failed to create sso cache directory: %w", err)}
+	}
+
+	// 若前一次恢復中斷，先回滾再開始新的交易
+	if _, err := recoverRestore(ssoDir); err != nil {
+		return &RestoreError{Stage: RestoreStageSnapshot, Err: fmt.Errorf("failed to recover interrupted restore: %w", err)}
+	}
+
+	files = skipExistingFiles(ssoDir, files)
+
+	journal, err := takeSnapshot(ssoDir, backupName, files)
+	if err != nil {
+		os.RemoveAll(filepath.Join(ssoDir, restoreSnapshotDirName))
+		return &RestoreError{Stage: RestoreStageSnapshot, Err: err}
+	}
+
+	tmpPaths := make([]string, 0, len(files))
+	for i, f := range files {
+		tmpPath, err := writeTempFile(filepath.Join(ssoDir, f.Name), f.Data, journal.Files[i].Mode)
+		if err != nil {
+			removeFiles(tmpPaths)
+			os.RemoveAll(filepath.Join(ssoDir, restoreSnapshotDirName))
+			return &RestoreError{Stage: RestoreStageWrite, Err: err}
+		}
+		tmpPaths = append(tmpPaths, tmpPath)
+	}
+
+	commitErr := func() error {
+		for i, f := range files {
+			if err := renameFile(tmpPaths[i], filepath.Join(ssoDir, f.Name)); err != nil {
+				return err
+			}
+		}
+		return syncDir(ssoDir)
+	}()
+	if commitErr != nil {
+		removeFiles(tmpPaths)
+		rollbackErr := rollbackSnapshot(ssoDir, journal)
+		return &RestoreError{
+			Stage:       RestoreStageCommit,
+			Err:         commitErr,
+			RolledBack:  rollbackErr == nil,
+			RollbackErr: rollbackErr,
+		}
+	}
+
+	// 交易完成，移除快照
+	os.RemoveAll(filepath.Join(ssoDir, restoreSnapshotDirName))
+	return nil
+}
+
+// skipExistingFiles 排除標記為 KeepExisting 且已存在於 ssoDir 的檔案
+func skipExistingFiles(ssoDir string, files []restoreFile) []restoreFile {
+	kept := files[:0:0]
+	for _, f := range files {
+		if f.KeepExisting {
+			if _, err := os.Lstat(filepath.Join(ssoDir, f.Name)); err == nil {
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// takeSnapshot 將即將被覆蓋的檔案複製到快照目錄，最後寫入日誌
+func takeSnapshot(ssoDir, backupName string, files []restoreFile) (*restoreJournal, error) {
+	snapshotDir := filepath.Join(ssoDir, restoreSnapshotDirName)
+	if err := os.RemoveAll(snapshotDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(snapshotDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	journal := &restoreJournal{
+		Backup:    backupName,
+		StartedAt: sysenv.Now().Format(time.RFC3339),
+	}
+
+	for _, f := range files {
+		entry := journalEntry{Name: f.Name, Mode: 0600}
+		src := filepath.Join(ssoDir, f.Name)
+		data, err := os.ReadFile(src)
+		switch {
+		case err == nil:
+			entry.Existed = true
+			if info, statErr := os.Stat(src); statErr == nil {
+				entry.Mode = info.Mode().Perm()
+			}
+			if err := writeSyncedFile(filepath.Join(snapshotDir, f.Name), data, 0600); err != nil {
+				return nil, fmt.Errorf("failed to snapshot %s: %w", f.Name, err)
+			}
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		journal.Files = append(journal.Files, entry)
+	}
+
+	journalData, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeSyncedFile(filepath.Join(snapshotDir, restoreJournalFileName), journalData, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write restore journal: %w", err)
+	}
+	if err := syncDir(snapshotDir); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// rollbackSnapshot 依日誌將檔案還原為快照時的狀態
+// 全部還原成功才移除快照，否則保留以便下次重試
+func rollbackSnapshot(ssoDir string, journal *restoreJournal) error {
+	snapshotDir := filepath.Join(ssoDir, restoreSnapshotDirName)
+
+	var firstErr error
+	for _, entry := range journal.Files {
+		dst := filepath.Join(ssoDir, entry.Name)
+		os.Remove(dst + restoreTempSuffix)
+
+		var err error
+		if entry.Existed {
+			var data []byte
+			data, err = os.ReadFile(filepath.Join(snapshotDir, entry.Name))
+			if err == nil {
+				err = atomicWriteFile(dst, data, entry.Mode)
+			}
+		} else if removeErr := os.Remove(dst); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			err = removeErr
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to roll back %s: %w", entry.Name, err)
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if err := syncDir(ssoDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(snapshotDir)
+}
+
+// recoverRestore 若 ssoDir 中有完整的快照日誌則回滾，沒有日誌時僅清除殘留的快照目錄
+func recoverRestore(ssoDir string) (bool, error) {
+	snapshotDir := filepath.Join(ssoDir, restoreSnapshotDirName)
+	data, err := os.ReadFile(filepath.Join(snapshotDir, restoreJournalFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// 快照未完成時 SSO cache 尚未被修改
+			return false, os.RemoveAll(snapshotDir)
+		}
+		return false, err
+	}
+
+	var journal restoreJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return false, fmt.Errorf("failed to parse restore journal: %w", err)
+	}
+
+	if err := rollbackSnapshot(ssoDir, &journal); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// atomicWriteFile 先寫入同目錄的暫存檔並 fsync，再改名覆蓋目標檔案
+// 寫到一半中斷時目標檔案維持原狀
+func atomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTempFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// writeTempFile 將資料寫入 path 對應的暫存檔並 fsync，回傳暫存檔路徑
+func writeTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmpPath := path + restoreTempSuffix
+	if err := writeSyncedFile(tmpPath, data, perm); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
+// writeSyncedFile 寫入檔案並 fsync 後關閉
+func writeSyncedFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// OpenFile 的權限只在建立檔案時生效，且受 umask 影響
+	return os.Chmod(path, perm)
+}
+
+// syncDir fsync 目錄以確保改名已寫入磁碟（Windows 不支援，直接略過）
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// removeFiles 刪除檔案並忽略錯誤
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		os.Remove(p)
+	}
+}
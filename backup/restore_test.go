@@ -0,0 +1,134 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRestoreFiles_Success 測試恢復成功後檔案被替換且快照被移除
+func TestRestoreFiles_Success(t *testing.T) {
+	ssoDir := t.TempDir()
+	tokenPath := filepath.Join(ssoDir, KiroAuthTokenFile)
+	if err := os.WriteFile(tokenPath, []byte(`{"accessToken":"old"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []restoreFile{
+		{Name: KiroAuthTokenFile, Data: []byte(`{"accessToken":"new"}`)},
+		{Name: "abc123.json", Data: []byte(`{"clientId":"id"}`)},
+	}
+	if err := restoreFiles(ssoDir, "work", files); err != nil {
+		t.Fatalf("restoreFiles failed: %v", err)
+	}
+
+	for _, f := range files {
+		got, err := os.ReadFile(filepath.Join(ssoDir, f.Name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		if string(got) != string(f.Data) {
+			t.Errorf("%s: got %q, want %q", f.Name, got, f.Data)
+		}
+		if _, err := os.Stat(filepath.Join(ssoDir, f.Name+restoreTempSuffix)); !os.IsNotExist(err) {
+			t.Errorf("temp file for %s should be removed", f.Name)
+		}
+	}
+
+	// 原有檔案的權限應保留
+	if info, err := os.Stat(tokenPath); err == nil && info.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644 to be preserved, got %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(ssoDir, restoreSnapshotDirName)); !os.IsNotExist(err) {
+		t.Error("snapshot directory should be removed after commit")
+	}
+}
+
+// TestRestoreFiles_RollbackOnCommitFailure 測試改名途中失敗會回滾所有檔案
+func TestRestoreFiles_RollbackOnCommitFailure(t *testing.T) {
+	ssoDir := t.TempDir()
+	tokenPath := filepath.Join(ssoDir, KiroAuthTokenFile)
+	oldToken := []byte(`{"accessToken":"old"}`)
+	if err := os.WriteFile(tokenPath, oldToken, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二個檔案改名時失敗，此時 token 已被替換
+	injected := errors.New("disk full")
+	calls := 0
+	origRename := renameFile
+	renameFile = func(oldpath, newpath string) error {
+		calls++
+		if calls == 2 {
+			return injected
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	t.Cleanup(func() { renameFile = origRename })
+
+	files := []restoreFile{
+		{Name: KiroAuthTokenFile, Data: []byte(`{"accessToken":"new"}`)},
+		{Name: "abc123.json", Data: []byte(`{"clientId":"id"}`)},
+	}
+	err := restoreFiles(ssoDir, "work", files)
+
+	var restoreErr *RestoreError
+	if !errors.As(err, &restoreErr) {
+		t.Fatalf("expected *RestoreError, got %v", err)
+	}
+	if restoreErr.Stage != RestoreStageCommit {
+		t.Errorf("expected stage %q, got %q", RestoreStageCommit, restoreErr.Stage)
+	}
+	if !restoreErr.RolledBack || !errors.Is(err, injected) {
+		t.Errorf("expected rolled back error wrapping injected error, got %+v", restoreErr)
+	}
+
+	got, _ := os.ReadFile(tokenPath)
+	if string(got) != string(oldToken) {
+		t.Errorf("token should be rolled back, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(ssoDir, "abc123.json")); !os.IsNotExist(err) {
+		t.Error("file that did not exist before restore should be removed")
+	}
+	entries, _ := os.ReadDir(ssoDir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the original token to remain, got %d entries", len(entries))
+	}
+}
+
+// TestRecoverRestore_InterruptedTransaction 測試崩潰後留下的快照會在下次啟動時回滾
+func TestRecoverRestore_InterruptedTransaction(t *testing.T) {
+	ssoDir := t.TempDir()
+	tokenPath := filepath.Join(ssoDir, KiroAuthTokenFile)
+	oldToken := []byte(`{"accessToken":"old"}`)
+	if err := os.WriteFile(tokenPath, oldToken, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []restoreFile{{Name: KiroAuthTokenFile, Data: []byte(`{"accessToken":"new"}`)}}
+	if _, err := takeSnapshot(ssoDir, "work", files); err != nil {
+		t.Fatalf("takeSnapshot failed: %v", err)
+	}
+	// 模擬寫到一半崩潰：token 已被截斷
+	if err := os.WriteFile(tokenPath, []byte(`{"acc`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := recoverRestore(ssoDir)
+	if err != nil {
+		t.Fatalf("recoverRestore failed: %v", err)
+	}
+	if !recovered {
+		t.Error("expected interrupted restore to be recovered")
+	}
+	got, _ := os.ReadFile(tokenPath)
+	if string(got) != string(oldToken) {
+		t.Errorf("token should be recovered, got %q", got)
+	}
+
+	// 沒有快照時不做任何事
+	if recovered, err := recoverRestore(ssoDir); err != nil || recovered {
+		t.Errorf("expected no-op, got recovered=%v err=%v", recovered, err)
+	}
+}
@@ -0,0 +1,1435 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/backup"
+	"kiro-manager/datadir"
+	"kiro-manager/httpclient"
+	"kiro-manager/kiropath"
+	"kiro-manager/kiroprocess"
+	"kiro-manager/kiroversion"
+	"kiro-manager/machineid"
+	"kiro-manager/report"
+	"kiro-manager/settings"
+	"kiro-manager/usage"
+)
+
+// CLI 結束碼
+const (
+	exitOK          = 0 // 成功
+	exitError       = 1 // 一般錯誤
+	exitUsage       = 2 // 參數錯誤
+	exitNotFound    = 3 // 備份或安裝設定檔不存在
+	exitConflict    = 4 // 備份或安裝設定檔已存在
+	exitKiroRunning = 5 // Kiro 正在運行（未指定 --force）
+	exitLocked      = 6 // 備份已上鎖或密語錯誤
+	exitRemote      = 7 // Token 刷新或 API 呼叫失敗
+)
+
+// PassphraseEnv 密語模式下用於解鎖備份的環境變數
+const PassphraseEnv = "KIRO_MANAGER_PASSPHRASE"
+
+// ArchivePassphraseEnv 加密 / 解密備份封存檔的密語環境變數
+const ArchivePassphraseEnv = "KIRO_MANAGER_ARCHIVE_PASSPHRASE"
+
+const cliUsage = `Usage: kiro-manager [--json] [--data-dir <dir>] <command> [arguments]
+
+Commands:
+  backup create <name>            Back up the current Kiro login
+  backup list [--search S] [--tag a,b] [--provider P] [--token valid|expired]
+              [--low-balance] [--sort name|backupTime|tag|provider|expiry|balance] [--desc]
+                                  List backups
+  backup restore [--force] <name> Switch Kiro to a backup (--force closes Kiro first,
+                                  force closing it if it does not exit in time)
+  backup delete <name>            Delete a backup
+  backup rename <old> <new>       Rename a backup
+  backup clone <src> <dst>        Duplicate a backup under a new name
+  backup show <name>              Show backup details
+  backup meta [--display-name S] [--email S] [--tags a,b] [--notes S] <name>
+                                  Set the display name, email, tags and notes of a backup
+  backup export [--all] [--encrypt] -o <file> [name...]
+                                  Export backups to a portable archive
+  backup import [--on-conflict skip|overwrite|rename] <file>
+                                  Import backups from an archive
+  usage refresh [--all] [name...] Refresh cached usage of backups
+  usage report [--days N] [--csv] [name...]
+                                  Report daily consumption, burn rate and projected
+                                  zero-balance date (all backups when no name is given)
+  token refresh <name>            Refresh the access token stored in a backup
+  settings get [key]              Show settings
+  settings set <key> <value>      Change a setting
+  kiro status                     Show running Kiro processes and their roles
+  kiro kill [--grace N]           Ask Kiro to close, then force close it after N seconds
+  kiro path                       Show Kiro paths
+  kiro installs                   List every Kiro installation found (use one with
+                                  settings set customKiroInstallPath <path>)
+  kiro version                    Show the installed Kiro version
+  profile list                    List Kiro installation profiles with their version
+                                  and running state
+  profile add [--version V] <name> <path>
+                                  Add or update a named Kiro installation
+  profile remove <name>           Remove an installation profile
+  profile use <name> | --default  Run every command against this installation
+
+Flags:
+  --json            Print machine-readable JSON
+  --data-dir <dir>  Directory for settings, backups and logs
+
+Environment:
+  ` + datadir.EnvHome + `                Data directory (overridden by --data-dir)
+  ` + PassphraseEnv + `          Passphrase used to unlock backups in passphrase mode
+  ` + ArchivePassphraseEnv + `  Passphrase used to encrypt / decrypt backup archives
+`
+
+// cliError 帶有結束碼的錯誤
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf 建立參數錯誤
+func usageErrorf(format string, args ...interface{}) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// exitCodeFor 依錯誤類型決定結束碼
+func exitCodeFor(err error) int {
+	var ce *cliError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ce):
+		return ce.code
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return exitNotFound
+	case errors.Is(err, backup.ErrBackupExists):
+		return exitConflict
+	case errors.Is(err, backup.ErrInvalidBackupName):
+		return exitUsage
+	case errors.Is(err, settings.ErrKiroProfileNotFound):
+		return exitNotFound
+	case errors.Is(err, settings.ErrKiroProfileExists):
+		return exitConflict
+	case errors.Is(err, settings.ErrKiroProfileNameRequired), errors.Is(err, settings.ErrInstallPathNotFound):
+		return exitUsage
+	case errors.Is(err, backup.ErrPassphraseRequired), errors.Is(err, backup.ErrWrongPassphrase):
+		return exitLocked
+	case errors.Is(err, httpclient.ErrUnauthorized), errors.Is(err, httpclient.ErrRateLimited),
+		errors.Is(err, httpclient.ErrServerUnavailable), errors.Is(err, httpclient.ErrNetwork):
+		return exitRemote
+	}
+	return exitError
+}
+
+// cliRunner 執行單次 CLI 指令的狀態
+type cliRunner struct {
+	app    *App
+	stdout io.Writer
+	stderr io.Writer
+	json   bool
+}
+
+// runCLI 解析參數並執行指令，回傳結束碼
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	r := &cliRunner{app: NewApp(), stdout: stdout, stderr: stderr}
+
+	fs := r.newFlagSet("kiro-manager")
+	dataDir := fs.String(datadir.FlagName, "", "directory for settings, backups and logs")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprint(stderr, cliUsage)
+		return exitUsage
+	}
+	if *dataDir != "" {
+		datadir.SetOverride(*dataDir)
+	}
+
+	err := r.dispatch(fs.Arg(0), fs.Args()[1:])
+	if err != nil {
+		r.printError(err)
+	}
+	return exitCodeFor(err)
+}
+
+// newFlagSet 建立子指令的 FlagSet，所有層級都接受 --json
+func (r *cliRunner) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(r.stderr)
+	fs.BoolVar(&r.json, "json", r.json, "print machine-readable JSON")
+	fs.Usage = func() { fmt.Fprint(r.stderr, cliUsage) }
+	return fs
+}
+
+// parseFlags 解析子指令參數，參數錯誤時回傳 exitUsage
+func (r *cliRunner) parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return &cliError{code: exitUsage, err: err}
+	}
+	return nil
+}
+
+// dispatch 依指令分派
+func (r *cliRunner) dispatch(command string, args []string) error {
+	switch command {
+	case "backup":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"create":  r.backupCreate,
+			"list":    r.backupList,
+			"restore": r.backupRestore,
+			"delete":  r.backupDelete,
+			"rename":  r.backupRename,
+			"clone":   r.backupClone,
+			"show":    r.backupShow,
+			"meta":    r.backupMeta,
+			"export":  r.backupExport,
+			"import":  r.backupImport,
+		})
+	case "usage":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"refresh": r.usageRefresh,
+			"report":  r.usageReport,
+		})
+	case "token":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"refresh": r.tokenRefresh,
+		})
+	case "settings":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"get": r.settingsGet,
+			"set": r.settingsSet,
+		})
+	case "kiro":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"status":   r.kiroStatus,
+			"kill":     r.kiroKill,
+			"path":     r.kiroPath,
+			"installs": r.kiroInstalls,
+			"version":  r.kiroVersion,
+		})
+	case "profile":
+		return r.runSubcommand(command, args, map[string]func([]string) error{
+			"list":   r.profileList,
+			"add":    r.profileAdd,
+			"remove": r.profileRemove,
+			"use":    r.profileUse,
+		})
+	case "help":
+		fmt.Fprint(r.stdout, cliUsage)
+		return nil
+	}
+	return usageErrorf("unknown command %q", command)
+}
+
+// runSubcommand 執行指令下的子指令
+func (r *cliRunner) runSubcommand(command string, args []string, handlers map[string]func([]string) error) error {
+	if len(args) == 0 {
+		return usageErrorf("missing subcommand for %q", command)
+	}
+	handler, ok := handlers[args[0]]
+	if !ok {
+		return usageErrorf("unknown subcommand %q for %q", args[0], command)
+	}
+	return handler(args[1:])
+}
+
+// unlockFromEnv 密語模式下以環境變數解鎖備份
+func (r *cliRunner) unlockFromEnv() error {
+	status, err := backup.GetEncryptionStatus()
+	if err != nil || !status.Locked {
+		return err
+	}
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		return &cliError{code: exitLocked, err: fmt.Errorf("backups are locked; set %s to unlock", PassphraseEnv)}
+	}
+	return backup.Unlock(passphrase)
+}
+
+// printResult 輸出結果；JSON 模式輸出 value，否則呼叫 text 輸出文字
+func (r *cliRunner) printResult(value interface{}, text func(w io.Writer)) error {
+	if r.json {
+		enc := json.NewEncoder(r.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+	text(r.stdout)
+	return nil
+}
+
+// printError 輸出錯誤至 stderr
+func (r *cliRunner) printError(err error) {
+	if r.json {
+		data, _ := json.Marshal(map[string]interface{}{
+			"error": err.Error(),
+			"code":  exitCodeFor(err),
+		})
+		fmt.Fprintln(r.stderr, string(data))
+		return
+	}
+	fmt.Fprintf(r.stderr, "error: %v\n", err)
+	var ce *cliError
+	if errors.As(err, &ce) && ce.code == exitUsage {
+		fmt.Fprintln(r.stderr, "Run 'kiro-manager help' for usage.")
+	}
+}
+
+// requireArgs 檢查位置參數數量
+func requireArgs(fs *flag.FlagSet, n int, usage string) error {
+	if fs.NArg() != n {
+		return usageErrorf("usage: kiro-manager %s", usage)
+	}
+	return nil
+}
+
+// ============================================================================
+// backup
+// ============================================================================
+
+// backupCreate 備份目前的 Kiro 登入
+func (r *cliRunner) backupCreate(args []string) error {
+	fs := r.newFlagSet("backup create")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup create <name>"); err != nil {
+		return err
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if err := backup.CreateBackup(name); err != nil {
+		return err
+	}
+	return r.printResult(Result{Success: true, Message: "backup created"}, func(w io.Writer) {
+		fmt.Fprintf(w, "Created backup %q\n", name)
+	})
+}
+
+// backupList 列出備份
+func (r *cliRunner) backupList(args []string) error {
+	fs := r.newFlagSet("backup list")
+	var query BackupQuery
+	fs.StringVar(&query.Search, "search", "", "match name, display name, email or tag")
+	tags := fs.String("tag", "", "comma-separated tags a backup must have")
+	fs.StringVar(&query.Provider, "provider", "", "login provider (Github, Google, BuilderId...)")
+	fs.StringVar(&query.TokenState, "token", "", "token state: valid or expired")
+	fs.BoolVar(&query.LowBalanceOnly, "low-balance", false, "only show low-balance backups")
+	fs.StringVar(&query.SortBy, "sort", SortByName, "sort by name, backupTime, tag, provider, expiry or balance")
+	fs.BoolVar(&query.Desc, "desc", false, "reverse the sort order")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 0, "backup list [--search S] [--tag a,b] [--provider P] [--token valid|expired] [--low-balance] [--sort field] [--desc]"); err != nil {
+		return err
+	}
+	if _, err := backupItemSorter(query.SortBy); err != nil {
+		return usageErrorf("unknown sort field %q", query.SortBy)
+	}
+	if query.TokenState != "" && query.TokenState != TokenStateValid && query.TokenState != TokenStateExpired {
+		return usageErrorf("unknown token state %q (valid or expired)", query.TokenState)
+	}
+	query.Tags = splitTags(*tags)
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	items, err := r.app.GetBackupList(query)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []BackupItem{}
+	}
+
+	return r.printResult(items, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPROVIDER\tSUBSCRIPTION\tBALANCE\tTOKEN\tTAGS\tBACKUP TIME\t")
+		for _, item := range items {
+			name := item.Name
+			if item.IsCurrent {
+				name += " *"
+			}
+			balance := "-"
+			if item.SubscriptionTitle != "" {
+				balance = fmt.Sprintf("%.2f / %.2f", item.Balance, item.UsageLimit)
+			}
+			tokenState := "valid"
+			if !item.HasToken {
+				tokenState = "-"
+			} else if item.IsTokenExpired {
+				tokenState = "expired"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+				name, orDash(item.Provider), orDash(item.SubscriptionTitle), balance, tokenState,
+				orDash(strings.Join(item.Tags, ",")), orDash(item.BackupTime))
+		}
+		tw.Flush()
+	})
+}
+
+// backupRestore 切換至指定備份
+func (r *cliRunner) backupRestore(args []string) error {
+	fs := r.newFlagSet("backup restore")
+	force := fs.Bool("force", false, "close Kiro before restoring")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup restore [--force] <name>"); err != nil {
+		return err
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	var launch *kiroprocess.LaunchState
+	if kiroprocess.IsKiroRunning() {
+		if !*force {
+			return &cliError{code: exitKiroRunning, err: errors.New("Kiro is running; close it or pass --force")}
+		}
+		if settings.IsRelaunchKiroEnabled() {
+			launch, _ = kiroprocess.CaptureLaunchState()
+		}
+		if err := r.closeKiro(settings.GetKiroShutdownGracePeriod()); err != nil {
+			return err
+		}
+	}
+
+	if err := backup.RestoreBackup(name); err != nil {
+		return err
+	}
+
+	// 只重新啟動由本指令關閉的 Kiro
+	relaunched := false
+	if launch != nil {
+		if err := kiroprocess.Relaunch(launch); err != nil {
+			fmt.Fprintf(r.stderr, "Failed to relaunch Kiro: %v\n", err)
+		} else {
+			relaunched = true
+		}
+	}
+	return r.printResult(Result{Success: true, Message: "backup restored"}, func(w io.Writer) {
+		if relaunched {
+			fmt.Fprintf(w, "Switched to backup %q; Kiro relaunched\n", name)
+			return
+		}
+		fmt.Fprintf(w, "Switched to backup %q; restart Kiro to apply\n", name)
+	})
+}
+
+// backupDelete 刪除備份
+func (r *cliRunner) backupDelete(args []string) error {
+	fs := r.newFlagSet("backup delete")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup delete <name>"); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if name == backup.OriginalBackupName {
+		return usageErrorf("the original backup cannot be deleted")
+	}
+	if err := backup.DeleteBackup(name); err != nil {
+		return err
+	}
+	return r.printResult(Result{Success: true, Message: "backup deleted"}, func(w io.Writer) {
+		fmt.Fprintf(w, "Deleted backup %q\n", name)
+	})
+}
+
+// backupRename 重新命名備份
+func (r *cliRunner) backupRename(args []string) error {
+	fs := r.newFlagSet("backup rename")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2, "backup rename <old> <new>"); err != nil {
+		return err
+	}
+
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+	if err := backup.RenameBackup(oldName, newName); err != nil {
+		return err
+	}
+	return r.printResult(Result{Success: true, Message: "backup renamed"}, func(w io.Writer) {
+		fmt.Fprintf(w, "Renamed backup %q to %q\n", oldName, newName)
+	})
+}
+
+// backupClone 複製備份
+func (r *cliRunner) backupClone(args []string) error {
+	fs := r.newFlagSet("backup clone")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2, "backup clone <src> <dst>"); err != nil {
+		return err
+	}
+
+	srcName, dstName := fs.Arg(0), fs.Arg(1)
+	if err := backup.CloneBackup(srcName, dstName); err != nil {
+		return err
+	}
+	return r.printResult(Result{Success: true, Message: "backup cloned"}, func(w io.Writer) {
+		fmt.Fprintf(w, "Cloned backup %q to %q\n", srcName, dstName)
+	})
+}
+
+// backupExport 將備份匯出為封存檔
+func (r *cliRunner) backupExport(args []string) error {
+	fs := r.newFlagSet("backup export")
+	all := fs.Bool("all", false, "export every backup")
+	encrypt := fs.Bool("encrypt", false, "encrypt the archive with $"+ArchivePassphraseEnv)
+	output := fs.String("o", "", "archive file to write")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *output == "" || *all == (fs.NArg() > 0) {
+		return usageErrorf("usage: kiro-manager backup export [--all] [--encrypt] -o <file> [name...]")
+	}
+
+	var opts backup.ExportOptions
+	if *encrypt {
+		if opts.Passphrase = os.Getenv(ArchivePassphraseEnv); opts.Passphrase == "" {
+			return usageErrorf("--encrypt requires %s", ArchivePassphraseEnv)
+		}
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if *all {
+		var err error
+		if names, err = exportableBackups(); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return &cliError{code: exitNotFound, err: errors.New("no backups to export")}
+		}
+	}
+
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := backup.Export(names, f, opts); err != nil {
+		f.Close()
+		os.Remove(*output)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return r.printResult(map[string]interface{}{"path": *output, "backups": names, "encrypted": *encrypt}, func(w io.Writer) {
+		fmt.Fprintf(w, "Exported %d backup(s) to %s\n", len(names), *output)
+	})
+}
+
+// backupImport 從封存檔匯入備份
+func (r *cliRunner) backupImport(args []string) error {
+	fs := r.newFlagSet("backup import")
+	onConflict := fs.String("on-conflict", backup.ConflictSkip, "skip, overwrite or rename existing backups")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup import [--on-conflict skip|overwrite|rename] <file>"); err != nil {
+		return err
+	}
+	switch *onConflict {
+	case backup.ConflictSkip, backup.ConflictOverwrite, backup.ConflictRename:
+	default:
+		return usageErrorf("--on-conflict must be one of skip, overwrite, rename")
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	results, err := backup.Import(f, backup.ImportOptions{
+		Passphrase: os.Getenv(ArchivePassphraseEnv),
+		OnConflict: *onConflict,
+	})
+	if results == nil {
+		results = []backup.ImportedBackup{}
+	}
+	if err != nil && len(results) == 0 {
+		if errors.Is(err, backup.ErrPassphraseRequired) {
+			return &cliError{code: exitLocked, err: fmt.Errorf("archive is encrypted; set %s to decrypt", ArchivePassphraseEnv)}
+		}
+		return err
+	}
+
+	// 部分匯入失敗時仍輸出已完成的結果
+	if printErr := r.printResult(results, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSOURCE\tACTION\t")
+		for _, res := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t\n", res.Name, res.SourceName, res.Action)
+		}
+		tw.Flush()
+	}); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+// backupDetail 備份詳細資訊（不包含任何 Token 內容）
+type backupDetail struct {
+	Name           string             `json:"name"`
+	Path           string             `json:"path"`
+	BackupTime     string             `json:"backupTime,omitempty"`
+	MachineID      string             `json:"machineId,omitempty"`
+	Provider       string             `json:"provider,omitempty"`
+	AuthMethod     string             `json:"authMethod,omitempty"`
+	ExpiresAt      string             `json:"expiresAt,omitempty"`
+	IsTokenExpired bool               `json:"isTokenExpired"`
+	Usage          *backup.UsageCache `json:"usage,omitempty"`
+	Meta           backup.BackupMeta  `json:"meta"`
+}
+
+// backupShow 顯示備份詳細資訊
+func (r *cliRunner) backupShow(args []string) error {
+	fs := r.newFlagSet("backup show")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup show <name>"); err != nil {
+		return err
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	info, err := backup.GetBackupInfo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	detail := backupDetail{Name: info.Name, Path: info.Path, Meta: info.Meta}
+	if !info.BackupTime.IsZero() {
+		detail.BackupTime = info.BackupTime.Format("2006-01-02 15:04:05")
+	}
+	if mid, err := backup.ReadBackupMachineID(info.Name); err == nil {
+		detail.MachineID = mid.MachineID
+	}
+	if info.HasToken {
+		token, err := backup.ReadBackupToken(info.Name)
+		if err != nil {
+			return err
+		}
+		detail.Provider = token.Provider
+		detail.AuthMethod = token.AuthMethod
+		detail.ExpiresAt = token.ExpiresAtString()
+		detail.IsTokenExpired = awssso.IsTokenExpired(token)
+	}
+	if cache, err := backup.ReadUsageCache(info.Name); err == nil {
+		detail.Usage = cache
+	}
+
+	return r.printResult(detail, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Name:\t%s\n", detail.Name)
+		fmt.Fprintf(tw, "Path:\t%s\n", detail.Path)
+		fmt.Fprintf(tw, "Display name:\t%s\n", orDash(detail.Meta.DisplayName))
+		fmt.Fprintf(tw, "Email:\t%s\n", orDash(detail.Meta.Email))
+		fmt.Fprintf(tw, "Tags:\t%s\n", orDash(strings.Join(detail.Meta.Tags, ", ")))
+		fmt.Fprintf(tw, "Backup time:\t%s\n", orDash(detail.BackupTime))
+		fmt.Fprintf(tw, "Machine ID:\t%s\n", orDash(detail.MachineID))
+		fmt.Fprintf(tw, "Provider:\t%s\n", orDash(detail.Provider))
+		fmt.Fprintf(tw, "Auth method:\t%s\n", orDash(detail.AuthMethod))
+		fmt.Fprintf(tw, "Token expires:\t%s (expired: %v)\n", orDash(detail.ExpiresAt), detail.IsTokenExpired)
+		if detail.Usage != nil {
+			fmt.Fprintf(tw, "Subscription:\t%s\n", detail.Usage.SubscriptionTitle)
+			fmt.Fprintf(tw, "Usage:\t%.2f / %.2f (balance %.2f)\n", detail.Usage.CurrentUsage, detail.Usage.UsageLimit, detail.Usage.Balance)
+			for _, item := range detail.Usage.Breakdown {
+				fmt.Fprintf(tw, "  %s:\t%.2f / %.2f (balance %.2f)\n", breakdownLabel(item), item.CurrentUsage, item.UsageLimit, item.Balance)
+			}
+			fmt.Fprintf(tw, "Cached at:\t%s\n", detail.Usage.CachedAt.Format("2006-01-02 15:04:05"))
+		}
+		if detail.Meta.Notes != "" {
+			fmt.Fprintf(tw, "Notes:\t%s\n", detail.Meta.Notes)
+		}
+		tw.Flush()
+	})
+}
+
+// backupMeta 設定備份的顯示名稱、Email、標籤與備註（只更新有指定的欄位）
+func (r *cliRunner) backupMeta(args []string) error {
+	fs := r.newFlagSet("backup meta")
+	displayName := fs.String("display-name", "", "display name")
+	email := fs.String("email", "", "account email or login hint")
+	tags := fs.String("tags", "", "comma-separated tags (empty to clear)")
+	notes := fs.String("notes", "", "free-form notes")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "backup meta [--display-name S] [--email S] [--tags a,b] [--notes S] <name>"); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if name == backup.OriginalBackupName {
+		return usageErrorf("the original backup has no metadata")
+	}
+	meta, err := backup.ReadBackupMeta(name)
+	if err != nil {
+		return err
+	}
+
+	visited := 0
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "display-name":
+			meta.DisplayName = *displayName
+		case "email":
+			meta.Email = *email
+		case "tags":
+			meta.Tags = splitTags(*tags)
+		case "notes":
+			meta.Notes = *notes
+		default:
+			return
+		}
+		visited++
+	})
+
+	if visited > 0 {
+		if err := backup.WriteBackupMeta(name, meta); err != nil {
+			if errors.Is(err, backup.ErrInvalidMeta) {
+				return usageErrorf("%v", err)
+			}
+			return err
+		}
+		if meta, err = backup.ReadBackupMeta(name); err != nil {
+			return err
+		}
+	}
+
+	return r.printResult(meta, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Display name:\t%s\n", orDash(meta.DisplayName))
+		fmt.Fprintf(tw, "Email:\t%s\n", orDash(meta.Email))
+		fmt.Fprintf(tw, "Tags:\t%s\n", orDash(strings.Join(meta.Tags, ", ")))
+		fmt.Fprintf(tw, "Notes:\t%s\n", orDash(meta.Notes))
+		tw.Flush()
+	})
+}
+
+// splitTags 解析逗號分隔的標籤
+func splitTags(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return backup.NormalizeTags(strings.Split(value, ","))
+}
+
+// ============================================================================
+// usage / token
+// ============================================================================
+
+// usageRefreshResult 單一備份的餘額刷新結果
+type usageRefreshResult struct {
+	Name string `json:"name"`
+	UsageCacheResult
+}
+
+// usageRefresh 刷新備份的餘額緩存
+func (r *cliRunner) usageRefresh(args []string) error {
+	fs := r.newFlagSet("usage refresh")
+	all := fs.Bool("all", false, "refresh every backup")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *all == (fs.NArg() > 0) {
+		return usageErrorf("usage: kiro-manager usage refresh [--all] [name...]")
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if *all {
+		var err error
+		if names, err = refreshableBackups(); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if !backup.BackupExists(name) {
+			return &cliError{code: exitNotFound, err: fmt.Errorf("%s: %w", name, backup.ErrBackupNotFound)}
+		}
+	}
+
+	// Ctrl+C 取消尚未完成的刷新
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report := refreshAll(ctx, names, maxRefreshWorkers, refreshBackupUsage, nil)
+	results := make([]usageRefreshResult, 0, len(report.Results))
+	for _, res := range report.Results {
+		results = append(results, usageRefreshResult{Name: res.Name, UsageCacheResult: res.Result})
+	}
+	failed := report.Total - len(report.Succeeded)
+
+	if err := r.printResult(results, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tRESULT\tSUBSCRIPTION\tBALANCE\t")
+		for _, res := range results {
+			if !res.Success {
+				fmt.Fprintf(tw, "%s\tfailed: %s\t-\t-\t\n", res.Name, res.Message)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\tok\t%s\t%.2f / %.2f\t\n", res.Name, res.SubscriptionTitle, res.Balance, res.UsageLimit)
+		}
+		tw.Flush()
+	}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return &cliError{code: exitRemote, err: fmt.Errorf("%d of %d backups failed to refresh", failed, len(results))}
+	}
+	return nil
+}
+
+// usageReport 輸出備份的用量趨勢報表
+func (r *cliRunner) usageReport(args []string) error {
+	fs := r.newFlagSet("usage report")
+	days := fs.Int("days", report.DefaultDays, "number of days to include")
+	asCSV := fs.Bool("csv", false, "print daily usage as CSV")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return usageErrorf("--days must be a positive integer")
+	}
+	if *asCSV && r.json {
+		return usageErrorf("--csv and --json cannot be used together")
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		var err error
+		if names, err = allBackupNames(); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if !backup.BackupExists(name) {
+			return &cliError{code: exitNotFound, err: fmt.Errorf("%s: %w", name, backup.ErrBackupNotFound)}
+		}
+	}
+
+	trends, err := usageTrends(names, *days, time.Now())
+	if err != nil {
+		return err
+	}
+	if *asCSV {
+		return report.WriteCSV(r.stdout, trends)
+	}
+
+	return r.printResult(trends, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "NAME\tSUBSCRIPTION\tBALANCE\tCONSUMED (%dd)\tBURN/DAY\tZERO DATE\t\n", *days)
+		for _, t := range trends {
+			if t.Samples == 0 {
+				fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\t\n", t.Name)
+				continue
+			}
+			burn := "-"
+			if t.BurnRatePerDay > 0 {
+				burn = fmt.Sprintf("%.2f", t.BurnRatePerDay)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%.2f / %.2f\t%.2f\t%s\t%s\t\n",
+				t.Name, orDash(t.SubscriptionTitle), t.Balance, t.UsageLimit, t.Consumed, burn, orDash(t.ProjectedZeroDate))
+		}
+		tw.Flush()
+	})
+}
+
+// tokenRefresh 強制刷新備份中的 Access Token
+func (r *cliRunner) tokenRefresh(args []string) error {
+	fs := r.newFlagSet("token refresh")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "token refresh <name>"); err != nil {
+		return err
+	}
+	if err := r.unlockFromEnv(); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	mid, err := backup.ReadBackupMachineID(name)
+	if err != nil {
+		return err
+	}
+	token, err := backup.ReadBackupToken(name)
+	if err != nil {
+		return err
+	}
+
+	if err := refreshBackupToken(context.Background(), name, token, machineid.HashMachineID(mid.MachineID)); err != nil {
+		return &cliError{code: exitRemote, err: err}
+	}
+
+	result := map[string]string{"name": name, "expiresAt": token.ExpiresAtString()}
+	return r.printResult(result, func(w io.Writer) {
+		fmt.Fprintf(w, "Refreshed token of %q, expires at %s\n", name, token.ExpiresAtString())
+	})
+}
+
+// ============================================================================
+// settings
+// ============================================================================
+
+// settingsGet 顯示全部或單一設定
+func (r *cliRunner) settingsGet(args []string) error {
+	fs := r.newFlagSet("settings get")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageErrorf("usage: kiro-manager settings get [key]")
+	}
+
+	current := settings.GetCurrentSettings()
+	values := settingsToMap(current)
+	if fs.NArg() == 0 {
+		return r.printResult(redactedSettings(current), func(w io.Writer) {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			for _, key := range settingsKeys {
+				fmt.Fprintf(tw, "%s\t%v\n", key, values[key])
+			}
+			tw.Flush()
+		})
+	}
+
+	key := fs.Arg(0)
+	value, ok := values[key]
+	if !ok {
+		return usageErrorf("unknown setting %q (available: %s)", key, strings.Join(settingsKeys, ", "))
+	}
+	return r.printResult(map[string]interface{}{key: value}, func(w io.Writer) {
+		fmt.Fprintln(w, value)
+	})
+}
+
+// settingsSet 修改單一設定
+func (r *cliRunner) settingsSet(args []string) error {
+	fs := r.newFlagSet("settings set")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2, "settings set <key> <value>"); err != nil {
+		return err
+	}
+
+	current := *settings.GetCurrentSettings()
+	key, raw := fs.Arg(0), fs.Arg(1)
+	if err := setSettingValue(&current, key, raw); err != nil {
+		return err
+	}
+
+	if err := settings.SaveSettings(&current); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	saved := settings.GetCurrentSettings()
+	return r.printResult(redactedSettings(saved), func(w io.Writer) {
+		fmt.Fprintf(w, "%s = %v\n", key, settingsToMap(saved)[key])
+	})
+}
+
+// settingsKeys CLI 可存取的設定鍵（與 settings.json 欄位名稱相同）
+var settingsKeys = []string{
+	"lowBalanceThreshold", "kiroVersion", "useAutoDetect", "customKiroInstallPath", "logLevel",
+	"proxyUrl", "noProxy", "caBundlePath", "connectTimeoutSeconds", "requestTimeoutSeconds",
+	"retryMaxAttempts", "retryBaseDelayMillis", "retryMaxDelaySeconds", "trashSsoCache",
+	"kiroShutdownGraceSeconds", "relaunchKiro",
+}
+
+// redactedSettings 回傳隱藏代理密碼的設定副本，供 --json 輸出使用
+func redactedSettings(s *settings.Settings) *settings.Settings {
+	redacted := *s
+	redacted.ProxyURL = httpclient.RedactProxyURL(s.ProxyURL)
+	return &redacted
+}
+
+// settingsToMap 將設定轉為鍵值表
+func settingsToMap(s *settings.Settings) map[string]interface{} {
+	return map[string]interface{}{
+		"lowBalanceThreshold":      s.LowBalanceThreshold,
+		"kiroVersion":              s.KiroVersion,
+		"useAutoDetect":            s.UseAutoDetect,
+		"customKiroInstallPath":    s.CustomKiroInstallPath,
+		"logLevel":                 s.LogLevel,
+		"proxyUrl":                 httpclient.RedactProxyURL(s.ProxyURL),
+		"noProxy":                  s.NoProxy,
+		"caBundlePath":             s.CABundlePath,
+		"connectTimeoutSeconds":    s.ConnectTimeoutSeconds,
+		"requestTimeoutSeconds":    s.RequestTimeoutSeconds,
+		"retryMaxAttempts":         s.RetryMaxAttempts,
+		"retryBaseDelayMillis":     s.RetryBaseDelayMillis,
+		"retryMaxDelaySeconds":     s.RetryMaxDelaySeconds,
+		"trashSsoCache":            s.TrashSSOCache,
+		"kiroShutdownGraceSeconds": s.KiroShutdownGraceSeconds,
+		"relaunchKiro":             s.RelaunchKiro,
+	}
+}
+
+// setSettingValue 解析字串值並寫入對應設定
+func setSettingValue(s *settings.Settings, key, raw string) error {
+	switch key {
+	case "lowBalanceThreshold":
+		v, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+		if err != nil {
+			return usageErrorf("invalid number %q", raw)
+		}
+		// 接受 0.2 或 20%
+		if strings.HasSuffix(raw, "%") {
+			v /= 100
+		}
+		if v < 0 || v > 1 {
+			return usageErrorf("lowBalanceThreshold must be between 0 and 1")
+		}
+		s.LowBalanceThreshold = v
+	case "kiroVersion":
+		s.KiroVersion = raw
+	case "useAutoDetect":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return usageErrorf("invalid boolean %q", raw)
+		}
+		s.UseAutoDetect = v
+	case "trashSsoCache":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return usageErrorf("invalid boolean %q", raw)
+		}
+		s.TrashSSOCache = v
+	case "relaunchKiro":
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return usageErrorf("invalid boolean %q", raw)
+		}
+		s.RelaunchKiro = v
+	case "customKiroInstallPath":
+		s.CustomKiroInstallPath = raw
+	case "logLevel":
+		switch raw {
+		case "debug", "info", "warn", "error":
+			s.LogLevel = raw
+		default:
+			return usageErrorf("logLevel must be one of debug, info, warn, error")
+		}
+	case "proxyUrl":
+		if _, err := httpclient.NewTransport(httpclient.Config{ProxyURL: raw}); err != nil {
+			return &cliError{code: exitUsage, err: err}
+		}
+		s.ProxyURL = raw
+	case "noProxy":
+		s.NoProxy = raw
+	case "caBundlePath":
+		if raw != "" {
+			if _, err := httpclient.NewTransport(httpclient.Config{CABundlePath: raw}); err != nil {
+				return &cliError{code: exitUsage, err: err}
+			}
+		}
+		s.CABundlePath = raw
+	case "connectTimeoutSeconds", "requestTimeoutSeconds", "retryMaxDelaySeconds", "kiroShutdownGraceSeconds":
+		v, err := parseBoundedInt(key, raw, settings.MaxTimeoutSeconds)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "connectTimeoutSeconds":
+			s.ConnectTimeoutSeconds = v
+		case "requestTimeoutSeconds":
+			s.RequestTimeoutSeconds = v
+		case "kiroShutdownGraceSeconds":
+			s.KiroShutdownGraceSeconds = v
+		default:
+			s.RetryMaxDelaySeconds = v
+		}
+	case "retryMaxAttempts":
+		v, err := parseBoundedInt(key, raw, settings.MaxRetryAttempts)
+		if err != nil {
+			return err
+		}
+		s.RetryMaxAttempts = v
+	case "retryBaseDelayMillis":
+		v, err := parseBoundedInt(key, raw, settings.MaxRetryBaseDelayMillis)
+		if err != nil {
+			return err
+		}
+		s.RetryBaseDelayMillis = v
+	default:
+		return usageErrorf("unknown setting %q (available: %s)", key, strings.Join(settingsKeys, ", "))
+	}
+	return nil
+}
+
+// parseBoundedInt 解析 0 ~ upper 之間的整數設定值
+func parseBoundedInt(key, raw string, upper int) (int, error) {
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 || v > upper {
+		return 0, usageErrorf("%s must be an integer between 0 and %d", key, upper)
+	}
+	return v, nil
+}
+
+// ============================================================================
+// kiro
+// ============================================================================
+
+// kiroStatusInfo Kiro 運行狀態
+type kiroStatusInfo struct {
+	Running   bool                       `json:"running"`
+	Processes []kiroprocess.ProcessInfo  `json:"processes"`
+	Tree      []*kiroprocess.ProcessNode `json:"tree"`
+}
+
+// kiroStatus 顯示 Kiro 運行狀態
+func (r *cliRunner) kiroStatus(args []string) error {
+	fs := r.newFlagSet("kiro status")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	processes, err := kiroprocess.GetKiroProcesses()
+	if err != nil {
+		return err
+	}
+	if processes == nil {
+		processes = []kiroprocess.ProcessInfo{}
+	}
+	status := kiroStatusInfo{
+		Running:   len(processes) > 0,
+		Processes: processes,
+		Tree:      kiroprocess.BuildProcessTree(processes),
+	}
+
+	return r.printResult(status, func(w io.Writer) {
+		if !status.Running {
+			fmt.Fprintln(w, "Kiro is not running")
+			return
+		}
+		fmt.Fprintf(w, "Kiro is running (%d processes)\n", len(processes))
+		printProcessTree(w, status.Tree, 1)
+	})
+}
+
+// printProcessTree 以縮排顯示 Kiro 進程樹
+func printProcessTree(w io.Writer, nodes []*kiroprocess.ProcessNode, depth int) {
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s%d\t%-13s\t%s\n", strings.Repeat("  ", depth), node.PID, node.Role, node.Name)
+		printProcessTree(w, node.Children, depth+1)
+	}
+}
+
+// kiroKill 關閉所有 Kiro 進程：先要求正常結束，寬限期後強制終止
+func (r *cliRunner) kiroKill(args []string) error {
+	fs := r.newFlagSet("kiro kill")
+	grace := fs.Int("grace", int(settings.GetKiroShutdownGracePeriod()/time.Second), "seconds to wait for Kiro to exit before force closing (0 = default)")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	processes, err := kiroprocess.GetKiroProcesses()
+	if err != nil {
+		return err
+	}
+	if err := r.closeKiro(time.Duration(*grace) * time.Second); err != nil {
+		return err
+	}
+	closed := len(processes)
+	return r.printResult(map[string]int{"killed": closed}, func(w io.Writer) {
+		fmt.Fprintf(w, "Closed %d Kiro processes\n", closed)
+	})
+}
+
+// closeKiro 分段關閉 Kiro，寬限期後強制終止（CLI 以 --force 或 kiro kill 表示使用者已同意），進度輸出到 stderr
+func (r *cliRunner) closeKiro(grace time.Duration) error {
+	var last kiroprocess.ShutdownStage
+	err := kiroprocess.Shutdown(context.Background(), kiroprocess.ShutdownOptions{
+		GracePeriod: grace,
+		Force:       true,
+		OnProgress: func(p kiroprocess.ShutdownProgress) {
+			if p.Stage == last {
+				return
+			}
+			last = p.Stage
+			switch p.Stage {
+			case kiroprocess.ShutdownClosing:
+				fmt.Fprintf(r.stderr, "Asking Kiro to close (%d processes)\n", p.Remaining)
+			case kiroprocess.ShutdownWaiting:
+				fmt.Fprintf(r.stderr, "Waiting up to %ds for Kiro to exit\n", p.SecondsLeft)
+			case kiroprocess.ShutdownKilling:
+				fmt.Fprintf(r.stderr, "Kiro did not exit in time; force closing %d processes\n", p.Remaining)
+			}
+		},
+	})
+	if errors.Is(err, kiroprocess.ErrStillRunning) {
+		return &cliError{code: exitKiroRunning, err: errors.New("failed to close Kiro")}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close Kiro: %w", err)
+	}
+	return nil
+}
+
+// kiroPaths Kiro 相關路徑
+type kiroPaths struct {
+	Home         string `json:"home"`
+	Config       string `json:"config"`
+	Install      string `json:"install,omitempty"`
+	SSOCache     string `json:"ssoCache"`
+	BackupRoot   string `json:"backupRoot"`
+	SettingsFile string `json:"settingsFile"`
+	DataDir      string `json:"dataDir"`
+	DataSource   string `json:"dataSource"` // flag / env / portable / user / execDir
+}
+
+// kiroPath 顯示 Kiro 相關路徑
+func (r *cliRunner) kiroPath(args []string) error {
+	fs := r.newFlagSet("kiro path")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var paths kiroPaths
+	paths.Home, _ = kiropath.GetKiroHomePath()
+	paths.Config, _ = kiropath.GetKiroConfigPath()
+	paths.Install, _ = kiropath.GetKiroInstallPath()
+	paths.SSOCache, _ = awssso.GetSSOCachePath()
+	paths.BackupRoot, _ = backup.GetBackupRootPath()
+	paths.SettingsFile, _ = settings.GetSettingsPath()
+	if loc, err := datadir.Resolve(); err == nil {
+		paths.DataDir, paths.DataSource = loc.Dir, loc.Source
+	}
+
+	return r.printResult(paths, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Kiro home:\t%s\n", orDash(paths.Home))
+		fmt.Fprintf(tw, "Kiro config:\t%s\n", orDash(paths.Config))
+		fmt.Fprintf(tw, "Kiro install:\t%s\n", orDash(paths.Install))
+		fmt.Fprintf(tw, "SSO cache:\t%s\n", orDash(paths.SSOCache))
+		fmt.Fprintf(tw, "Backups:\t%s\n", orDash(paths.BackupRoot))
+		fmt.Fprintf(tw, "Settings:\t%s\n", orDash(paths.SettingsFile))
+		fmt.Fprintf(tw, "Data directory:\t%s (%s)\n", orDash(paths.DataDir), orDash(paths.DataSource))
+		tw.Flush()
+	})
+}
+
+// kiroInstalls 列出所有偵測到的 Kiro 安裝
+func (r *cliRunner) kiroInstalls(args []string) error {
+	fs := r.newFlagSet("kiro installs")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	installations := kiropath.DiscoverInstallations()
+	return r.printResult(installations, func(w io.Writer) {
+		if len(installations) == 0 {
+			fmt.Fprintln(w, "No Kiro installation found")
+			return
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tPATH\tSOURCE\tVERSION\tWRITABLE")
+		for _, inst := range installations {
+			marker := ""
+			if inst.Current {
+				marker = "*"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\n", marker, inst.Path, inst.Source, orDash(inst.Version), inst.Writable)
+		}
+		tw.Flush()
+	})
+}
+
+// kiroVersion 顯示已安裝的 Kiro 版本
+func (r *cliRunner) kiroVersion(args []string) error {
+	fs := r.newFlagSet("kiro version")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	version, err := kiroversion.GetKiroVersion()
+	if err != nil {
+		return err
+	}
+	return r.printResult(map[string]string{"version": version}, func(w io.Writer) {
+		fmt.Fprintln(w, version)
+	})
+}
+
+// ============================================================================
+// profile
+// ============================================================================
+
+// profileList 列出預設安裝與所有安裝設定檔
+func (r *cliRunner) profileList(args []string) error {
+	fs := r.newFlagSet("profile list")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	profiles := r.app.GetKiroProfiles()
+	return r.printResult(profiles, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "\tNAME\tPATH\tVERSION\tRUNNING")
+		for _, p := range profiles {
+			marker := ""
+			if p.Active {
+				marker = "*"
+			}
+			name := p.Name
+			if name == "" {
+				name = "(default)"
+			}
+			running := "no"
+			switch {
+			case !p.Exists:
+				running = "missing"
+			case p.Running:
+				running = fmt.Sprintf("yes (%d)", p.ProcessCount)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", marker, name, orDash(p.InstallPath), orDash(p.DetectedVersion), running)
+		}
+		tw.Flush()
+	})
+}
+
+// profileAdd 新增安裝設定檔，名稱已存在時更新該設定檔
+func (r *cliRunner) profileAdd(args []string) error {
+	fs := r.newFlagSet("profile add")
+	version := fs.String("version", "", "Kiro version sent to the API when auto-detect is off")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 2, "profile add [--version V] <name> <path>"); err != nil {
+		return err
+	}
+
+	profile := settings.KiroProfile{Name: fs.Arg(0), InstallPath: fs.Arg(1), KiroVersion: *version}
+	if abs, err := filepath.Abs(profile.InstallPath); err == nil {
+		profile.InstallPath = abs
+	}
+	originalName := ""
+	if existing := settings.GetCurrentSettings().FindKiroProfile(profile.Name); existing != nil {
+		originalName = existing.Name
+	}
+	if err := settings.SaveKiroProfile(originalName, profile); err != nil {
+		return err
+	}
+	return r.printResult(profile, func(w io.Writer) {
+		fmt.Fprintf(w, "Saved profile %q (%s)\n", profile.Name, profile.InstallPath)
+	})
+}
+
+// profileRemove 刪除安裝設定檔
+func (r *cliRunner) profileRemove(args []string) error {
+	fs := r.newFlagSet("profile remove")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireArgs(fs, 1, "profile remove <name>"); err != nil {
+		return err
+	}
+
+	name := fs.Arg(0)
+	if err := settings.DeleteKiroProfile(name); err != nil {
+		return err
+	}
+	return r.printResult(Result{Success: true, Message: "profile removed"}, func(w io.Writer) {
+		fmt.Fprintf(w, "Removed profile %q\n", name)
+	})
+}
+
+// profileUse 選擇之後所有指令使用的安裝
+func (r *cliRunner) profileUse(args []string) error {
+	fs := r.newFlagSet("profile use")
+	useDefault := fs.Bool("default", false, "use the default installation (customKiroInstallPath or auto-detect)")
+	if err := r.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *useDefault == (fs.NArg() == 1) || fs.NArg() > 1 {
+		return usageErrorf("usage: kiro-manager profile use <name> | --default")
+	}
+
+	if err := settings.SetActiveKiroProfile(fs.Arg(0)); err != nil {
+		return err
+	}
+	active := settings.GetActiveKiroProfile()
+	return r.printResult(active, func(w io.Writer) {
+		if active == nil {
+			fmt.Fprintln(w, "Using the default Kiro installation")
+			return
+		}
+		fmt.Fprintf(w, "Using profile %q (%s)\n", active.Name, active.InstallPath)
+	})
+}
+
+// breakdownLabel 額度明細的顯示名稱，例如 "bonus WELCOME [ACTIVE]"
+func breakdownLabel(item usage.BreakdownItem) string {
+	label := item.Category
+	if item.BonusCode != "" {
+		label += " " + item.BonusCode
+	}
+	if item.Status != "" {
+		label += " [" + item.Status + "]"
+	}
+	return label
+}
+
+// orDash 空字串顯示為 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
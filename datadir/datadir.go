@@ -0,0 +1,309 @@
+// Package datadir 決定 Kiro Manager 存放設定、備份與日誌的資料目錄
+//
+// 優先順序：
+//  1. --data-dir 參數
+//  2. KIRO_MANAGER_HOME 環境變數
+//  3. 執行檔同層有 PortableMarkerName 標記檔時，使用執行檔所在目錄（可攜模式）
+//  4. 系統的使用者資料目錄（Linux: $XDG_DATA_HOME 或 ~/.local/share、
+//     Windows: %APPDATA%、macOS: ~/Library/Application Support）下的 AppDirName
+package datadir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvHome 指定資料目錄的環境變數
+	EnvHome = "KIRO_MANAGER_HOME"
+	// FlagName 指定資料目錄的命令列參數名稱（--data-dir）
+	FlagName = "data-dir"
+	// PortableMarkerName 可攜模式的標記檔，放在執行檔同層
+	PortableMarkerName = "kiro-manager.portable"
+	// AppDirName 使用者資料目錄下的資料夾名稱
+	AppDirName = "kiro-manager"
+)
+
+// 資料目錄的來源
+const (
+	SourceFlag     = "flag"     // --data-dir 參數
+	SourceEnv      = "env"      // KIRO_MANAGER_HOME 環境變數
+	SourcePortable = "portable" // 執行檔同層的標記檔
+	SourceUser     = "user"     // 系統的使用者資料目錄
+	SourceExecDir  = "execDir"  // 無法取得使用者資料目錄時退回執行檔所在目錄
+)
+
+// legacyItems 舊版存放在執行檔同層、需要搬移的項目
+var legacyItems = []string{"settings.json", "backups"}
+
+var (
+	overrideMu sync.RWMutex
+	override   string
+)
+
+// Location 解析後的資料目錄
+type Location struct {
+	Dir    string `json:"dir"`
+	Source string `json:"source"` // flag / env / portable / user / execDir
+}
+
+// env 解析資料目錄時需要的外部狀態（測試時可替換）
+type env struct {
+	goos     string
+	getenv   func(string) string
+	execDir  func() (string, error)
+	homeDir  func() (string, error)
+	override string
+}
+
+// systemEnv 目前程式的外部狀態
+func systemEnv() env {
+	overrideMu.RLock()
+	defer overrideMu.RUnlock()
+	return env{
+		goos:   runtime.GOOS,
+		getenv: os.Getenv,
+		execDir: func() (string, error) {
+			execPath, err := os.Executable()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Dir(execPath), nil
+		},
+		homeDir:  os.UserHomeDir,
+		override: override,
+	}
+}
+
+// SetOverride 設定 --data-dir 參數指定的資料目錄（空字串表示不指定）
+func SetOverride(dir string) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	override = dir
+}
+
+// Resolve 依優先順序決定資料目錄（不建立目錄）
+func Resolve() (Location, error) {
+	return resolve(systemEnv())
+}
+
+// Dir 返回資料目錄
+func Dir() (string, error) {
+	loc, err := Resolve()
+	if err != nil {
+		return "", err
+	}
+	return loc.Dir, nil
+}
+
+// Init 建立資料目錄，並將舊版存放在執行檔同層的資料搬移過來
+// 返回搬移的項目名稱；搬移失敗不影響資料目錄的使用，只回傳錯誤供呼叫端記錄
+func Init(overrideDir string) (Location, []string, error) {
+	SetOverride(overrideDir)
+
+	e := systemEnv()
+	loc, err := resolve(e)
+	if err != nil {
+		return loc, nil, err
+	}
+	if err := os.MkdirAll(loc.Dir, 0700); err != nil {
+		return loc, nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	if loc.Source == SourcePortable || loc.Source == SourceExecDir {
+		return loc, nil, nil
+	}
+	execDir, err := e.execDir()
+	if err != nil {
+		return loc, nil, nil
+	}
+	migrated, err := migrate(execDir, loc.Dir)
+	return loc, migrated, err
+}
+
+// ArgValue 從命令列參數中取出 --data-dir 的值（支援 --data-dir=x、--data-dir x 與單一 -）
+// 只檢查第一個非參數引數（子指令）之前的部分；GUI 版本會收到系統附加的參數，因此不使用 flag 套件解析
+func ArgValue(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			break
+		}
+		if value, ok := strings.CutPrefix(name, FlagName+"="); ok {
+			return value
+		}
+		if name == FlagName && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// resolve 依優先順序決定資料目錄
+func resolve(e env) (Location, error) {
+	if e.override != "" {
+		dir, err := filepath.Abs(e.override)
+		return Location{Dir: dir, Source: SourceFlag}, err
+	}
+	if home := e.getenv(EnvHome); home != "" {
+		dir, err := filepath.Abs(home)
+		return Location{Dir: dir, Source: SourceEnv}, err
+	}
+
+	execDir, execErr := e.execDir()
+	if execErr == nil {
+		if _, err := os.Stat(filepath.Join(execDir, PortableMarkerName)); err == nil {
+			return Location{Dir: execDir, Source: SourcePortable}, nil
+		}
+	}
+
+	if base := userDataDir(e); base != "" {
+		return Location{Dir: filepath.Join(base, AppDirName), Source: SourceUser}, nil
+	}
+	if execErr != nil {
+		return Location{}, fmt.Errorf("failed to determine data directory: %w", execErr)
+	}
+	return Location{Dir: execDir, Source: SourceExecDir}, nil
+}
+
+// userDataDir 系統的使用者資料目錄，無法取得時返回空字串
+func userDataDir(e env) string {
+	switch e.goos {
+	case "windows":
+		if appData := e.getenv("APPDATA"); appData != "" {
+			return appData
+		}
+	case "darwin":
+		if home, err := e.homeDir(); err == nil && home != "" {
+			return filepath.Join(home, "Library", "Application Support")
+		}
+	default:
+		if xdg := e.getenv("XDG_DATA_HOME"); filepath.IsAbs(xdg) {
+			return xdg
+		}
+		if home, err := e.homeDir(); err == nil && home != "" {
+			return filepath.Join(home, ".local", "share")
+		}
+	}
+	return ""
+}
+
+// migrate 將 fromDir 下的舊版資料搬移至 toDir
+// toDir 已有任一項目時不搬移，避免覆蓋新位置的資料
+func migrate(fromDir, toDir string) ([]string, error) {
+	if sameDir(fromDir, toDir) {
+		return nil, nil
+	}
+	for _, item := range legacyItems {
+		if _, err := os.Stat(filepath.Join(toDir, item)); err == nil {
+			return nil, nil
+		}
+	}
+
+	var migrated []string
+	var errs []error
+	for _, item := range legacyItems {
+		src := filepath.Join(fromDir, item)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		if err := move(src, filepath.Join(toDir, item)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item, err))
+			continue
+		}
+		migrated = append(migrated, item)
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// move 搬移檔案或目錄；無法直接改名（例如跨磁碟）時先複製，再盡量刪除來源
+// 來源在唯讀位置時會保留原檔，只複製到新位置
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	tmp := dst + ".migrating"
+	os.RemoveAll(tmp)
+	if err := copyTree(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	os.RemoveAll(src)
+	return nil
+}
+
+// copyTree 遞迴複製檔案或目錄（只處理一般檔案與目錄，權限維持 0600 / 0700）
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(src, dst)
+	}
+
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile 複製單一檔案並 fsync
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sameDir 兩個路徑是否指向同一個目錄
+func sameDir(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,153 @@
+package datadir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testEnv 建立測試用的外部狀態
+func testEnv(goos, execDir, home string, vars map[string]string) env {
+	return env{
+		goos:    goos,
+		getenv:  func(key string) string { return vars[key] },
+		execDir: func() (string, error) { return execDir, nil },
+		homeDir: func() (string, error) { return home, nil },
+	}
+}
+
+// TestResolve_Priority 測試 --data-dir > KIRO_MANAGER_HOME > 可攜模式 > 使用者資料目錄
+func TestResolve_Priority(t *testing.T) {
+	execDir, home, custom := t.TempDir(), t.TempDir(), t.TempDir()
+	e := testEnv("linux", execDir, home, map[string]string{EnvHome: custom})
+
+	e.override = filepath.Join(custom, "flag")
+	if loc, err := resolve(e); err != nil || loc.Source != SourceFlag || loc.Dir != e.override {
+		t.Errorf("flag: got %+v (err %v)", loc, err)
+	}
+
+	e.override = ""
+	if loc, err := resolve(e); err != nil || loc.Source != SourceEnv || loc.Dir != custom {
+		t.Errorf("env: got %+v (err %v)", loc, err)
+	}
+
+	e = testEnv("linux", execDir, home, nil)
+	if loc, err := resolve(e); err != nil || loc.Source != SourceUser || loc.Dir != filepath.Join(home, ".local", "share", AppDirName) {
+		t.Errorf("user: got %+v (err %v)", loc, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(execDir, PortableMarkerName), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if loc, err := resolve(e); err != nil || loc.Source != SourcePortable || loc.Dir != execDir {
+		t.Errorf("portable: got %+v (err %v)", loc, err)
+	}
+}
+
+// TestUserDataDir 測試各平台的使用者資料目錄
+func TestUserDataDir(t *testing.T) {
+	home := filepath.FromSlash("/home/dev")
+	tests := []struct {
+		goos string
+		vars map[string]string
+		want string
+	}{
+		{"linux", nil, filepath.Join(home, ".local", "share")},
+		{"linux", map[string]string{"XDG_DATA_HOME": filepath.FromSlash("/data")}, filepath.FromSlash("/data")},
+		{"linux", map[string]string{"XDG_DATA_HOME": "relative"}, filepath.Join(home, ".local", "share")},
+		{"darwin", nil, filepath.Join(home, "Library", "Application Support")},
+		{"windows", map[string]string{"APPDATA": `C:\Users\dev\AppData\Roaming`}, `C:\Users\dev\AppData\Roaming`},
+		{"windows", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := userDataDir(testEnv(tt.goos, "", home, tt.vars)); got != tt.want {
+			t.Errorf("userDataDir(%s, %v) = %q, want %q", tt.goos, tt.vars, got, tt.want)
+		}
+	}
+
+	// 無法取得家目錄時退回執行檔所在目錄
+	e := testEnv("darwin", "/opt/kiro-manager", "", nil)
+	e.homeDir = func() (string, error) { return "", errors.New("no home") }
+	if loc, err := resolve(e); err != nil || loc.Source != SourceExecDir {
+		t.Errorf("expected execDir fallback, got %+v (err %v)", loc, err)
+	}
+}
+
+// TestMigrate 測試舊版資料搬移，新位置已有資料時不覆蓋
+func TestMigrate(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(from, "settings.json"), []byte(`{"logLevel":"debug"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(from, "backups", "work"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(from, "backups", "work", "machine-id.json"), []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := migrate(from, to)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if want := []string{"settings.json", "backups"}; !reflect.DeepEqual(migrated, want) {
+		t.Errorf("migrated = %v, want %v", migrated, want)
+	}
+	if _, err := os.Stat(filepath.Join(to, "backups", "work", "machine-id.json")); err != nil {
+		t.Errorf("backup not migrated: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(from, "settings.json")); !os.IsNotExist(err) {
+		t.Error("source settings should be moved")
+	}
+
+	// 再次執行時新位置已有資料，不應搬移
+	if err := os.WriteFile(filepath.Join(from, "settings.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if migrated, err := migrate(from, to); err != nil || len(migrated) != 0 {
+		t.Errorf("expected no migration, got %v (err %v)", migrated, err)
+	}
+	if migrated, err := migrate(to, to); err != nil || len(migrated) != 0 {
+		t.Errorf("same directory should not migrate, got %v (err %v)", migrated, err)
+	}
+}
+
+// TestCopyTree 測試跨磁碟搬移時使用的遞迴複製
+func TestCopyTree(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "copy")
+	if err := os.MkdirAll(filepath.Join(src, "a", "b"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a", "b", "f.json"), []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "a", "b", "f.json")); err != nil || string(data) != "data" {
+		t.Errorf("copied file = %q (err %v)", data, err)
+	}
+}
+
+// TestArgValue 測試從命令列參數取出 --data-dir
+func TestArgValue(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"--data-dir", "/srv/km"}, "/srv/km"},
+		{[]string{"--json", "--data-dir=/srv/km", "backup", "list"}, "/srv/km"},
+		{[]string{"-data-dir=/srv/km"}, "/srv/km"},
+		{[]string{"backup", "meta", "--notes", "--data-dir=/tmp", "work"}, ""},
+		{[]string{"--data-dir"}, ""},
+		{[]string{"--", "--data-dir=/srv/km"}, ""},
+	}
+	for _, tt := range tests {
+		if got := ArgValue(tt.args); got != tt.want {
+			t.Errorf("ArgValue(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
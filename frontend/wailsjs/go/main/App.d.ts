@@ -1,17 +1,37 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {backup} from '../models';
 import {main} from '../models';
+import {kiropath} from '../models';
 import {kiroprocess} from '../models';
+import {report} from '../models';
+import {settings} from '../models';
+
+export function CancelRefreshAll():Promise<main.Result>;
+
+export function CloneBackup(arg1:string,arg2:string):Promise<main.Result>;
 
 export function CreateBackup(arg1:string):Promise<main.Result>;
 
 export function DeleteBackup(arg1:string):Promise<main.Result>;
 
+export function DeleteKiroProfile(arg1:string):Promise<main.Result>;
+
 export function EnsureOriginalBackup():Promise<main.Result>;
 
+export function ExportBackups(arg1:Array<string>,arg2:string):Promise<main.Result>;
+
+export function ExportLogs():Promise<main.Result>;
+
+export function ExportUsageCSV(arg1:number):Promise<main.Result>;
+
+export function ForceCloseKiro():Promise<main.Result>;
+
 export function GetAppInfo():Promise<Record<string, string>>;
 
-export function GetBackupList():Promise<Array<main.BackupItem>>;
+export function GetBackupEncryptionStatus():Promise<backup.EncryptionStatus>;
+
+export function GetBackupList(arg1:main.BackupQuery):Promise<Array<main.BackupItem>>;
 
 export function GetCurrentMachineID():Promise<string>;
 
@@ -19,34 +39,74 @@ export function GetCurrentProvider():Promise<string>;
 
 export function GetCurrentUsageInfo():Promise<main.CurrentUsageInfo>;
 
+export function GetDataDir():Promise<main.DataDirInfo>;
+
 export function GetDetectedKiroInstallPath():Promise<main.Result>;
 
 export function GetDetectedKiroVersion():Promise<main.Result>;
 
+export function GetKiroInstallations():Promise<Array<kiropath.Installation>>;
+
 export function GetKiroProcesses():Promise<Array<kiroprocess.ProcessInfo>>;
 
+export function GetKiroProfiles():Promise<Array<main.KiroProfileStatus>>;
+
+export function GetNetworkSettings():Promise<main.NetworkSettings>;
+
 export function GetSettings():Promise<main.AppSettings>;
 
 export function GetSoftResetStatus():Promise<main.SoftResetStatus>;
 
+export function GetUsageTrend(arg1:string,arg2:number):Promise<report.Trend>;
+
+export function GetUsageTrends(arg1:number):Promise<Array<report.Trend>>;
+
+export function ImportBackupArchive(arg1:string,arg2:string,arg3:string):Promise<main.ImportBackupsResult>;
+
 export function IsKiroRunning():Promise<boolean>;
 
+export function OpenDataFolder():Promise<main.Result>;
+
 export function OpenExtensionFolder():Promise<main.Result>;
 
+export function OpenLogFolder():Promise<main.Result>;
+
 export function OpenMachineIDFolder():Promise<main.Result>;
 
 export function OpenSSOCacheFolder():Promise<main.Result>;
 
+export function RefreshAllBackupUsage():Promise<main.RefreshAllReport>;
+
 export function RefreshBackupUsage(arg1:string):Promise<main.UsageCacheResult>;
 
+export function RelaunchKiro():Promise<main.Result>;
+
+export function RenameBackup(arg1:string,arg2:string):Promise<main.Result>;
+
 export function RepatchExtension():Promise<main.Result>;
 
 export function RestoreSoftReset():Promise<main.Result>;
 
+export function SaveKiroProfile(arg1:string,arg2:settings.KiroProfile):Promise<main.Result>;
+
+export function SaveNetworkSettings(arg1:main.NetworkSettings):Promise<main.Result>;
+
 export function SaveSettings(arg1:main.AppSettings):Promise<main.Result>;
 
+export function SelectBackupArchive():Promise<main.ArchiveSelection>;
+
+export function SetActiveKiroProfile(arg1:string):Promise<main.Result>;
+
+export function SetBackupKeySource(arg1:string,arg2:string):Promise<main.Result>;
+
 export function SoftResetToNewMachine():Promise<main.Result>;
 
 export function SwitchToBackup(arg1:string):Promise<main.Result>;
 
+export function TestConnection(arg1:main.NetworkSettings):Promise<main.Result>;
+
+export function UnlockBackups(arg1:string):Promise<main.Result>;
+
 export function UnpatchExtension():Promise<main.Result>;
+
+export function UpdateBackupMeta(arg1:string,arg2:backup.BackupMeta):Promise<main.Result>;
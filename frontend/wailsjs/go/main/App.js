@@ -2,6 +2,14 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelRefreshAll() {
+  return window['go']['main']['App']['CancelRefreshAll']();
+}
+
+export function CloneBackup(arg1, arg2) {
+  return window['go']['main']['App']['CloneBackup'](arg1, arg2);
+}
+
 export function CreateBackup(arg1) {
   return window['go']['main']['App']['CreateBackup'](arg1);
 }
@@ -10,16 +18,40 @@ export function DeleteBackup(arg1) {
   return window['go']['main']['App']['DeleteBackup'](arg1);
 }
 
+export function DeleteKiroProfile(arg1) {
+  return window['go']['main']['App']['DeleteKiroProfile'](arg1);
+}
+
 export function EnsureOriginalBackup() {
   return window['go']['main']['App']['EnsureOriginalBackup']();
 }
 
+export function ExportBackups(arg1, arg2) {
+  return window['go']['main']['App']['ExportBackups'](arg1, arg2);
+}
+
+export function ExportLogs() {
+  return window['go']['main']['App']['ExportLogs']();
+}
+
+export function ExportUsageCSV(arg1) {
+  return window['go']['main']['App']['ExportUsageCSV'](arg1);
+}
+
+export function ForceCloseKiro() {
+  return window['go']['main']['App']['ForceCloseKiro']();
+}
+
 export function GetAppInfo() {
   return window['go']['main']['App']['GetAppInfo']();
 }
 
-export function GetBackupList() {
-  return window['go']['main']['App']['GetBackupList']();
+export function GetBackupEncryptionStatus() {
+  return window['go']['main']['App']['GetBackupEncryptionStatus']();
+}
+
+export function GetBackupList(arg1) {
+  return window['go']['main']['App']['GetBackupList'](arg1);
 }
 
 export function GetCurrentMachineID() {
@@ -34,6 +66,10 @@ export function GetCurrentUsageInfo() {
   return window['go']['main']['App']['GetCurrentUsageInfo']();
 }
 
+export function GetDataDir() {
+  return window['go']['main']['App']['GetDataDir']();
+}
+
 export function GetDetectedKiroInstallPath() {
   return window['go']['main']['App']['GetDetectedKiroInstallPath']();
 }
@@ -42,10 +78,22 @@ export function GetDetectedKiroVersion() {
   return window['go']['main']['App']['GetDetectedKiroVersion']();
 }
 
+export function GetKiroInstallations() {
+  return window['go']['main']['App']['GetKiroInstallations']();
+}
+
 export function GetKiroProcesses() {
   return window['go']['main']['App']['GetKiroProcesses']();
 }
 
+export function GetKiroProfiles() {
+  return window['go']['main']['App']['GetKiroProfiles']();
+}
+
+export function GetNetworkSettings() {
+  return window['go']['main']['App']['GetNetworkSettings']();
+}
+
 export function GetSettings() {
   return window['go']['main']['App']['GetSettings']();
 }
@@ -54,14 +102,34 @@ export function GetSoftResetStatus() {
   return window['go']['main']['App']['GetSoftResetStatus']();
 }
 
+export function GetUsageTrend(arg1, arg2) {
+  return window['go']['main']['App']['GetUsageTrend'](arg1, arg2);
+}
+
+export function GetUsageTrends(arg1) {
+  return window['go']['main']['App']['GetUsageTrends'](arg1);
+}
+
+export function ImportBackupArchive(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportBackupArchive'](arg1, arg2, arg3);
+}
+
 export function IsKiroRunning() {
   return window['go']['main']['App']['IsKiroRunning']();
 }
 
+export function OpenDataFolder() {
+  return window['go']['main']['App']['OpenDataFolder']();
+}
+
 export function OpenExtensionFolder() {
   return window['go']['main']['App']['OpenExtensionFolder']();
 }
 
+export function OpenLogFolder() {
+  return window['go']['main']['App']['OpenLogFolder']();
+}
+
 export function OpenMachineIDFolder() {
   return window['go']['main']['App']['OpenMachineIDFolder']();
 }
@@ -70,10 +138,22 @@ export function OpenSSOCacheFolder() {
   return window['go']['main']['App']['OpenSSOCacheFolder']();
 }
 
+export function RefreshAllBackupUsage() {
+  return window['go']['main']['App']['RefreshAllBackupUsage']();
+}
+
 export function RefreshBackupUsage(arg1) {
   return window['go']['main']['App']['RefreshBackupUsage'](arg1);
 }
 
+export function RelaunchKiro() {
+  return window['go']['main']['App']['RelaunchKiro']();
+}
+
+export function RenameBackup(arg1, arg2) {
+  return window['go']['main']['App']['RenameBackup'](arg1, arg2);
+}
+
 export function RepatchExtension() {
   return window['go']['main']['App']['RepatchExtension']();
 }
@@ -82,10 +162,30 @@ export function RestoreSoftReset() {
   return window['go']['main']['App']['RestoreSoftReset']();
 }
 
+export function SaveKiroProfile(arg1, arg2) {
+  return window['go']['main']['App']['SaveKiroProfile'](arg1, arg2);
+}
+
+export function SaveNetworkSettings(arg1) {
+  return window['go']['main']['App']['SaveNetworkSettings'](arg1);
+}
+
 export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
 
+export function SelectBackupArchive() {
+  return window['go']['main']['App']['SelectBackupArchive']();
+}
+
+export function SetActiveKiroProfile(arg1) {
+  return window['go']['main']['App']['SetActiveKiroProfile'](arg1);
+}
+
+export function SetBackupKeySource(arg1, arg2) {
+  return window['go']['main']['App']['SetBackupKeySource'](arg1, arg2);
+}
+
 export function SoftResetToNewMachine() {
   return window['go']['main']['App']['SoftResetToNewMachine']();
 }
@@ -94,6 +194,18 @@ export function SwitchToBackup(arg1) {
   return window['go']['main']['App']['SwitchToBackup'](arg1);
 }
 
+export function TestConnection(arg1) {
+  return window['go']['main']['App']['TestConnection'](arg1);
+}
+
+export function UnlockBackups(arg1) {
+  return window['go']['main']['App']['UnlockBackups'](arg1);
+}
+
 export function UnpatchExtension() {
   return window['go']['main']['App']['UnpatchExtension']();
 }
+
+export function UpdateBackupMeta(arg1, arg2) {
+  return window['go']['main']['App']['UpdateBackupMeta'](arg1, arg2);
+}
@@ -1,8 +1,93 @@
+export namespace backup {
+	
+	export class BackupMeta {
+	    displayName?: string;
+	    email?: string;
+	    tags?: string[];
+	    notes?: string;
+	    updatedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupMeta(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.displayName? = source["displayName?"];
+	        this.email? = source["email?"];
+	        this.tags? = source["tags?"];
+	        this.notes? = source["notes?"];
+	        this.updatedAt? = source["updatedAt?"];
+	    }
+	}
+	export class EncryptionStatus {
+	    keySource: string;
+	    locked: boolean;
+	    migrated: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keySource = source["keySource"];
+	        this.locked = source["locked"];
+	        this.migrated = source["migrated"];
+	    }
+	}
+	export class ImportedBackup {
+	    name: string;
+	    sourceName: string;
+	    action: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportedBackup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.sourceName = source["sourceName"];
+	        this.action = source["action"];
+	    }
+	}
+
+}
+
+export namespace kiropath {
+	
+	export class Installation {
+	    path: string;
+	    source: string;
+	    version?: string;
+	    writable: boolean;
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Installation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.source = source["source"];
+	        this.version = source["version"];
+	        this.writable = source["writable"];
+	        this.current = source["current"];
+	    }
+	}
+
+}
+
 export namespace kiroprocess {
 	
 	export class ProcessInfo {
 	    pid: number;
+	    ppid: number;
 	    name: string;
+	    exe?: string;
+	    role: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ProcessInfo(source);
@@ -11,7 +96,10 @@ export namespace kiroprocess {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.pid = source["pid"];
+	        this.ppid = source["ppid"];
 	        this.name = source["name"];
+	        this.exe = source["exe"];
+	        this.role = source["role"];
 	    }
 	}
 
@@ -24,6 +112,9 @@ export namespace main {
 	    kiroVersion: string;
 	    useAutoDetect: boolean;
 	    customKiroInstallPath: string;
+	    trashSsoCache: boolean;
+	    kiroShutdownGraceSeconds: number;
+	    relaunchKiro: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -35,6 +126,31 @@ export namespace main {
 	        this.kiroVersion = source["kiroVersion"];
 	        this.useAutoDetect = source["useAutoDetect"];
 	        this.customKiroInstallPath = source["customKiroInstallPath"];
+	        this.trashSsoCache = source["trashSsoCache"];
+	        this.kiroShutdownGraceSeconds = source["kiroShutdownGraceSeconds"];
+	        this.relaunchKiro = source["relaunchKiro"];
+	    }
+	}
+	export class ArchiveSelection {
+	    success: boolean;
+	    message: string;
+	    path: string;
+	    encrypted: boolean;
+	    createdAt: string;
+	    backups: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveSelection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.path = source["path"];
+	        this.encrypted = source["encrypted"];
+	        this.createdAt = source["createdAt"];
+	        this.backups = source["backups"];
 	    }
 	}
 	export class BackupItem {
@@ -47,12 +163,18 @@ export namespace main {
 	    isCurrent: boolean;
 	    isOriginalMachine: boolean;
 	    isTokenExpired: boolean;
+	    tokenExpiresAt: string;
 	    subscriptionTitle: string;
 	    usageLimit: number;
 	    currentUsage: number;
 	    balance: number;
 	    isLowBalance: boolean;
 	    cachedAt: string;
+	    breakdown: usage.BreakdownItem[];
+	    displayName: string;
+	    email: string;
+	    tags: string[];
+	    notes: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new BackupItem(source);
@@ -69,13 +191,93 @@ export namespace main {
 	        this.isCurrent = source["isCurrent"];
 	        this.isOriginalMachine = source["isOriginalMachine"];
 	        this.isTokenExpired = source["isTokenExpired"];
+	        this.tokenExpiresAt = source["tokenExpiresAt"];
 	        this.subscriptionTitle = source["subscriptionTitle"];
 	        this.usageLimit = source["usageLimit"];
 	        this.currentUsage = source["currentUsage"];
 	        this.balance = source["balance"];
 	        this.isLowBalance = source["isLowBalance"];
 	        this.cachedAt = source["cachedAt"];
+	        this.breakdown = this.convertValues(source["breakdown"], usage.BreakdownItem);
+	        this.displayName = source["displayName"];
+	        this.email = source["email"];
+	        this.tags = source["tags"];
+	        this.notes = source["notes"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BackupQuery {
+	    search: string;
+	    tags: string[];
+	    provider: string;
+	    tokenState: string;
+	    lowBalanceOnly: boolean;
+	    sortBy: string;
+	    desc: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.search = source["search"];
+	        this.tags = source["tags"];
+	        this.provider = source["provider"];
+	        this.tokenState = source["tokenState"];
+	        this.lowBalanceOnly = source["lowBalanceOnly"];
+	        this.sortBy = source["sortBy"];
+	        this.desc = source["desc"];
+	    }
+	}
+	export class BackupRefreshResult {
+	    name: string;
+	    result: UsageCacheResult;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupRefreshResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.result = this.convertValues(source["result"], UsageCacheResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class CurrentUsageInfo {
 	    subscriptionTitle: string;
@@ -97,9 +299,154 @@ export namespace main {
 	        this.isLowBalance = source["isLowBalance"];
 	    }
 	}
+	export class DataDirInfo {
+	    dir: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DataDirInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dir = source["dir"];
+	        this.source = source["source"];
+	    }
+	}
+	export class ImportBackupsResult {
+	    success: boolean;
+	    message: string;
+	    backups: backup.ImportedBackup[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportBackupsResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.backups = this.convertValues(source["backups"], backup.ImportedBackup);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KiroProfileStatus {
+	    name: string;
+	    installPath: string;
+	    kiroVersion: string;
+	    detectedVersion: string;
+	    exists: boolean;
+	    running: boolean;
+	    processCount: number;
+	    active: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new KiroProfileStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.installPath = source["installPath"];
+	        this.kiroVersion = source["kiroVersion"];
+	        this.detectedVersion = source["detectedVersion"];
+	        this.exists = source["exists"];
+	        this.running = source["running"];
+	        this.processCount = source["processCount"];
+	        this.active = source["active"];
+	    }
+	}
+	export class NetworkSettings {
+	    proxyUrl: string;
+	    noProxy: string;
+	    caBundlePath: string;
+	    connectTimeoutSeconds: number;
+	    requestTimeoutSeconds: number;
+	    retryMaxAttempts: number;
+	    retryBaseDelayMillis: number;
+	    retryMaxDelaySeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NetworkSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxyUrl = source["proxyUrl"];
+	        this.noProxy = source["noProxy"];
+	        this.caBundlePath = source["caBundlePath"];
+	        this.connectTimeoutSeconds = source["connectTimeoutSeconds"];
+	        this.requestTimeoutSeconds = source["requestTimeoutSeconds"];
+	        this.retryMaxAttempts = source["retryMaxAttempts"];
+	        this.retryBaseDelayMillis = source["retryBaseDelayMillis"];
+	        this.retryMaxDelaySeconds = source["retryMaxDelaySeconds"];
+	    }
+	}
+	export class RefreshAllReport {
+	    success: boolean;
+	    message: string;
+	    total: number;
+	    succeeded: string[];
+	    failed: string[];
+	    expired: string[];
+	    canceled: string[];
+	    results: BackupRefreshResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RefreshAllReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.total = source["total"];
+	        this.succeeded = source["succeeded"];
+	        this.failed = source["failed"];
+	        this.expired = source["expired"];
+	        this.canceled = source["canceled"];
+	        this.results = this.convertValues(source["results"], BackupRefreshResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Result {
 	    success: boolean;
 	    message: string;
+	    errorCode?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Result(source);
@@ -109,6 +456,7 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.success = source["success"];
 	        this.message = source["message"];
+	        this.errorCode = source["errorCode"];
 	    }
 	}
 	export class SoftResetStatus {
@@ -141,6 +489,8 @@ export namespace main {
 	    isLowBalance: boolean;
 	    isTokenExpired: boolean;
 	    cachedAt: string;
+	    errorCode: string;
+	    breakdown: usage.BreakdownItem[];
 	
 	    static createFrom(source: any = {}) {
 	        return new UsageCacheResult(source);
@@ -157,6 +507,155 @@ export namespace main {
 	        this.isLowBalance = source["isLowBalance"];
 	        this.isTokenExpired = source["isTokenExpired"];
 	        this.cachedAt = source["cachedAt"];
+	        this.errorCode = source["errorCode"];
+	        this.breakdown = this.convertValues(source["breakdown"], usage.BreakdownItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace report {
+	
+	export class DailyUsage {
+	    date: string;
+	    consumed: number;
+	    balance: number;
+	    usageLimit: number;
+	    samples: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DailyUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.consumed = source["consumed"];
+	        this.balance = source["balance"];
+	        this.usageLimit = source["usageLimit"];
+	        this.samples = source["samples"];
+	    }
+	}
+	export class Trend {
+	    name: string;
+	    subscriptionTitle: string;
+	    days: number;
+	    samples: number;
+	    from: string;
+	    to: string;
+	    balance: number;
+	    usageLimit: number;
+	    consumed: number;
+	    burnRatePerDay: number;
+	    daysRemaining: number;
+	    projectedZeroDate: string;
+	    daily: DailyUsage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Trend(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.subscriptionTitle = source["subscriptionTitle"];
+	        this.days = source["days"];
+	        this.samples = source["samples"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.balance = source["balance"];
+	        this.usageLimit = source["usageLimit"];
+	        this.consumed = source["consumed"];
+	        this.burnRatePerDay = source["burnRatePerDay"];
+	        this.daysRemaining = source["daysRemaining"];
+	        this.projectedZeroDate = source["projectedZeroDate"];
+	        this.daily = this.convertValues(source["daily"], DailyUsage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace settings {
+	
+	export class KiroProfile {
+	    name: string;
+	    installPath: string;
+	    kiroVersion?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KiroProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.installPath = source["installPath"];
+	        this.kiroVersion = source["kiroVersion"];
+	    }
+	}
+
+}
+
+export namespace usage {
+	
+	export class BreakdownItem {
+	    category: string;
+	    displayName: string;
+	    bonusCode: string;
+	    status: string;
+	    usageLimit: number;
+	    currentUsage: number;
+	    balance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreakdownItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.displayName = source["displayName"];
+	        this.bonusCode = source["bonusCode"];
+	        this.status = source["status"];
+	        this.usageLimit = source["usageLimit"];
+	        this.currentUsage = source["currentUsage"];
+	        this.balance = source["balance"];
 	    }
 	}
 
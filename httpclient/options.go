@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Options API 客戶端共用的連線選項：傳輸層、超時、重試策略與時鐘
+// 零值即依全域設定運作；測試可替換 Transport 與 Now 以 httptest.Server 跑完整流程
+type Options struct {
+	// Transport HTTP 傳輸層，nil 時使用 http.DefaultTransport
+	Transport http.RoundTripper
+	// Timeout 單次請求超時，0 時使用全域設定或呼叫端的預設值
+	Timeout time.Duration
+	// Retry 重試策略，nil 時使用全域設定
+	Retry *RetryPolicy
+	// Now 時鐘，nil 時使用 time.Now
+	Now func() time.Time
+}
+
+// DefaultOptions 使用全域設定（代理、CA、超時、重試）的選項
+func DefaultOptions() Options {
+	return Options{Transport: Transport}
+}
+
+// HTTPClient 建立本次請求使用的 http.Client
+// Timeout 與全域設定都未指定時使用 fallback
+func (o Options) HTTPClient(fallback time.Duration) *http.Client {
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = RequestTimeout(fallback)
+	}
+	return &http.Client{Transport: o.Transport, Timeout: timeout}
+}
+
+// RetryPolicy 取得本次請求使用的重試策略
+func (o Options) RetryPolicy() RetryPolicy {
+	if o.Retry != nil {
+		return o.Retry.WithDefaults()
+	}
+	return CurrentRetryPolicy()
+}
+
+// CurrentTime 依 Now 取得目前時間
+func (o Options) CurrentTime() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
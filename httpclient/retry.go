@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kiro-manager/logging"
+)
+
+var (
+	// ErrUnauthorized Token 無效或已過期（HTTP 401/403）
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited 請求過於頻繁（HTTP 429）
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerUnavailable 伺服器暫時無法使用（HTTP 5xx）
+	ErrServerUnavailable = errors.New("server unavailable")
+	// ErrNetwork 無法連線到伺服器（DNS、連線逾時、TLS、代理錯誤等）
+	ErrNetwork = errors.New("network error")
+)
+
+// RetryPolicy 重試策略：指數退避加上隨機抖動，並遵守 Retry-After
+type RetryPolicy struct {
+	// MaxAttempts 最多嘗試次數（含第一次），小於等於 1 表示不重試
+	MaxAttempts int
+	// BaseDelay 第一次重試前的等待時間，之後每次加倍
+	BaseDelay time.Duration
+	// MaxDelay 單次等待上限；伺服器要求的 Retry-After 超過此值時不再重試
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy 未設定時使用的重試策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// CurrentRetryPolicy 取得目前設定的重試策略
+func CurrentRetryPolicy() RetryPolicy {
+	if s := current.Load(); s != nil {
+		return s.retry
+	}
+	return DefaultRetryPolicy
+}
+
+// WithDefaults 以預設值補齊未設定的欄位
+func (p RetryPolicy) WithDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// backoff 計算第 retry 次重試（從 1 開始）前的等待時間
+// 上限為 BaseDelay * 2^(retry-1)，實際等待時間在上限的一半到上限之間隨機取值
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.MaxDelay
+	if shift := retry - 1; shift < 30 {
+		if exp := p.BaseDelay << shift; exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// StatusError 非 2xx 的 HTTP 回應
+// 可用 errors.Is 比對 ErrUnauthorized、ErrRateLimited、ErrServerUnavailable
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter 伺服器要求的等待時間（未提供時為 0）
+	RetryAfter time.Duration
+}
+
+// NewStatusError 由 HTTP 回應建立 StatusError
+func NewStatusError(resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *StatusError) Error() string {
+	body := e.Body
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, body)
+}
+
+// Is 依狀態碼對應到哨兵錯誤
+func (e *StatusError) Is(target error) bool {
+	return target != nil && target == statusSentinel(e.StatusCode)
+}
+
+// statusSentinel 取得狀態碼對應的哨兵錯誤，沒有對應時返回 nil
+func statusSentinel(code int) error {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return ErrUnauthorized
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500 && code < 600:
+		return ErrServerUnavailable
+	}
+	return nil
+}
+
+// isRetryableStatus 判斷狀態碼是否值得重試
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code < 600 && code != http.StatusNotImplemented)
+}
+
+// ParseRetryAfter 解析 Retry-After header（秒數或 HTTP 日期），無法解析時返回 0
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Do 依重試策略發送請求
+// newRequest 每次嘗試都會被呼叫（attempt 從 1 開始），以便重建 body 與更新 header
+// 網路錯誤與 429/5xx 會在等待後重試；最後一次的回應（包含錯誤狀態碼）會原樣返回，
+// 由呼叫端讀取 body 並以 NewStatusError 轉換。網路錯誤會包裝 ErrNetwork
+func Do(ctx context.Context, client *http.Client, policy RetryPolicy, newRequest func(attempt int) (*http.Request, error)) (*http.Response, error) {
+	policy = policy.WithDefaults()
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest(attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if attempt >= policy.MaxAttempts {
+				return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+			}
+			delay = policy.backoff(attempt)
+			logging.Debug("request failed, retrying", "url", req.URL.Host, "attempt", attempt, "delay", delay, "err", err)
+
+		case isRetryableStatus(resp.StatusCode) && attempt < policy.MaxAttempts:
+			delay = policy.backoff(attempt)
+			if retryAfter := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+				if retryAfter > policy.MaxDelay {
+					// 伺服器要求等待太久，直接交由呼叫端處理
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			logging.Debug("retryable status, retrying", "url", req.URL.Host, "status", resp.StatusCode, "attempt", attempt, "delay", delay)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+
+		default:
+			return resp, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// DoRequest 依重試策略發送 req，重試時以 req.GetBody 重建 body
+func DoRequest(client *http.Client, policy RetryPolicy, req *http.Request) (*http.Response, error) {
+	return Do(req.Context(), client, policy, func(attempt int) (*http.Request, error) {
+		if attempt == 1 {
+			return req, nil
+		}
+		r := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		return r, nil
+	})
+}
@@ -0,0 +1,210 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry 測試用的短等待重試策略
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// stubServer 依序回傳指定狀態碼的測試伺服器，回傳請求次數計數器
+func stubServer(t *testing.T, retryAfter string, codes ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		code := codes[min(n, len(codes))-1]
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(code)
+		fmt.Fprintf(w, "attempt %d", n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func get(ctx context.Context, url string) func(int) (*http.Request, error) {
+	return func(int) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+}
+
+// TestDo_RetriesUntilSuccess 測試 5xx 會重試直到成功
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	server, calls := stubServer(t, "", 503, 502, 200)
+	ctx := context.Background()
+
+	resp, err := Do(ctx, server.Client(), fastRetry, get(ctx, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || calls.Load() != 3 {
+		t.Errorf("status %d after %d calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+// TestDo_StopsAtMaxAttempts 測試達到上限後返回最後一次的回應
+func TestDo_StopsAtMaxAttempts(t *testing.T) {
+	server, calls := stubServer(t, "", 429)
+	ctx := context.Background()
+
+	resp, err := Do(ctx, server.Client(), fastRetry, get(ctx, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 429 || calls.Load() != 3 {
+		t.Errorf("status %d after %d calls, want 429 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+// TestDo_NoRetryForClientErrors 測試 4xx（429 以外）不重試
+func TestDo_NoRetryForClientErrors(t *testing.T) {
+	server, calls := stubServer(t, "", 401, 200)
+	ctx := context.Background()
+
+	resp, err := Do(ctx, server.Client(), fastRetry, get(ctx, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 401 || calls.Load() != 1 {
+		t.Errorf("status %d after %d calls, want 401 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+// TestDo_RetryAfterTooLong 測試 Retry-After 超過等待上限時不重試
+func TestDo_RetryAfterTooLong(t *testing.T) {
+	server, calls := stubServer(t, "120", 429, 200)
+	ctx := context.Background()
+
+	resp, err := Do(ctx, server.Client(), fastRetry, get(ctx, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 429 || calls.Load() != 1 {
+		t.Errorf("status %d after %d calls, want 429 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+// TestDo_RetryAfterHonoured 測試遵守 Retry-After 的等待時間
+func TestDo_RetryAfterHonoured(t *testing.T) {
+	server, calls := stubServer(t, "1", 503, 200)
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+	start := time.Now()
+	resp, err := Do(ctx, server.Client(), policy, get(ctx, server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least Retry-After (1s)", elapsed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+// TestDo_NetworkError 測試網路錯誤會重試並包裝 ErrNetwork
+func TestDo_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	attempts := 0
+	ctx := context.Background()
+	_, err := Do(ctx, http.DefaultClient, fastRetry, func(int) (*http.Request, error) {
+		attempts++
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("expected ErrNetwork, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+// TestDo_ContextCanceled 測試等待重試時取消會立即返回
+func TestDo_ContextCanceled(t *testing.T) {
+	server, _ := stubServer(t, "", 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute}
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := Do(ctx, server.Client(), policy, get(ctx, server.URL)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestStatusError_Is 測試狀態碼對應的哨兵錯誤
+func TestStatusError_Is(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{401, ErrUnauthorized},
+		{403, ErrUnauthorized},
+		{429, ErrRateLimited},
+		{500, ErrServerUnavailable},
+		{503, ErrServerUnavailable},
+	}
+	for _, tt := range tests {
+		err := fmt.Errorf("wrapped: %w", &StatusError{StatusCode: tt.code})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("HTTP %d should match %v", tt.code, tt.want)
+		}
+	}
+
+	err := &StatusError{StatusCode: 400}
+	for _, sentinel := range []error{ErrUnauthorized, ErrRateLimited, ErrServerUnavailable, ErrNetwork} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("HTTP 400 should not match %v", sentinel)
+		}
+	}
+}
+
+// TestParseRetryAfter 測試 Retry-After 解析
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestBackoff 測試退避時間在指數上限的一半到上限之間
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for retry := 1; retry <= 8; retry++ {
+		ceiling := min(p.BaseDelay<<(retry-1), p.MaxDelay)
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(retry); d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", retry, d, ceiling/2, ceiling)
+			}
+		}
+	}
+}
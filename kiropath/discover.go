@@ -0,0 +1,500 @@
+package kiropath
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"kiro-manager/sysenv"
+)
+
+// InstallSource Kiro 安裝的來源
+type InstallSource string
+
+const (
+	SourcePackage  InstallSource = "package"  // 系統套件（deb / rpm）或標準安裝位置
+	SourceUser     InstallSource = "user"     // 使用者目錄下的標準安裝位置
+	SourceTarball  InstallSource = "tarball"  // 解壓縮的 tar.gz（例如 ~/Applications/Kiro）
+	SourceAppImage InstallSource = "appimage" // 單一 AppImage 檔案
+	SourceFlatpak  InstallSource = "flatpak"
+	SourceSnap     InstallSource = "snap"
+	SourcePath     InstallSource = "path"    // PATH 上的 kiro 指令
+	SourceDesktop  InstallSource = "desktop" // .desktop 捷徑的 Exec
+)
+
+// Installation 偵測到的一個 Kiro 安裝
+type Installation struct {
+	Path     string        `json:"path"`              // 安裝路徑，可直接作為 CustomKiroInstallPath
+	Source   InstallSource `json:"source"`            // 偵測來源
+	Version  string        `json:"version,omitempty"` // 讀取不到時為空字串
+	Writable bool          `json:"writable"`          // 是否可修改安裝內容（Patch extension.js 需要）
+	Current  bool          `json:"current"`           // 是否為 GetKiroInstallPath 目前使用的安裝
+}
+
+// systemRoot Linux 系統路徑的根目錄（測試時替換）
+var systemRoot = "/"
+
+// appImageVersionPattern AppImage 檔名中的版本號（例如 Kiro-0.7.5-x86_64.AppImage）
+var appImageVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+`)
+
+// DiscoverInstallations 找出所有 Kiro 安裝，依偵測優先順序排列
+// 同一安裝經由不同來源（例如套件目錄與 PATH 上的符號連結）找到時只保留第一筆
+func DiscoverInstallations() []Installation {
+	var candidates []Installation
+	switch runtime.GOOS {
+	case "windows":
+		candidates = windowsInstallations()
+	case "darwin":
+		candidates = darwinInstallations()
+	case "linux":
+		for _, source := range linuxInstallSources {
+			candidates = append(candidates, source()...)
+		}
+	}
+
+	current, _ := GetKiroInstallPath()
+	current = resolveInstallPath(current)
+
+	installations := []Installation{}
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		key := resolveInstallPath(c.Path)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		c.Version = ReadInstallVersion(c.Path)
+		c.Writable = isInstallWritable(c.Path)
+		c.Current = key == current
+		installations = append(installations, c)
+	}
+	return installations
+}
+
+// ReadInstallVersion 讀取安裝的版本號，讀取不到時回傳空字串
+// 目錄從 Electron 的 resources/app/package.json 讀取；AppImage 從檔名取得
+func ReadInstallVersion(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		if isAppImage(path) {
+			return appImageVersionPattern.FindString(filepath.Base(path))
+		}
+		path = filepath.Dir(path)
+	}
+
+	for _, appDir := range []string{
+		filepath.Join(path, "resources", "app"),
+		filepath.Join(path, "Contents", "Resources", "app"),
+	} {
+		data, err := os.ReadFile(filepath.Join(appDir, "package.json"))
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+			return pkg.Version
+		}
+	}
+	return ""
+}
+
+// isInstallWritable 檢查是否能修改安裝內容
+// AppImage、Snap 等唯讀映像即使檔案本身可寫入，也無法 Patch 其中的 extension.js
+func isInstallWritable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	for _, dir := range []string{
+		filepath.Join(path, "resources", "app", "extensions"),
+		filepath.Join(path, "Contents", "Resources", "app", "extensions"),
+	} {
+		if _, err := os.Stat(dir); err == nil {
+			return isWritable(dir)
+		}
+	}
+	return isWritable(path)
+}
+
+// resolveInstallPath 解析符號連結，作為比較兩個安裝是否相同的鍵
+func resolveInstallPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(filepath.Clean(path))
+	}
+	return filepath.Clean(path)
+}
+
+// firstInstallDir 回傳第一個目錄形式的安裝（AppImage 等單一檔案無法讀取版本或 Patch）
+func firstInstallDir(installations []Installation) (string, error) {
+	for _, inst := range installations {
+		if info, err := os.Stat(inst.Path); err == nil && info.IsDir() {
+			return inst.Path, nil
+		}
+	}
+	return "", ErrKiroNotFound
+}
+
+// ============================================================================
+// Windows / macOS
+// ============================================================================
+
+// windowsInstallations 使用者安裝（%LOCALAPPDATA%\Programs）與系統安裝（Program Files）
+func windowsInstallations() []Installation {
+	var found []Installation
+	for _, c := range []struct {
+		base   string
+		sub    []string
+		source InstallSource
+	}{
+		{os.Getenv("LOCALAPPDATA"), []string{"Programs", "Kiro"}, SourceUser},
+		{os.Getenv("PROGRAMFILES"), []string{"Kiro"}, SourcePackage},
+		{os.Getenv("PROGRAMFILES(X86)"), []string{"Kiro"}, SourcePackage},
+	} {
+		if c.base == "" {
+			continue
+		}
+		dir := filepath.Join(append([]string{c.base}, c.sub...)...)
+		if _, err := os.Stat(filepath.Join(dir, "Kiro.exe")); err == nil {
+			found = append(found, Installation{Path: dir, Source: c.source})
+		}
+	}
+	return found
+}
+
+// darwinInstallations /Applications 與 ~/Applications 下的 Kiro.app
+func darwinInstallations() []Installation {
+	var found []Installation
+	if _, err := os.Stat("/Applications/Kiro.app"); err == nil {
+		found = append(found, Installation{Path: "/Applications/Kiro.app", Source: SourcePackage})
+	}
+	if home, err := sysenv.HomeDir(); err == nil {
+		userAppPath := filepath.Join(home, "Applications", "Kiro.app")
+		if _, err := os.Stat(userAppPath); err == nil {
+			found = append(found, Installation{Path: userAppPath, Source: SourceUser})
+		}
+	}
+	return found
+}
+
+// ============================================================================
+// Linux
+// ============================================================================
+
+// linuxInstallSources Linux 各種安裝來源的偵測，依優先順序排列
+// 自動偵測取第一個找到的目錄，因此較便宜、較常見的來源放在前面
+var linuxInstallSources = []func() []Installation{
+	linuxPackageInstallations,
+	linuxUserInstallations,
+	linuxFlatpakInstallations,
+	linuxSnapInstallations,
+	linuxPathInstallations,
+	linuxDesktopInstallations,
+}
+
+// systemPath 將 Linux 系統路徑對應到 systemRoot 之下
+func systemPath(path string) string {
+	return filepath.Join(systemRoot, path)
+}
+
+// isLinuxInstallDir 檢查目錄是否為 Kiro 的 Electron 安裝（含 resources/app 或 kiro 執行檔）
+func isLinuxInstallDir(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, "resources", "app")); err == nil && info.IsDir() {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(dir, "kiro"))
+	return err == nil && !info.IsDir()
+}
+
+// isAppImage 檢查檔名是否為 AppImage
+func isAppImage(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".appimage")
+}
+
+// xdgDataHome 取得 $XDG_DATA_HOME（預設 ~/.local/share）
+func xdgDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	home, err := sysenv.HomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+// linuxPackageInstallations deb / rpm 套件與手動安裝的系統目錄
+func linuxPackageInstallations() []Installation {
+	var found []Installation
+	for _, dir := range []string{
+		"/usr/share/kiro",
+		"/opt/kiro",
+		"/opt/Kiro",
+		"/usr/local/share/kiro",
+		"/usr/lib/kiro",
+	} {
+		if dir = systemPath(dir); isLinuxInstallDir(dir) {
+			found = append(found, Installation{Path: dir, Source: SourcePackage})
+		}
+	}
+	return found
+}
+
+// linuxUserInstallations 使用者目錄下的安裝、解壓縮的 tarball 與 AppImage
+func linuxUserInstallations() []Installation {
+	var found []Installation
+	if dataHome := xdgDataHome(); dataHome != "" {
+		if dir := filepath.Join(dataHome, "kiro"); isLinuxInstallDir(dir) {
+			found = append(found, Installation{Path: dir, Source: SourceUser})
+		}
+	}
+
+	home, err := sysenv.HomeDir()
+	if err != nil {
+		return found
+	}
+	// 常見放置 tarball 與 AppImage 的目錄，只看名稱含有 kiro 的項目
+	for _, dir := range []string{
+		filepath.Join(home, "Applications"),
+		filepath.Join(home, "apps"),
+		filepath.Join(home, "opt"),
+		filepath.Join(home, ".local", "opt"),
+		filepath.Join(home, ".local", "bin"),
+		filepath.Join(home, "bin"),
+		filepath.Join(home, "Downloads"),
+	} {
+		found = append(found, scanUserDir(dir)...)
+	}
+	return found
+}
+
+// scanUserDir 掃描目錄中名稱含有 kiro 的 AppImage 與解壓縮目錄（含下一層，例如 kiro/Kiro-linux-x64）
+func scanUserDir(dir string) []Installation {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var found []Installation
+	for _, entry := range entries {
+		if !strings.Contains(strings.ToLower(entry.Name()), "kiro") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			if isAppImage(path) {
+				found = append(found, Installation{Path: path, Source: SourceAppImage})
+			}
+			continue
+		}
+		if installDir := findInstallDir(path, 1); installDir != "" {
+			found = append(found, Installation{Path: installDir, Source: SourceTarball})
+		}
+	}
+	return found
+}
+
+// findInstallDir 在 root 與其下 depth 層子目錄中尋找 Kiro 安裝目錄
+func findInstallDir(root string, depth int) string {
+	if isLinuxInstallDir(root) {
+		return root
+	}
+	if depth <= 0 {
+		return ""
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			continue
+		}
+		if found := findInstallDir(path, depth-1); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// linuxFlatpakInstallations 系統與使用者的 Flatpak 應用程式中 ID 含有 kiro 者
+func linuxFlatpakInstallations() []Installation {
+	roots := []string{systemPath("/var/lib/flatpak/app")}
+	if dataHome := xdgDataHome(); dataHome != "" {
+		roots = append(roots, filepath.Join(dataHome, "flatpak", "app"))
+	}
+
+	var found []Installation
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.Contains(strings.ToLower(entry.Name()), "kiro") {
+				continue
+			}
+			files := filepath.Join(root, entry.Name(), "current", "active", "files")
+			if installDir := findInstallDir(files, 3); installDir != "" {
+				found = append(found, Installation{Path: installDir, Source: SourceFlatpak})
+			}
+		}
+	}
+	return found
+}
+
+// linuxSnapInstallations /snap 下名稱含有 kiro 的 Snap
+func linuxSnapInstallations() []Installation {
+	root := systemPath("/snap")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var found []Installation
+	for _, entry := range entries {
+		if !strings.Contains(strings.ToLower(entry.Name()), "kiro") {
+			continue
+		}
+		if installDir := findInstallDir(filepath.Join(root, entry.Name(), "current"), 3); installDir != "" {
+			found = append(found, Installation{Path: installDir, Source: SourceSnap})
+		}
+	}
+	return found
+}
+
+// linuxPathInstallations PATH 上的 kiro 指令（通常是指向安裝目錄的符號連結）
+func linuxPathInstallations() []Installation {
+	var found []Installation
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		if inst, ok := installFromExecutable(filepath.Join(dir, "kiro"), SourcePath); ok {
+			found = append(found, inst)
+		}
+	}
+	return found
+}
+
+// linuxDesktopInstallations .desktop 捷徑指向的 Kiro（AppImage 整合工具建立的捷徑也在此）
+func linuxDesktopInstallations() []Installation {
+	var dirs []string
+	if dataHome := xdgDataHome(); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "applications"))
+	}
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = systemPath("/usr/local/share") + string(filepath.ListSeparator) + systemPath("/usr/share")
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+
+	var found []Installation
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			if !strings.HasSuffix(name, ".desktop") || !strings.Contains(name, "kiro") {
+				continue
+			}
+			exe := desktopExec(filepath.Join(dir, entry.Name()))
+			if !filepath.IsAbs(exe) {
+				// 相對指令由 PATH 偵測處理；flatpak run 等包裝由各自的來源處理
+				continue
+			}
+			if inst, ok := installFromExecutable(exe, SourceDesktop); ok {
+				found = append(found, inst)
+			}
+		}
+	}
+	return found
+}
+
+// desktopExec 讀取 .desktop 檔 [Desktop Entry] 區段 Exec 的執行檔（第一個參數）
+func desktopExec(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inEntry := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEntry = line == "[Desktop Entry]"
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "Exec=")
+		if !inEntry || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if quoted, ok := strings.CutPrefix(value, `"`); ok {
+			if end := strings.IndexByte(quoted, '"'); end >= 0 {
+				return quoted[:end]
+			}
+			return ""
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			return fields[0]
+		}
+		return ""
+	}
+	return ""
+}
+
+// installFromExecutable 由執行檔推回安裝位置：AppImage 即為安裝本身，
+// 其他執行檔解析符號連結後往上三層內尋找安裝目錄（例如 <install>/bin/kiro）
+func installFromExecutable(exe string, source InstallSource) (Installation, bool) {
+	resolved, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return Installation{}, false
+	}
+	if info, err := os.Stat(resolved); err != nil || info.IsDir() {
+		return Installation{}, false
+	}
+	if isAppImage(resolved) {
+		return Installation{Path: resolved, Source: source}, true
+	}
+
+	dir := filepath.Dir(resolved)
+	for i := 0; i < 3; i++ {
+		if info, err := os.Stat(filepath.Join(dir, "resources", "app")); err == nil && info.IsDir() {
+			return Installation{Path: dir, Source: source}, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return Installation{}, false
+}
@@ -0,0 +1,188 @@
+package kiropath
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kiro-manager/sysenv"
+)
+
+// makeInstall 建立含 resources/app/package.json 的模擬 Electron 安裝目錄
+func makeInstall(t *testing.T, dir, version string) string {
+	t.Helper()
+	appDir := filepath.Join(dir, "resources", "app", "extensions")
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := `{"name": "kiro", "version": "` + version + `"}`
+	if err := os.WriteFile(filepath.Join(dir, "resources", "app", "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin", "kiro"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writeFile 建立檔案與所在目錄
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupLinuxTree 以暫存目錄模擬 Linux 系統根目錄與家目錄
+func setupLinuxTree(t *testing.T) (root, home string) {
+	t.Helper()
+	root, home = t.TempDir(), t.TempDir()
+	savedRoot := systemRoot
+	systemRoot = root
+	t.Cleanup(func() { systemRoot = savedRoot })
+	t.Cleanup(sysenv.Set(sysenv.Fixed{Home: home, Data: t.TempDir()}))
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_DATA_DIRS", "")
+	t.Setenv("PATH", "")
+	return root, home
+}
+
+// linuxCandidates 依序執行所有 Linux 來源
+func linuxCandidates() []Installation {
+	var found []Installation
+	for _, source := range linuxInstallSources {
+		found = append(found, source()...)
+	}
+	return found
+}
+
+// TestLinuxInstallSources 測試各種 Linux 安裝來源都能被找到
+func TestLinuxInstallSources(t *testing.T) {
+	root, home := setupLinuxTree(t)
+
+	pkg := makeInstall(t, filepath.Join(root, "usr", "share", "kiro"), "0.7.5")
+	user := makeInstall(t, filepath.Join(home, ".local", "share", "kiro"), "0.7.6")
+	tarball := makeInstall(t, filepath.Join(home, "Applications", "kiro", "Kiro-linux-x64"), "0.8.0")
+	appImage := filepath.Join(home, "Downloads", "Kiro-0.8.1-x86_64.AppImage")
+	writeFile(t, appImage, "")
+	writeFile(t, filepath.Join(home, "Downloads", "kiro-notes.txt"), "")
+	flatpak := makeInstall(t, filepath.Join(root, "var", "lib", "flatpak", "app", "dev.kiro.Kiro", "current", "active", "files", "kiro"), "0.7.4")
+	snap := makeInstall(t, filepath.Join(root, "snap", "kiro", "current", "usr", "share", "kiro"), "0.7.3")
+
+	// PATH 上的 kiro 指向 /opt 下的另一份安裝
+	optInstall := makeInstall(t, filepath.Join(root, "srv", "kiro-nightly"), "0.9.0")
+	binDir := filepath.Join(root, "usr", "local", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(optInstall, "bin", "kiro"), filepath.Join(binDir, "kiro")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	// .desktop 捷徑指向其他位置的 AppImage
+	desktopAppImage := filepath.Join(root, "media", "tools", "Kiro.AppImage")
+	writeFile(t, desktopAppImage, "")
+	writeFile(t, filepath.Join(home, ".local", "share", "applications", "appimagekit-kiro.desktop"),
+		"[Desktop Entry]\nName=Kiro\nExec=\""+desktopAppImage+"\" %U\n\n[Desktop Action new]\nExec=/bin/false\n")
+	writeFile(t, filepath.Join(home, ".local", "share", "applications", "kiro-url-handler.desktop"),
+		"[Desktop Entry]\nExec=flatpak run dev.kiro.Kiro %U\n")
+
+	want := []struct {
+		path    string
+		source  InstallSource
+		version string
+	}{
+		{pkg, SourcePackage, "0.7.5"},
+		{user, SourceUser, "0.7.6"},
+		{tarball, SourceTarball, "0.8.0"},
+		{appImage, SourceAppImage, "0.8.1"},
+		{flatpak, SourceFlatpak, "0.7.4"},
+		{snap, SourceSnap, "0.7.3"},
+		{optInstall, SourcePath, "0.9.0"},
+		{desktopAppImage, SourceDesktop, ""},
+	}
+
+	got := linuxCandidates()
+	if len(got) != len(want) {
+		t.Fatalf("found %d installations: %+v", len(got), got)
+	}
+	for i, w := range want {
+		if got[i].Path != w.path || got[i].Source != w.source {
+			t.Errorf("[%d] = %s (%s), want %s (%s)", i, got[i].Path, got[i].Source, w.path, w.source)
+		}
+		if v := ReadInstallVersion(got[i].Path); v != w.version {
+			t.Errorf("[%d] version = %q, want %q", i, v, w.version)
+		}
+	}
+
+	if path, err := getLinuxKiroInstallPath(); err != nil || path != pkg {
+		t.Errorf("getLinuxKiroInstallPath = %q, %v", path, err)
+	}
+}
+
+// TestLinuxInstallSources_DedupAndWritable 測試經由 PATH 找到的套件安裝不會重複列出，且 AppImage 不可寫入
+func TestLinuxInstallSources_DedupAndWritable(t *testing.T) {
+	root, home := setupLinuxTree(t)
+
+	pkg := makeInstall(t, filepath.Join(root, "opt", "kiro"), "0.7.5")
+	binDir := filepath.Join(root, "usr", "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(pkg, "bin", "kiro"), filepath.Join(binDir, "kiro")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+	appImage := filepath.Join(home, "Applications", "Kiro.AppImage")
+	writeFile(t, appImage, "")
+
+	candidates := linuxCandidates()
+	if len(candidates) != 3 {
+		t.Fatalf("candidates = %+v", candidates)
+	}
+
+	seen := make(map[string]bool)
+	var unique []Installation
+	for _, c := range candidates {
+		if key := resolveInstallPath(c.Path); !seen[key] {
+			seen[key] = true
+			unique = append(unique, c)
+		}
+	}
+	if len(unique) != 2 || unique[0].Source != SourcePackage || unique[1].Source != SourceAppImage {
+		t.Fatalf("unique = %+v", unique)
+	}
+	if !isInstallWritable(pkg) {
+		t.Error("package install in a writable temp dir should be writable")
+	}
+	if isInstallWritable(appImage) {
+		t.Error("AppImage should not be writable")
+	}
+}
+
+// TestDesktopExec 測試解析 .desktop 的 Exec
+func TestDesktopExec(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"[Desktop Entry]\nExec=/opt/kiro/kiro --no-sandbox %F\n":       "/opt/kiro/kiro",
+		"[Desktop Entry]\nExec=\"/home/u/My Apps/Kiro.AppImage\" %U\n": "/home/u/My Apps/Kiro.AppImage",
+		"[Desktop Action new]\nExec=/bin/false\n":                      "",
+		"[Desktop Entry]\nName=Kiro\n":                                 "",
+	}
+	i := 0
+	for content, want := range cases {
+		path := filepath.Join(dir, "kiro"+string(rune('a'+i))+".desktop")
+		i++
+		writeFile(t, path, content)
+		if got := desktopExec(path); got != want {
+			t.Errorf("desktopExec(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
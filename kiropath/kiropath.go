@@ -7,16 +7,17 @@ import (
 	"runtime"
 
 	"kiro-manager/settings"
+	"kiro-manager/sysenv"
 )
 
 var (
-	ErrKiroNotFound      = errors.New("kiro installation not found")
+	ErrKiroNotFound        = errors.New("kiro installation not found")
 	ErrUnsupportedPlatform = errors.New("unsupported platform: " + runtime.GOOS)
 )
 
 // GetKiroHomePath 取得 Kiro 的使用者設定目錄 (~/.kiro)
 func GetKiroHomePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := sysenv.HomeDir()
 	if err != nil {
 		return "", err
 	}
@@ -29,7 +30,7 @@ func GetKiroHomePath() (string, error) {
 // macOS: ~/Library/Application Support/Kiro
 // Linux: ~/.config/Kiro
 func GetKiroConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := sysenv.HomeDir()
 	if err != nil {
 		return "", err
 	}
@@ -54,13 +55,20 @@ func GetKiroConfigPath() (string, error) {
 	}
 }
 
-
 // GetKiroInstallPath 取得 Kiro 的安裝路徑
-// 優先使用自定義路徑，若未設定則自動偵測
+// 已選擇安裝設定檔時只使用該設定檔的路徑（不存在時返回 ErrKiroNotFound，避免誤操作另一個安裝）
+// 否則優先使用自定義路徑，若未設定則自動偵測
 // Windows: 檢查 %LOCALAPPDATA%\Programs\Kiro 和 %PROGRAMFILES%\Kiro
 // macOS: /Applications/Kiro.app
-// Linux: /usr/share/kiro, /opt/kiro, 或 /usr/local/bin/kiro
+// Linux: 套件目錄、使用者目錄、tarball、Flatpak、Snap、PATH 與 .desktop 捷徑（見 DiscoverInstallations）
 func GetKiroInstallPath() (string, error) {
+	if profile := settings.GetActiveKiroProfile(); profile != nil {
+		if _, err := os.Stat(profile.InstallPath); err != nil {
+			return "", ErrKiroNotFound
+		}
+		return profile.InstallPath, nil
+	}
+
 	// 優先使用自定義路徑
 	customPath := settings.GetCustomKiroInstallPath()
 	if customPath != "" {
@@ -133,7 +141,7 @@ func GetAWSConfigPath() (string, error) {
 		return filepath.Dir(awsConfigFile), nil
 	}
 
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := sysenv.HomeDir()
 	if err != nil {
 		return "", err
 	}
@@ -150,78 +158,23 @@ func AWSConfigExists() bool {
 	return err == nil
 }
 
+// getWindowsKiroInstallPath 依序檢查使用者安裝與系統安裝路徑
 func getWindowsKiroInstallPath() (string, error) {
-	// 優先檢查使用者安裝路徑
-	localAppData := os.Getenv("LOCALAPPDATA")
-	if localAppData != "" {
-		userPath := filepath.Join(localAppData, "Programs", "Kiro", "Kiro.exe")
-		if _, err := os.Stat(userPath); err == nil {
-			return filepath.Dir(userPath), nil
-		}
-	}
-
-	// 檢查系統安裝路徑
-	programFiles := os.Getenv("PROGRAMFILES")
-	if programFiles != "" {
-		systemPath := filepath.Join(programFiles, "Kiro", "Kiro.exe")
-		if _, err := os.Stat(systemPath); err == nil {
-			return filepath.Dir(systemPath), nil
-		}
-	}
-
-	// 檢查 x86 程式目錄
-	programFilesX86 := os.Getenv("PROGRAMFILES(X86)")
-	if programFilesX86 != "" {
-		x86Path := filepath.Join(programFilesX86, "Kiro", "Kiro.exe")
-		if _, err := os.Stat(x86Path); err == nil {
-			return filepath.Dir(x86Path), nil
-		}
-	}
-
-	return "", ErrKiroNotFound
+	return firstInstallDir(windowsInstallations())
 }
 
+// getDarwinKiroInstallPath 依序檢查 /Applications 與 ~/Applications
 func getDarwinKiroInstallPath() (string, error) {
-	// 標準應用程式目錄
-	appPath := "/Applications/Kiro.app"
-	if _, err := os.Stat(appPath); err == nil {
-		return appPath, nil
-	}
-
-	// 使用者應用程式目錄
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		userAppPath := filepath.Join(homeDir, "Applications", "Kiro.app")
-		if _, err := os.Stat(userAppPath); err == nil {
-			return userAppPath, nil
-		}
-	}
-
-	return "", ErrKiroNotFound
+	return firstInstallDir(darwinInstallations())
 }
 
+// getLinuxKiroInstallPath 依 linuxInstallSources 的順序取第一個找到的安裝目錄
+// 找到後即停止，不會掃描其餘較慢的來源
 func getLinuxKiroInstallPath() (string, error) {
-	// 常見的 Linux 安裝路徑
-	paths := []string{
-		"/usr/share/kiro",
-		"/opt/kiro",
-		"/usr/local/share/kiro",
-	}
-
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
+	for _, source := range linuxInstallSources {
+		if path, err := firstInstallDir(source()); err == nil {
 			return path, nil
 		}
 	}
-
-	// 檢查使用者本地安裝
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		localPath := filepath.Join(homeDir, ".local", "share", "kiro")
-		if _, err := os.Stat(localPath); err == nil {
-			return localPath, nil
-		}
-	}
-
 	return "", ErrKiroNotFound
 }
@@ -0,0 +1,82 @@
+package kiropath
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// GetKiroExecutablePath 取得 Kiro 主程式的路徑
+// Windows: <安裝路徑>\Kiro.exe
+// macOS: <Kiro.app>/Contents/MacOS/Kiro
+// Linux: <安裝路徑>/kiro 或 <安裝路徑>/bin/kiro（安裝路徑本身是執行檔時直接使用）
+func GetKiroExecutablePath() (string, error) {
+	installPath, err := GetKiroInstallPath()
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(installPath); err == nil && !info.IsDir() {
+		return installPath, nil
+	}
+
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		candidates = []string{filepath.Join(installPath, "Kiro.exe")}
+	case "darwin":
+		candidates = []string{filepath.Join(installPath, "Contents", "MacOS", "Kiro")}
+	case "linux":
+		candidates = []string{
+			filepath.Join(installPath, "kiro"),
+			filepath.Join(installPath, "bin", "kiro"),
+		}
+	default:
+		return "", ErrUnsupportedPlatform
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", ErrKiroNotFound
+}
+
+// GetKiroLaunchCommand 取得啟動 Kiro 並傳入 args 的命令
+// exe 為先前執行中 Kiro 的執行檔，空字串時使用 GetKiroExecutablePath
+// macOS 透過 open -a 啟動所屬的 .app，讓 Kiro 以一般方式（LaunchServices）執行
+func GetKiroLaunchCommand(exe string, args []string) (string, []string, error) {
+	if exe == "" {
+		var err error
+		if exe, err = GetKiroExecutablePath(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		if app := appBundlePath(exe); app != "" {
+			openArgs := []string{"-a", app}
+			if len(args) > 0 {
+				openArgs = append(openArgs, "--args")
+				openArgs = append(openArgs, args...)
+			}
+			return "open", openArgs, nil
+		}
+	}
+	return exe, args, nil
+}
+
+// appBundlePath 取得執行檔所屬的 .app 路徑，不在 .app 內時回傳空字串
+func appBundlePath(exe string) string {
+	for dir := exe; ; {
+		if strings.HasSuffix(dir, ".app") {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package kiropath
+
+import "golang.org/x/sys/unix"
+
+// isWritable 以 access(2) 檢查目前使用者能否寫入（唯讀掛載的檔案系統也會回報不可寫入）
+func isWritable(path string) bool {
+	return unix.Access(path, unix.W_OK) == nil
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package kiropath
+
+import "os"
+
+// isWritable 以建立暫存檔的方式檢查能否寫入目錄（Windows 的 ACL 無法只由屬性判斷）
+func isWritable(dir string) bool {
+	file, err := os.CreateTemp(dir, ".kiro-manager-*")
+	if err != nil {
+		return false
+	}
+	name := file.Name()
+	file.Close()
+	os.Remove(name)
+	return true
+}
@@ -2,7 +2,14 @@ package kiroprocess
 
 import (
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+
+	"kiro-manager/kiropath"
 )
 
 var (
@@ -10,12 +17,53 @@ var (
 	ErrProcessNotFound     = errors.New("kiro process not found")
 )
 
+// ProcessRole Kiro 進程在 Electron 架構中的角色
+type ProcessRole string
+
+const (
+	RoleMain          ProcessRole = "main"          // 主進程，負責視窗與其他進程的生命週期
+	RoleRenderer      ProcessRole = "renderer"      // 視窗內容
+	RoleExtensionHost ProcessRole = "extensionHost" // 執行擴充套件（含 Kiro Agent）
+	RoleHelper        ProcessRole = "helper"        // GPU、網路、crashpad 等其他輔助進程
+)
+
 // ProcessInfo 包含進程的基本資訊
 type ProcessInfo struct {
-	PID  int    `json:"pid"`
-	Name string `json:"name"`
+	PID  int         `json:"pid"`
+	PPID int         `json:"ppid"`
+	Name string      `json:"name"`
+	Exe  string      `json:"exe,omitempty"` // 執行檔完整路徑
+	Role ProcessRole `json:"role"`
+}
+
+// ProcessNode Kiro 進程樹的節點
+type ProcessNode struct {
+	ProcessInfo
+	Children []*ProcessNode `json:"children,omitempty"`
+}
+
+// systemProcess 平台掃描到的進程（尚未判斷是否屬於 Kiro）
+type systemProcess struct {
+	PID  int
+	PPID int
+	Name string
+	Exe  string   // 無法讀取時為空字串（例如其他使用者的進程）
+	Args []string // 無法讀取時為 nil
+	// AppImage 由 AppImage 啟動的進程其 APPIMAGE 環境變數（映像檔路徑），僅 Linux
+	// 這類進程的執行檔位於 /tmp/.mount_* 下，無法以安裝路徑比對
+	AppImage string
 }
 
+// installKind 安裝路徑的形式，決定如何比對進程
+type installKind int
+
+const (
+	installUnknown  installKind = iota // 找不到安裝路徑，以執行檔名稱判斷
+	installDir                         // 安裝目錄
+	installFile                        // 單一執行檔
+	installAppImage                    // AppImage 映像檔
+)
+
 // IsKiroRunning 檢查 Kiro 是否正在運行
 func IsKiroRunning() bool {
 	processes, err := GetKiroProcesses()
@@ -25,18 +73,187 @@ func IsKiroRunning() bool {
 	return len(processes) > 0
 }
 
-// GetKiroProcesses 取得所有正在運行的 Kiro 進程
+// GetKiroProcesses 取得所有正在運行的 Kiro 進程，依 PID 排序
+// 以執行檔路徑是否位於 kiropath.GetKiroInstallPath() 之下判斷，不會誤判名稱含有 kiro 的其他程式（包含本程式）
+// AppImage 安裝改以 APPIMAGE 環境變數判斷（執行檔位於臨時掛載點下）
+// Linux 讀取 /proc，macOS 使用 ps，Windows 使用 Toolhelp 快照
 func GetKiroProcesses() ([]ProcessInfo, error) {
-	switch runtime.GOOS {
-	case "windows":
-		return getWindowsKiroProcesses()
-	case "darwin":
-		return getDarwinKiroProcesses()
-	case "linux":
-		return getLinuxKiroProcesses()
+	// 找不到安裝路徑時改以執行檔名稱判斷
+	installPath, _ := kiropath.GetKiroInstallPath()
+	return GetKiroProcessesAt(installPath)
+}
+
+// GetKiroProcessesAt 取得執行檔位於指定安裝路徑的 Kiro 進程，依 PID 排序
+// 同時安裝多個 Kiro 時可分別判斷各安裝是否執行中
+func GetKiroProcessesAt(installPath string) ([]ProcessInfo, error) {
+	processes, err := listSystemProcesses()
+	if err != nil {
+		return nil, err
+	}
+	return matchKiroProcesses(processes, installPath, os.Getpid()), nil
+}
+
+// GetKiroProcessTree 取得 Kiro 進程樹，根節點為主進程
+func GetKiroProcessTree() ([]*ProcessNode, error) {
+	processes, err := GetKiroProcesses()
+	if err != nil {
+		return nil, err
+	}
+	return BuildProcessTree(processes), nil
+}
+
+// BuildProcessTree 依 PPID 將進程組成樹狀結構，父進程不在清單中的進程為根節點
+func BuildProcessTree(processes []ProcessInfo) []*ProcessNode {
+	nodes := make(map[int]*ProcessNode, len(processes))
+	for _, p := range processes {
+		nodes[p.PID] = &ProcessNode{ProcessInfo: p}
+	}
+
+	var roots []*ProcessNode
+	for _, p := range processes {
+		node := nodes[p.PID]
+		if parent, ok := nodes[p.PPID]; ok && p.PPID != p.PID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// matchKiroProcesses 從系統進程中挑出 Kiro 的進程並判斷角色，排除 self（本程式的 PID）
+func matchKiroProcesses(processes []systemProcess, installPath string, self int) []ProcessInfo {
+	installPath = resolvePath(installPath)
+	kind := detectInstallKind(installPath)
+
+	var matched []systemProcess
+	isKiro := make(map[int]bool)
+	for _, p := range processes {
+		if p.PID == self || p.PID <= 0 {
+			continue
+		}
+		if !isKiroExecutable(p, installPath, kind) {
+			continue
+		}
+		matched = append(matched, p)
+		isKiro[p.PID] = true
+	}
+
+	result := make([]ProcessInfo, 0, len(matched))
+	for _, p := range matched {
+		result = append(result, ProcessInfo{
+			PID:  p.PID,
+			PPID: p.PPID,
+			Name: p.Name,
+			Exe:  p.Exe,
+			Role: processRole(p.Args, isKiro[p.PPID]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PID < result[j].PID })
+	return result
+}
+
+// detectInstallKind 判斷安裝路徑是目錄、AppImage 或一般執行檔
+func detectInstallKind(installPath string) installKind {
+	if installPath == "" {
+		return installUnknown
+	}
+	info, err := os.Stat(installPath)
+	switch {
+	case err != nil:
+		return installFile
+	case info.IsDir():
+		return installDir
+	case isAppImageFile(installPath):
+		return installAppImage
 	default:
-		return nil, ErrUnsupportedPlatform
+		return installFile
+	}
+}
+
+// isAppImageFile 檢查 ELF 標頭後的 AppImage 魔術數字（offset 8 的 "AI" 與版本 1 或 2）
+func isAppImageFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, 11)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return string(header[:4]) == "\x7fELF" && header[8] == 'A' && header[9] == 'I' && (header[10] == 1 || header[10] == 2)
+}
+
+// isKiroExecutable 判斷進程的執行檔是否屬於 Kiro
+// installPath 為目錄時比對執行檔是否位於其中，為檔案時比對是否為同一檔案；
+// 為 AppImage 時以 APPIMAGE 環境變數比對，映像檔本身的進程負責掛載檔案系統，不屬於 Kiro；
+// installPath 為空時只接受名稱正好是 Kiro 的執行檔
+func isKiroExecutable(p systemProcess, installPath string, kind installKind) bool {
+	if p.Exe == "" {
+		return false
+	}
+	exe := resolvePath(p.Exe)
+
+	switch kind {
+	case installUnknown:
+		name := strings.TrimSuffix(strings.ToLower(filepath.Base(exe)), ".exe")
+		return name == "kiro" || strings.HasPrefix(name, "kiro helper")
+	case installAppImage:
+		return p.AppImage != "" && samePath(resolvePath(p.AppImage), installPath) && !samePath(exe, installPath)
+	case installFile:
+		return samePath(exe, installPath)
+	}
+	rel, err := filepath.Rel(installPath, exe)
+	if err != nil || filepath.IsAbs(rel) {
+		return false
 	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// processRole 由 Electron 的 --type 參數判斷進程角色
+// 沒有 --type 且父進程不是 Kiro 的進程為主進程
+func processRole(args []string, parentIsKiro bool) ProcessRole {
+	processType := ""
+	for _, arg := range args {
+		// VS Code 系列以 --type=extensionHost 啟動擴充套件主機，可能出現在 Electron 自己的 --type 之後
+		if arg == "--type=extensionHost" {
+			return RoleExtensionHost
+		}
+		if value, ok := strings.CutPrefix(arg, "--type="); ok && processType == "" {
+			processType = value
+		}
+	}
+
+	switch {
+	case processType == "renderer":
+		return RoleRenderer
+	case processType != "" || parentIsKiro:
+		return RoleHelper
+	default:
+		return RoleMain
+	}
+}
+
+// resolvePath 清理路徑並解析符號連結（例如 /usr/bin/kiro -> /usr/share/kiro/kiro）
+// Linux 上執行檔被更新或刪除時，/proc/<pid>/exe 會帶有 " (deleted)" 後綴
+func resolvePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = filepath.Clean(strings.TrimSuffix(path, " (deleted)"))
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// samePath 比較兩個路徑，Windows 與 macOS 預設的檔案系統不分大小寫
+func samePath(a, b string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
 }
 
 // GetKiroProcessCount 取得 Kiro 進程數量
@@ -48,8 +265,8 @@ func GetKiroProcessCount() int {
 	return len(processes)
 }
 
-// KillKiroProcesses 關閉所有 Kiro 進程
-// Windows 使用原生 API，其他平台使用 kill 命令
+// KillKiroProcesses 立即強制關閉所有 Kiro 進程，未儲存的變更會遺失
+// 一般操作應使用 Shutdown 先要求 Kiro 正常結束
 // 回傳被關閉的進程數量和錯誤
 func KillKiroProcesses() (int, error) {
 	processes, err := GetKiroProcesses()
@@ -63,18 +280,28 @@ func KillKiroProcesses() (int, error) {
 
 	killed := 0
 	for _, p := range processes {
-		var killErr error
-		switch runtime.GOOS {
-		case "windows":
-			killErr = killWindowsProcess(p.PID)
-		default:
-			killErr = killUnixProcess(p.PID)
-		}
-
-		if killErr == nil {
+		if killProcess(p.PID) == nil {
 			killed++
 		}
 	}
 
 	return killed, nil
 }
+
+// killProcess 強制終止指定 PID 的進程
+// Windows 使用 taskkill /F，其他平台使用 kill -9
+func killProcess(pid int) error {
+	if runtime.GOOS == "windows" {
+		return killWindowsProcess(pid)
+	}
+	return killUnixProcess(pid)
+}
+
+// terminateProcess 要求指定 PID 的進程正常結束
+// Windows 送出 WM_CLOSE（taskkill 不加 /F），其他平台送出 SIGTERM
+func terminateProcess(pid int) error {
+	if runtime.GOOS == "windows" {
+		return closeWindowsProcess(pid)
+	}
+	return terminateUnixProcess(pid)
+}
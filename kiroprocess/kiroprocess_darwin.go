@@ -0,0 +1,72 @@
+//go:build darwin
+
+package kiroprocess
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listSystemProcesses 使用 ps 取得所有進程
+// macOS 沒有 procfs；comm 欄位為執行檔完整路徑（可能含空白），args 欄位用於判斷 --type 參數
+func listSystemProcesses() ([]systemProcess, error) {
+	output, err := exec.Command("ps", "-axww", "-o", "pid=,ppid=,comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	processes := parsePsComm(string(output))
+
+	// 參數只用於判斷角色，取得失敗時仍可依執行檔路徑比對
+	if output, err := exec.Command("ps", "-axww", "-o", "pid=,args=").Output(); err == nil {
+		args := parsePsArgs(string(output))
+		for i := range processes {
+			processes[i].Args = args[processes[i].PID]
+		}
+	}
+	return processes, nil
+}
+
+// parsePsComm 解析 "pid ppid comm" 輸出
+func parsePsComm(output string) []systemProcess {
+	var processes []systemProcess
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		// 執行檔路徑可能含空白（例如 "Kiro Helper (Renderer)"），取前兩欄之後的完整內容
+		rest := strings.TrimSpace(line)
+		for range 2 {
+			rest = strings.TrimSpace(rest[strings.IndexAny(rest, " \t"):])
+		}
+		processes = append(processes, systemProcess{
+			PID:  pid,
+			PPID: ppid,
+			Name: filepath.Base(rest),
+			Exe:  rest,
+		})
+	}
+	return processes
+}
+
+// parsePsArgs 解析 "pid args" 輸出，參數以空白分隔（執行檔路徑中的空白會被拆開，不影響 --type 判斷）
+func parsePsArgs(output string) map[int][]string {
+	args := make(map[int][]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[0]); err == nil {
+			args[pid] = fields[1:]
+		}
+	}
+	return args
+}
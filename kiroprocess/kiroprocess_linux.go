@@ -0,0 +1,105 @@
+//go:build linux
+
+package kiroprocess
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot procfs 的掛載位置（測試時替換）
+var procRoot = "/proc"
+
+// listSystemProcesses 讀取 /proc 取得所有進程
+// 已結束或無權讀取的進程會略過執行檔與參數，不視為錯誤
+func listSystemProcesses() ([]systemProcess, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []systemProcess
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		p, ok := readProcProcess(pid)
+		if !ok {
+			continue
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+// readProcProcess 讀取 /proc/<pid> 下的 stat、exe、cmdline 與 environ
+func readProcProcess(pid int) (systemProcess, bool) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return systemProcess{}, false
+	}
+	name, ppid, ok := parseProcStat(string(stat))
+	if !ok {
+		return systemProcess{}, false
+	}
+
+	p := systemProcess{PID: pid, PPID: ppid, Name: name}
+	p.Exe, _ = os.Readlink(filepath.Join(dir, "exe"))
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		p.Args = parseProcCmdline(cmdline)
+	}
+	if environ, err := os.ReadFile(filepath.Join(dir, "environ")); err == nil {
+		p.AppImage = procEnvValue(environ, "APPIMAGE")
+	}
+	return p, true
+}
+
+// procEnvValue 從以 NUL 分隔的 /proc/<pid>/environ 取出環境變數的值
+func procEnvValue(environ []byte, key string) string {
+	prefix := []byte(key + "=")
+	for _, entry := range bytes.Split(environ, []byte{0}) {
+		if value, ok := bytes.CutPrefix(entry, prefix); ok {
+			return string(value)
+		}
+	}
+	return ""
+}
+
+// parseProcStat 解析 /proc/<pid>/stat 的名稱與父進程 PID
+// 格式為 "pid (comm) state ppid ..."，comm 可能含空白與括號，因此以最後一個 ')' 分隔
+func parseProcStat(stat string) (name string, ppid int, ok bool) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", 0, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return stat[open+1 : end], ppid, true
+}
+
+// parseProcCmdline 解析以 NUL 分隔的 /proc/<pid>/cmdline
+func parseProcCmdline(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+	parts := bytes.Split(data, []byte{0})
+	args := make([]string, len(parts))
+	for i, part := range parts {
+		args[i] = string(part)
+	}
+	return args
+}
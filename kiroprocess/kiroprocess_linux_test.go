@@ -0,0 +1,100 @@
+//go:build linux
+
+package kiroprocess
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// writeFakeProc 在模擬的 procfs 中建立一個進程
+func writeFakeProc(t *testing.T, root string, pid int, stat, exe string, args ...string) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if exe != "" {
+		if err := os.Symlink(exe, filepath.Join(dir, "exe")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cmdline []byte
+	for _, arg := range args {
+		cmdline = append(append(cmdline, arg...), 0)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), cmdline, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestListSystemProcesses_Proc 測試從 /proc 讀取執行檔、參數與父進程
+func TestListSystemProcesses_Proc(t *testing.T) {
+	root := t.TempDir()
+	_, exe := fakeInstall(t)
+	writeFakeProc(t, root, 100, "100 (kiro) S 1 100 100 0", exe, exe, "--no-sandbox")
+	writeFakeProc(t, root, 102, "102 (kiro (renderer)) S 100 100 100 0", exe, exe, "--type=renderer")
+	writeFakeProc(t, root, 300, "300 (kthreadd) S 2 0 0 0", "")
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = saved })
+
+	processes, err := listSystemProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []systemProcess{
+		{PID: 100, PPID: 1, Name: "kiro", Exe: exe, Args: []string{exe, "--no-sandbox"}},
+		{PID: 102, PPID: 100, Name: "kiro (renderer)", Exe: exe, Args: []string{exe, "--type=renderer"}},
+		{PID: 300, PPID: 2, Name: "kthreadd"},
+	}
+	if !reflect.DeepEqual(processes, want) {
+		t.Errorf("processes = %+v\nwant %+v", processes, want)
+	}
+}
+
+// TestListSystemProcesses_AppImageEnv 測試從 /proc/<pid>/environ 讀取 APPIMAGE
+func TestListSystemProcesses_AppImageEnv(t *testing.T) {
+	root := t.TempDir()
+	exe := "/tmp/.mount_KiroAb12/kiro"
+	writeFakeProc(t, root, 101, "101 (kiro) S 100 101 101 0", exe, exe)
+	environ := []byte("HOME=/home/user\x00APPIMAGE=/home/user/Apps/Kiro.AppImage\x00APPDIR=/tmp/.mount_KiroAb12\x00")
+	if err := os.WriteFile(filepath.Join(root, "101", "environ"), environ, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = saved })
+
+	processes, err := listSystemProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processes) != 1 || processes[0].AppImage != "/home/user/Apps/Kiro.AppImage" {
+		t.Errorf("processes = %+v", processes)
+	}
+}
+
+// TestGetKiroProcesses_ExcludesSelf 測試實際掃描時不會包含本程式
+func TestGetKiroProcesses_ExcludesSelf(t *testing.T) {
+	processes, err := GetKiroProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range processes {
+		if p.PID == os.Getpid() {
+			t.Errorf("own process reported as Kiro: %+v", p)
+		}
+	}
+}
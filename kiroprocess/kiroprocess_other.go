@@ -5,60 +5,22 @@ package kiroprocess
 import (
 	"os/exec"
 	"strconv"
-	"strings"
+	"syscall"
 )
 
-// getWindowsKiroProcesses 非 Windows 平台不支援
-func getWindowsKiroProcesses() ([]ProcessInfo, error) {
-	return nil, ErrUnsupportedPlatform
-}
-
 // killWindowsProcess 非 Windows 平台不支援
 func killWindowsProcess(pid int) error {
 	return ErrUnsupportedPlatform
 }
 
-func getDarwinKiroProcesses() ([]ProcessInfo, error) {
-	cmd := exec.Command("pgrep", "-l", "Kiro")
-	output, err := cmd.Output()
-	if err != nil {
-		return []ProcessInfo{}, nil
-	}
-	return parseUnixPgrep(string(output))
-}
-
-func getLinuxKiroProcesses() ([]ProcessInfo, error) {
-	cmd := exec.Command("pgrep", "-l", "-i", "kiro")
-	output, err := cmd.Output()
-	if err != nil {
-		return []ProcessInfo{}, nil
-	}
-	return parseUnixPgrep(string(output))
+// closeWindowsProcess 非 Windows 平台不支援
+func closeWindowsProcess(pid int) error {
+	return ErrUnsupportedPlatform
 }
 
-func parseUnixPgrep(output string) ([]ProcessInfo, error) {
-	var processes []ProcessInfo
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) >= 2 {
-			pid, err := strconv.Atoi(parts[0])
-			if err == nil {
-				processes = append(processes, ProcessInfo{
-					PID:  pid,
-					Name: parts[1],
-				})
-			}
-		}
-	}
-
-	return processes, nil
+// detachProcess 讓啟動的進程使用新的 session，不隨本程式或終端機結束
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 }
 
 // killUnixProcess 使用 kill 命令終止進程
@@ -66,3 +28,8 @@ func killUnixProcess(pid int) error {
 	cmd := exec.Command("kill", "-9", strconv.Itoa(pid))
 	return cmd.Run()
 }
+
+// terminateUnixProcess 送出 SIGTERM，讓 Kiro 有機會儲存狀態後結束
+func terminateUnixProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,145 @@
+package kiroprocess
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeInstall 建立模擬的 Kiro 安裝目錄，回傳目錄與主執行檔路徑
+func fakeInstall(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "kiro")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "kiro")
+	if err := os.WriteFile(exe, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir, exe
+}
+
+// TestMatchKiroProcesses 測試只比對安裝目錄下的執行檔並判斷角色
+func TestMatchKiroProcesses(t *testing.T) {
+	dir, exe := fakeInstall(t)
+	crashpad := filepath.Join(dir, "chrome_crashpad_handler")
+	manager := filepath.Join(filepath.Dir(dir), "kiro-manager")
+
+	processes := []systemProcess{
+		{PID: 1, PPID: 0, Name: "systemd", Exe: "/usr/lib/systemd/systemd"},
+		{PID: 100, PPID: 1, Name: "kiro", Exe: exe, Args: []string{exe}},
+		{PID: 101, PPID: 100, Name: "kiro", Exe: exe, Args: []string{exe, "--type=zygote"}},
+		{PID: 102, PPID: 101, Name: "kiro", Exe: exe, Args: []string{exe, "--type=renderer"}},
+		{PID: 103, PPID: 100, Name: "kiro", Exe: exe, Args: []string{exe, "--type=utility", "--type=extensionHost"}},
+		{PID: 104, PPID: 100, Name: "chrome_crashpad", Exe: crashpad},
+		{PID: 200, PPID: 1, Name: "kiro-manager", Exe: manager},
+		{PID: 300, PPID: 1, Name: "kiro", Exe: exe, Args: []string{exe}},
+		{PID: 400, PPID: 1, Name: "kiro"},
+	}
+
+	got := matchKiroProcesses(processes, dir, 300)
+	want := map[int]ProcessRole{
+		100: RoleMain,
+		101: RoleHelper,
+		102: RoleRenderer,
+		103: RoleExtensionHost,
+		104: RoleHelper,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("matched %+v", got)
+	}
+	for _, p := range got {
+		if role, ok := want[p.PID]; !ok || p.Role != role {
+			t.Errorf("pid %d: role = %q, want %q (matched = %v)", p.PID, p.Role, role, ok)
+		}
+	}
+}
+
+// TestMatchKiroProcesses_InstallFile 測試安裝路徑為執行檔時只比對同一檔案
+func TestMatchKiroProcesses_InstallFile(t *testing.T) {
+	dir, exe := fakeInstall(t)
+	link := filepath.Join(t.TempDir(), "kiro")
+	if err := os.Symlink(exe, link); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+
+	processes := []systemProcess{
+		{PID: 100, PPID: 1, Name: "kiro", Exe: exe},
+		{PID: 101, PPID: 1, Name: "other", Exe: filepath.Join(dir, "other")},
+	}
+	got := matchKiroProcesses(processes, link, 0)
+	if len(got) != 1 || got[0].PID != 100 || got[0].Role != RoleMain {
+		t.Errorf("matched %+v", got)
+	}
+}
+
+// TestMatchKiroProcesses_AppImage 測試 AppImage 安裝以 APPIMAGE 比對掛載點下的進程，
+// 負責掛載檔案系統的映像檔進程不列入，Shutdown 只會要求 Kiro 主進程結束
+func TestMatchKiroProcesses_AppImage(t *testing.T) {
+	root := t.TempDir()
+	image := filepath.Join(root, "Kiro-0.7.5-x86_64.AppImage")
+	header := append([]byte("\x7fELF\x02\x01\x01\x00AI\x02"), make([]byte, 64)...)
+	if err := os.WriteFile(image, header, 0755); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(root, "Other.AppImage")
+	mount := "/tmp/.mount_KiroAb12/kiro"
+
+	processes := []systemProcess{
+		{PID: 100, PPID: 1, Name: "Kiro-0.7.5-x86_", Exe: image, Args: []string{image}},
+		{PID: 101, PPID: 100, Name: "kiro", Exe: mount, Args: []string{mount}, AppImage: image},
+		{PID: 102, PPID: 101, Name: "kiro", Exe: mount, Args: []string{mount, "--type=renderer"}, AppImage: image},
+		{PID: 103, PPID: 101, Name: "kiro", Exe: mount, Args: []string{mount, "--type=utility", "--type=extensionHost"}, AppImage: image},
+		{PID: 200, PPID: 1, Name: "other", Exe: "/tmp/.mount_OtherX/other", AppImage: other},
+	}
+	got := matchKiroProcesses(processes, image, 0)
+	want := map[int]ProcessRole{101: RoleMain, 102: RoleRenderer, 103: RoleExtensionHost}
+	if len(got) != len(want) {
+		t.Fatalf("matched %+v", got)
+	}
+	for _, p := range got {
+		if role, ok := want[p.PID]; !ok || p.Role != role {
+			t.Errorf("pid %d: role = %q, want %q (matched = %v)", p.PID, p.Role, role, ok)
+		}
+	}
+	if targets := closeTargets(got); len(targets) != 1 || targets[0] != 101 {
+		t.Errorf("close targets = %v, want only the Kiro main process", targets)
+	}
+}
+
+// TestMatchKiroProcesses_NoInstallPath 測試找不到安裝路徑時只接受名稱正好是 Kiro 的執行檔
+func TestMatchKiroProcesses_NoInstallPath(t *testing.T) {
+	processes := []systemProcess{
+		{PID: 100, PPID: 1, Name: "Kiro.exe", Exe: "/mnt/c/Apps/Kiro/Kiro.exe"},
+		{PID: 101, PPID: 100, Name: "Kiro Helper (Renderer)", Exe: "/Applications/Kiro.app/Contents/Frameworks/Kiro Helper (Renderer)", Args: []string{"--type=renderer"}},
+		{PID: 200, PPID: 1, Name: "kiro-manager", Exe: "/opt/kiro-manager/kiro-manager"},
+		{PID: 201, PPID: 1, Name: "kiro-manager-cli", Exe: "/usr/local/bin/kiro-manager-cli"},
+	}
+	got := matchKiroProcesses(processes, "", 0)
+	if len(got) != 2 || got[0].PID != 100 || got[1].PID != 101 || got[1].Role != RoleRenderer {
+		t.Errorf("matched %+v", got)
+	}
+}
+
+// TestBuildProcessTree 測試依 PPID 組成進程樹
+func TestBuildProcessTree(t *testing.T) {
+	roots := BuildProcessTree([]ProcessInfo{
+		{PID: 100, PPID: 1, Role: RoleMain},
+		{PID: 101, PPID: 100, Role: RoleHelper},
+		{PID: 102, PPID: 101, Role: RoleRenderer},
+		{PID: 103, PPID: 100, Role: RoleExtensionHost},
+		{PID: 500, PPID: 42, Role: RoleMain},
+	})
+	if len(roots) != 2 || roots[0].PID != 100 || roots[1].PID != 500 {
+		t.Fatalf("roots = %+v", roots)
+	}
+	main := roots[0]
+	if len(main.Children) != 2 || main.Children[0].PID != 101 || main.Children[1].PID != 103 {
+		t.Fatalf("children = %+v", main.Children)
+	}
+	if len(main.Children[0].Children) != 1 || main.Children[0].Children[0].PID != 102 {
+		t.Errorf("grandchildren = %+v", main.Children[0].Children)
+	}
+}
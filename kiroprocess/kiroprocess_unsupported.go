@@ -0,0 +1,8 @@
+//go:build !windows && !linux && !darwin
+
+package kiroprocess
+
+// listSystemProcesses 不支援的平台
+func listSystemProcesses() ([]systemProcess, error) {
+	return nil, ErrUnsupportedPlatform
+}
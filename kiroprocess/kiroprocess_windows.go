@@ -5,107 +5,98 @@ package kiroprocess
 import (
 	"os/exec"
 	"strconv"
-	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
 
 	"kiro-manager/internal/cmdutil"
 )
 
-// getWindowsKiroProcesses 使用 tasklist 命令取得 Kiro 進程列表
-// 使用系統內建工具避免防毒軟體誤報
-func getWindowsKiroProcesses() ([]ProcessInfo, error) {
-	// tasklist /FI "IMAGENAME eq Kiro.exe" /FO CSV /NH
-	// 輸出格式: "Kiro.exe","12345","Console","1","123,456 K"
-	cmd := exec.Command("tasklist", "/FI", "IMAGENAME eq Kiro.exe", "/FO", "CSV", "/NH")
-	cmdutil.HideWindow(cmd)
-	output, err := cmd.Output()
+// listSystemProcesses 使用 Toolhelp 快照取得所有進程
+// 執行檔路徑與命令列需開啟進程查詢，無權限的進程（例如其他使用者或系統服務）只有名稱
+func listSystemProcesses() ([]systemProcess, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
 	if err != nil {
-		// tasklist 在找不到進程時會回傳錯誤，這是正常的
-		// 檢查輸出是否包含 "INFO: No tasks"
-		if strings.Contains(string(output), "INFO:") {
-			return []ProcessInfo{}, nil
-		}
-		// 如果是其他錯誤，嘗試解析輸出
-		if len(output) == 0 {
-			return []ProcessInfo{}, nil
-		}
+		return nil, err
 	}
+	defer windows.CloseHandle(snapshot)
 
-	return parseTasklistOutput(string(output))
-}
-
-// parseTasklistOutput 解析 tasklist CSV 輸出
-func parseTasklistOutput(output string) ([]ProcessInfo, error) {
-	var processes []ProcessInfo
-
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// 跳過 INFO 訊息（例如 "INFO: No tasks are running..."）
-		if strings.HasPrefix(line, "INFO:") {
-			continue
-		}
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := windows.Process32First(snapshot, &entry); err != nil {
+		return nil, err
+	}
 
-		// CSV 格式: "Kiro.exe","12345","Console","1","123,456 K"
-		// 移除引號並分割
-		fields := parseCSVLine(line)
-		if len(fields) < 2 {
-			continue
+	var processes []systemProcess
+	for {
+		p := systemProcess{
+			PID:  int(entry.ProcessID),
+			PPID: int(entry.ParentProcessID),
+			Name: windows.UTF16ToString(entry.ExeFile[:]),
 		}
+		p.Exe, p.Args = queryProcess(entry.ProcessID)
+		processes = append(processes, p)
 
-		name := fields[0]
-		pidStr := fields[1]
-
-		// 確認是 Kiro 進程
-		if !strings.EqualFold(name, "Kiro.exe") {
-			continue
+		if err := windows.Process32Next(snapshot, &entry); err != nil {
+			if err == windows.ERROR_NO_MORE_FILES {
+				break
+			}
+			return nil, err
 		}
+	}
+	return processes, nil
+}
 
-		pid, err := strconv.Atoi(pidStr)
-		if err != nil {
-			continue
-		}
+// queryProcess 取得進程的執行檔完整路徑與命令列參數，失敗時回傳空值
+func queryProcess(pid uint32) (string, []string) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", nil
+	}
+	defer windows.CloseHandle(handle)
 
-		processes = append(processes, ProcessInfo{
-			PID:  pid,
-			Name: name,
-		})
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return "", nil
 	}
+	exe := windows.UTF16ToString(buf[:size])
 
-	return processes, nil
+	commandLine, err := queryCommandLine(handle)
+	if err != nil {
+		return exe, nil
+	}
+	args, err := windows.DecomposeCommandLine(commandLine)
+	if err != nil {
+		return exe, nil
+	}
+	return exe, args
 }
 
-// parseCSVLine 解析 CSV 行，處理引號
-func parseCSVLine(line string) []string {
-	var fields []string
-	var current strings.Builder
-	inQuotes := false
-
-	for _, r := range line {
-		switch r {
-		case '"':
-			inQuotes = !inQuotes
-		case ',':
-			if inQuotes {
-				current.WriteRune(r)
-			} else {
-				fields = append(fields, current.String())
-				current.Reset()
-			}
-		default:
-			current.WriteRune(r)
+// queryCommandLine 以 NtQueryInformationProcess 讀取命令列（Windows 8.1 以上）
+func queryCommandLine(handle windows.Handle) (string, error) {
+	buf := make([]byte, 1024)
+	for {
+		var needed uint32
+		err := windows.NtQueryInformationProcess(handle, windows.ProcessCommandLineInformation,
+			unsafe.Pointer(&buf[0]), uint32(len(buf)), &needed)
+		if err == nil {
+			break
+		}
+		if err != windows.STATUS_INFO_LENGTH_MISMATCH || int(needed) <= len(buf) {
+			return "", err
 		}
+		buf = make([]byte, needed)
 	}
+	return (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0])).String(), nil
+}
 
-	// 加入最後一個欄位
-	if current.Len() > 0 {
-		fields = append(fields, current.String())
+// detachProcess 讓啟動的進程不附屬於本程式的主控台與進程群組
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.DETACHED_PROCESS | windows.CREATE_NEW_PROCESS_GROUP,
 	}
-
-	return fields
 }
 
 // killWindowsProcess 使用 taskkill 命令終止指定 PID 的進程
@@ -116,17 +107,20 @@ func killWindowsProcess(pid int) error {
 	return cmd.Run()
 }
 
-// getDarwinKiroProcesses Windows 平台不支援
-func getDarwinKiroProcesses() ([]ProcessInfo, error) {
-	return nil, ErrUnsupportedPlatform
-}
-
-// getLinuxKiroProcesses Windows 平台不支援
-func getLinuxKiroProcesses() ([]ProcessInfo, error) {
-	return nil, ErrUnsupportedPlatform
+// closeWindowsProcess 使用不加 /F 的 taskkill 送出 WM_CLOSE，讓 Kiro 正常關閉視窗
+// 沒有視窗的子進程會回傳錯誤，它們會隨主進程結束
+func closeWindowsProcess(pid int) error {
+	cmd := exec.Command("taskkill", "/PID", strconv.Itoa(pid))
+	cmdutil.HideWindow(cmd)
+	return cmd.Run()
 }
 
 // killUnixProcess Windows 平台不支援
 func killUnixProcess(pid int) error {
 	return ErrUnsupportedPlatform
 }
+
+// terminateUnixProcess Windows 平台不支援
+func terminateUnixProcess(pid int) error {
+	return ErrUnsupportedPlatform
+}
@@ -0,0 +1,194 @@
+package kiroprocess
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kiro-manager/kiropath"
+	"kiro-manager/sysenv"
+)
+
+// LaunchState 關閉前 Kiro 的啟動方式，用於操作完成後重新啟動
+type LaunchState struct {
+	Exe     string   `json:"exe"`     // 主進程的執行檔
+	Args    []string `json:"args"`    // 主進程的命令列參數（不含執行檔與工作區路徑）
+	Folders []string `json:"folders"` // 開啟中的資料夾或 .code-workspace 檔
+}
+
+// CaptureLaunchState 記錄執行中 Kiro 主進程的命令列與開啟的工作區，Kiro 未執行時回傳 nil
+// 工作區取自命令列上的路徑，以及 Kiro 在 storage.json 記錄的視窗狀態
+func CaptureLaunchState() (*LaunchState, error) {
+	processes, err := listSystemProcesses()
+	if err != nil {
+		return nil, err
+	}
+	installPath, _ := kiropath.GetKiroInstallPath()
+	matched := matchKiroProcesses(processes, installPath, os.Getpid())
+
+	var mainProcess *systemProcess
+	for _, p := range matched {
+		if p.Role != RoleMain {
+			continue
+		}
+		for i := range processes {
+			if processes[i].PID == p.PID {
+				mainProcess = &processes[i]
+				break
+			}
+		}
+		break
+	}
+	if mainProcess == nil {
+		return nil, nil
+	}
+
+	state := &LaunchState{Exe: resolvePath(mainProcess.Exe)}
+	var folders []string
+	if len(mainProcess.Args) > 1 {
+		state.Args, folders = splitLaunchArgs(mainProcess.Args[1:])
+	}
+	if configPath, err := kiropath.GetKiroConfigPath(); err == nil {
+		folders = append(folders, readWindowFolders(filepath.Join(configPath, "User", "globalStorage", "storage.json"))...)
+	}
+	state.Folders = existingPaths(folders)
+	return state, nil
+}
+
+// Relaunch 以記錄的命令列重新啟動 Kiro，並開啟原本的工作區
+// Kiro 與本程式脫離，本程式結束不影響 Kiro
+func Relaunch(state *LaunchState) error {
+	var exe string
+	var args []string
+	if state != nil {
+		exe = state.Exe
+		args = append(append(args, state.Args...), existingPaths(state.Folders)...)
+	}
+	// 執行檔在關閉後被更新或移除時，改用安裝路徑
+	if exe != "" {
+		if _, err := os.Stat(exe); err != nil {
+			exe = ""
+		}
+	}
+
+	name, cmdArgs, err := kiropath.GetKiroLaunchCommand(exe, args)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(name, cmdArgs...)
+	if home, err := sysenv.HomeDir(); err == nil {
+		cmd.Dir = home
+	}
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// 回收子進程（macOS 的 open 會立即結束；其他平台 Kiro 結束時才返回）
+	go cmd.Wait()
+	return nil
+}
+
+// splitLaunchArgs 將主進程的參數分為選項與工作區路徑
+// 緊接在不含 "=" 的選項後的值視為該選項的參數（例如 --user-data-dir <dir>）
+func splitLaunchArgs(args []string) (options, folders []string) {
+	takesValue := false
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			options = append(options, arg)
+			takesValue = !strings.Contains(arg, "=")
+		case takesValue || !filepath.IsAbs(arg):
+			// 相對路徑無法得知 Kiro 啟動時的工作目錄，保留原樣
+			options = append(options, arg)
+			takesValue = false
+		default:
+			folders = append(folders, arg)
+		}
+	}
+	return options, folders
+}
+
+// windowsState storage.json 中記錄開啟視窗的欄位
+type windowsState struct {
+	WindowsState struct {
+		LastActiveWindow *openedWindow  `json:"lastActiveWindow"`
+		OpenedWindows    []openedWindow `json:"openedWindows"`
+	} `json:"windowsState"`
+}
+
+// openedWindow 一個視窗開啟的資料夾或工作區（URI）
+type openedWindow struct {
+	Folder              string `json:"folder"`
+	WorkspaceIdentifier *struct {
+		ConfigURIPath string `json:"configURIPath"`
+	} `json:"workspaceIdentifier"`
+}
+
+// readWindowFolders 讀取 Kiro 記錄的視窗狀態，回傳本機的資料夾與工作區檔路徑
+// 讀取失敗時回傳 nil；遠端工作區（vscode-remote:// 等）會被略過
+func readWindowFolders(storagePath string) []string {
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		return nil
+	}
+	var state windowsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+
+	windows := state.WindowsState.OpenedWindows
+	if last := state.WindowsState.LastActiveWindow; last != nil {
+		windows = append([]openedWindow{*last}, windows...)
+	}
+
+	var folders []string
+	for _, w := range windows {
+		uri := w.Folder
+		if uri == "" && w.WorkspaceIdentifier != nil {
+			uri = w.WorkspaceIdentifier.ConfigURIPath
+		}
+		if path := fileURIToPath(uri); path != "" {
+			folders = append(folders, path)
+		}
+	}
+	return folders
+}
+
+// fileURIToPath 將 file:// URI 轉換為本機路徑，其他 scheme 回傳空字串
+func fileURIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return ""
+	}
+	path := u.Path
+	// Windows: file:///c%3A/Users/... -> c:/Users/...
+	if runtime.GOOS == "windows" && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// existingPaths 去除重複與已不存在的路徑，保留原本順序
+func existingPaths(paths []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		key := filepath.Clean(path)
+		if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+			key = strings.ToLower(key)
+		}
+		if seen[key] {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		seen[key] = true
+		result = append(result, path)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package kiroprocess
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSplitLaunchArgs 測試將主進程參數分為選項與工作區路徑
+func TestSplitLaunchArgs(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "project")
+	dataDir := filepath.Join(t.TempDir(), "data")
+
+	options, folders := splitLaunchArgs([]string{
+		"--no-sandbox", "--user-data-dir", dataDir, "--log=debug", project, "relative",
+	})
+	wantOptions := []string{"--no-sandbox", "--user-data-dir", dataDir, "--log=debug", "relative"}
+	if !reflect.DeepEqual(options, wantOptions) {
+		t.Errorf("options = %q, want %q", options, wantOptions)
+	}
+	if !reflect.DeepEqual(folders, []string{project}) {
+		t.Errorf("folders = %q", folders)
+	}
+}
+
+// TestReadWindowFolders 測試從 storage.json 讀取開啟中的本機工作區
+func TestReadWindowFolders(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "my project")
+	other := filepath.Join(dir, "other")
+	workspace := filepath.Join(dir, "team.code-workspace")
+	for _, d := range []string{project, other} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(workspace, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	toURI := func(path string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	storage := `{
+		"theme": "vs-dark",
+		"windowsState": {
+			"lastActiveWindow": {"folder": "` + toURI(project) + `", "uiState": {}},
+			"openedWindows": [
+				{"folder": "` + toURI(project) + `"},
+				{"folder": "vscode-remote://ssh-remote+host/home/dev"},
+				{"workspaceIdentifier": {"id": "abc", "configURIPath": "` + toURI(workspace) + `"}},
+				{"folder": "` + toURI(other) + `"},
+				{"folder": "` + toURI(filepath.Join(dir, "deleted")) + `"}
+			]
+		}
+	}`
+	storagePath := filepath.Join(dir, "storage.json")
+	if err := os.WriteFile(storagePath, []byte(storage), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := existingPaths(readWindowFolders(storagePath))
+	want := []string{project, workspace, other}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("folders = %q, want %q", got, want)
+	}
+
+	if folders := readWindowFolders(filepath.Join(dir, "missing.json")); folders != nil {
+		t.Errorf("missing storage = %q", folders)
+	}
+}
@@ -0,0 +1,186 @@
+package kiroprocess
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStillRunning Kiro 在等待時間內沒有結束
+var ErrStillRunning = errors.New("kiro is still running")
+
+// DefaultGracePeriod 正常關閉後等待 Kiro 自行結束的預設時間
+const DefaultGracePeriod = 10 * time.Second
+
+// killWait 強制終止後等待進程消失的時間
+const killWait = 3 * time.Second
+
+// shutdownPollInterval 等待期間檢查進程的間隔（測試時縮短）
+var shutdownPollInterval = 500 * time.Millisecond
+
+// ShutdownStage 分段關閉 Kiro 的階段
+type ShutdownStage string
+
+const (
+	ShutdownClosing ShutdownStage = "closing" // 送出正常關閉請求（SIGTERM / WM_CLOSE）
+	ShutdownWaiting ShutdownStage = "waiting" // 等待 Kiro 自行結束（可能正在詢問是否儲存）
+	ShutdownTimeout ShutdownStage = "timeout" // 寬限期已過仍在執行，需使用者確認才會強制關閉
+	ShutdownKilling ShutdownStage = "killing" // 強制終止剩餘進程
+	ShutdownDone    ShutdownStage = "done"    // 所有 Kiro 進程都已結束
+)
+
+// ShutdownProgress 關閉 Kiro 的進度
+type ShutdownProgress struct {
+	Stage       ShutdownStage `json:"stage"`
+	Remaining   int           `json:"remaining"`   // 仍在執行的進程數
+	SecondsLeft int           `json:"secondsLeft"` // 寬限期剩餘秒數（僅 waiting 階段）
+}
+
+// ShutdownOptions 分段關閉的選項
+type ShutdownOptions struct {
+	// GracePeriod 正常關閉後等待的時間，0 表示 DefaultGracePeriod
+	GracePeriod time.Duration
+	// Force 寬限期後直接強制終止；false 時回傳 ErrStillRunning，由呼叫端取得使用者確認後呼叫 ForceKill
+	Force bool
+	// OnProgress 進度回呼，可為 nil
+	OnProgress func(ShutdownProgress)
+}
+
+// processOps 列出與結束進程的實作（測試時替換）
+type processOps struct {
+	list      func() ([]ProcessInfo, error)
+	terminate func(pid int) error
+	kill      func(pid int) error
+}
+
+var ops = processOps{
+	list:      GetKiroProcesses,
+	terminate: terminateProcess,
+	kill:      killProcess,
+}
+
+// Shutdown 分段關閉 Kiro：先對主進程送出正常關閉請求，讓 Kiro 有機會儲存或詢問未儲存的變更，
+// 在寬限期內等待整個進程樹結束；仍未結束時依 opts.Force 強制終止或回傳 ErrStillRunning
+func Shutdown(ctx context.Context, opts ShutdownOptions) error {
+	grace := opts.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	report := progressReporter(opts.OnProgress)
+
+	processes, err := ops.list()
+	if err != nil {
+		return err
+	}
+	if len(processes) == 0 {
+		report(ShutdownProgress{Stage: ShutdownDone})
+		return nil
+	}
+
+	report(ShutdownProgress{Stage: ShutdownClosing, Remaining: len(processes)})
+	for _, pid := range closeTargets(processes) {
+		// 主進程可能已自行結束，個別失敗不影響後續等待
+		_ = ops.terminate(pid)
+	}
+
+	remaining, err := waitForExit(ctx, grace, func(n int, left time.Duration) {
+		report(ShutdownProgress{Stage: ShutdownWaiting, Remaining: n, SecondsLeft: int((left + time.Second - 1) / time.Second)})
+	})
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		report(ShutdownProgress{Stage: ShutdownDone})
+		return nil
+	}
+
+	if !opts.Force {
+		report(ShutdownProgress{Stage: ShutdownTimeout, Remaining: remaining})
+		return ErrStillRunning
+	}
+	return ForceKill(ctx, opts.OnProgress)
+}
+
+// closeTargets 取得要送出正常關閉請求的進程
+// 只送給主進程：視窗、擴充套件主機等子進程由主進程在處理完未儲存的變更後自行關閉，
+// 直接結束子進程會讓主進程來不及詢問。沒有主進程時（例如主進程已結束、只剩殘留的子進程），
+// 改送給進程樹的根節點
+func closeTargets(processes []ProcessInfo) []int {
+	var pids []int
+	for _, p := range processes {
+		if p.Role == RoleMain {
+			pids = append(pids, p.PID)
+		}
+	}
+	if len(pids) > 0 {
+		return pids
+	}
+	for _, root := range BuildProcessTree(processes) {
+		pids = append(pids, root.PID)
+	}
+	return pids
+}
+
+// ForceKill 強制終止所有 Kiro 進程並等待結束，未儲存的變更會遺失
+func ForceKill(ctx context.Context, onProgress func(ShutdownProgress)) error {
+	report := progressReporter(onProgress)
+
+	processes, err := ops.list()
+	if err != nil {
+		return err
+	}
+	if len(processes) > 0 {
+		report(ShutdownProgress{Stage: ShutdownKilling, Remaining: len(processes)})
+		for _, p := range processes {
+			_ = ops.kill(p.PID)
+		}
+		remaining, err := waitForExit(ctx, killWait, nil)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return ErrStillRunning
+		}
+	}
+
+	report(ShutdownProgress{Stage: ShutdownDone})
+	return nil
+}
+
+// waitForExit 等待 Kiro 進程結束，回傳逾時後仍在執行的進程數
+// 每次檢查仍有進程時以剩餘數量與剩餘時間呼叫 onTick（可為 nil）
+func waitForExit(ctx context.Context, timeout time.Duration, onTick func(remaining int, left time.Duration)) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		processes, err := ops.list()
+		if err != nil {
+			return 0, err
+		}
+		left := time.Until(deadline)
+		if len(processes) == 0 || left <= 0 {
+			return len(processes), nil
+		}
+		if onTick != nil {
+			onTick(len(processes), left)
+		}
+
+		wait := shutdownPollInterval
+		if left < wait {
+			wait = left
+		}
+		select {
+		case <-ctx.Done():
+			return len(processes), ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// progressReporter 包裝可為 nil 的進度回呼
+func progressReporter(onProgress func(ShutdownProgress)) func(ShutdownProgress) {
+	return func(p ShutdownProgress) {
+		if onProgress != nil {
+			onProgress(p)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package kiroprocess
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProcesses 模擬的進程表：主進程收到正常關閉請求時連同子進程一起結束，
+// ignoreTerm 中的進程不理會正常關閉請求
+type fakeProcesses struct {
+	mu         sync.Mutex
+	running    map[int]ProcessInfo
+	ignoreTerm map[int]bool
+	terminated []int
+	killed     []int
+}
+
+// kiroTree 建立模擬的 Kiro 進程樹：mainPID 為主進程，其餘為視窗、擴充套件主機與 GPU 子進程
+func kiroTree(mainPID int) []ProcessInfo {
+	return []ProcessInfo{
+		{PID: mainPID, PPID: 1, Name: "kiro", Role: RoleMain},
+		{PID: mainPID + 1, PPID: mainPID, Name: "kiro", Role: RoleRenderer},
+		{PID: mainPID + 2, PPID: mainPID, Name: "kiro", Role: RoleExtensionHost},
+		{PID: mainPID + 3, PPID: mainPID, Name: "kiro", Role: RoleHelper},
+	}
+}
+
+// useFakeProcesses 以模擬的進程表取代系統實作
+func useFakeProcesses(t *testing.T, processes []ProcessInfo, ignoreTerm ...int) *fakeProcesses {
+	t.Helper()
+	f := &fakeProcesses{running: make(map[int]ProcessInfo), ignoreTerm: make(map[int]bool)}
+	for _, p := range processes {
+		f.running[p.PID] = p
+	}
+	for _, pid := range ignoreTerm {
+		f.ignoreTerm[pid] = true
+	}
+
+	saved, savedInterval := ops, shutdownPollInterval
+	ops = processOps{
+		list: func() ([]ProcessInfo, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var list []ProcessInfo
+			for _, p := range f.running {
+				list = append(list, p)
+			}
+			return list, nil
+		},
+		terminate: func(pid int) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.terminated = append(f.terminated, pid)
+			if !f.ignoreTerm[pid] {
+				f.exit(pid)
+			}
+			return nil
+		},
+		kill: func(pid int) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.killed = append(f.killed, pid)
+			delete(f.running, pid)
+			return nil
+		},
+	}
+	shutdownPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { ops, shutdownPollInterval = saved, savedInterval })
+	return f
+}
+
+// exit 結束進程與其所有子進程（呼叫端需持有 f.mu）
+func (f *fakeProcesses) exit(pid int) {
+	delete(f.running, pid)
+	for child, p := range f.running {
+		if p.PPID == pid {
+			f.exit(child)
+		}
+	}
+}
+
+// stages 收集進度回呼的階段
+func stages(progress *[]ShutdownProgress) func(ShutdownProgress) {
+	return func(p ShutdownProgress) { *progress = append(*progress, p) }
+}
+
+// TestShutdown_Graceful 測試 Kiro 接受正常關閉時不會強制終止
+func TestShutdown_Graceful(t *testing.T) {
+	f := useFakeProcesses(t, kiroTree(100))
+
+	var progress []ShutdownProgress
+	err := Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second, OnProgress: stages(&progress)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.terminated, []int{100}) || len(f.killed) != 0 {
+		t.Errorf("terminated = %v, killed = %v", f.terminated, f.killed)
+	}
+	if first, last := progress[0].Stage, progress[len(progress)-1].Stage; first != ShutdownClosing || last != ShutdownDone {
+		t.Errorf("progress = %+v", progress)
+	}
+}
+
+// TestShutdown_TimeoutNeedsConfirmation 測試寬限期後未結束時不強制終止，直到呼叫 ForceKill
+func TestShutdown_TimeoutNeedsConfirmation(t *testing.T) {
+	f := useFakeProcesses(t, kiroTree(100), 100)
+
+	var progress []ShutdownProgress
+	err := Shutdown(context.Background(), ShutdownOptions{GracePeriod: 30 * time.Millisecond, OnProgress: stages(&progress)})
+	if !errors.Is(err, ErrStillRunning) {
+		t.Fatalf("Shutdown = %v, want ErrStillRunning", err)
+	}
+	if len(f.killed) != 0 {
+		t.Errorf("killed without confirmation: %v", f.killed)
+	}
+	last := progress[len(progress)-1]
+	if last.Stage != ShutdownTimeout || last.Remaining != 4 {
+		t.Errorf("last progress = %+v", last)
+	}
+
+	progress = nil
+	if err := ForceKill(context.Background(), stages(&progress)); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.killed) != 4 {
+		t.Errorf("killed = %v", f.killed)
+	}
+	if progress[0].Stage != ShutdownKilling || progress[len(progress)-1].Stage != ShutdownDone {
+		t.Errorf("force progress = %+v", progress)
+	}
+}
+
+// TestShutdown_Force 測試 Force 時寬限期後直接強制終止
+func TestShutdown_Force(t *testing.T) {
+	f := useFakeProcesses(t, kiroTree(100), 100)
+
+	err := Shutdown(context.Background(), ShutdownOptions{GracePeriod: 20 * time.Millisecond, Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.terminated) != 1 || len(f.killed) != 4 {
+		t.Errorf("terminated = %v, killed = %v", f.terminated, f.killed)
+	}
+}
+
+// TestShutdown_NotRunning 測試 Kiro 未執行時直接完成
+func TestShutdown_NotRunning(t *testing.T) {
+	f := useFakeProcesses(t, nil)
+
+	if err := Shutdown(context.Background(), ShutdownOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.terminated) != 0 {
+		t.Errorf("terminated = %v", f.terminated)
+	}
+}
+
+// TestShutdown_OnlyTerminatesMain 測試只對主進程送出正常關閉請求，
+// 子進程不會被直接結束，且等待到整個進程樹都結束
+func TestShutdown_OnlyTerminatesMain(t *testing.T) {
+	processes := append(kiroTree(100), kiroTree(200)...)
+	f := useFakeProcesses(t, processes)
+
+	if err := Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	roles := make(map[int]ProcessRole)
+	for _, p := range processes {
+		roles[p.PID] = p.Role
+	}
+	for _, pid := range f.terminated {
+		if roles[pid] != RoleMain {
+			t.Errorf("terminate sent to %d (%s)", pid, roles[pid])
+		}
+	}
+	if len(f.terminated) != 2 || len(f.running) != 0 {
+		t.Errorf("terminated = %v, running = %v", f.terminated, f.running)
+	}
+}
+
+// TestShutdown_OrphanedChildren 測試主進程已結束、只剩子進程時改對進程樹的根節點送出關閉請求
+func TestShutdown_OrphanedChildren(t *testing.T) {
+	f := useFakeProcesses(t, kiroTree(100)[1:])
+
+	if err := Shutdown(context.Background(), ShutdownOptions{GracePeriod: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.terminated) != 3 || len(f.killed) != 0 {
+		t.Errorf("terminated = %v, killed = %v", f.terminated, f.killed)
+	}
+}
@@ -18,25 +18,30 @@ var (
 // GetKiroVersion 取得 Kiro IDE 的版本號
 // 從 Kiro 執行檔的 metadata 讀取實際版本
 func GetKiroVersion() (string, error) {
+	installPath, err := kiropath.GetKiroInstallPath()
+	if err != nil {
+		return "", err
+	}
+	return GetKiroVersionAt(installPath)
+}
+
+// GetKiroVersionAt 取得指定安裝路徑的 Kiro 版本號
+// 用於同時安裝多個 Kiro（例如穩定版與預覽版）時分別顯示各自的版本
+func GetKiroVersionAt(installPath string) (string, error) {
 	switch runtime.GOOS {
 	case "windows":
-		return getWindowsKiroVersion()
+		return getWindowsKiroVersion(installPath)
 	case "darwin":
-		return getDarwinKiroVersion()
+		return getDarwinKiroVersion(installPath)
 	case "linux":
-		return getLinuxKiroVersion()
+		return getLinuxKiroVersion(installPath)
 	default:
 		return "", ErrVersionNotFound
 	}
 }
 
 // getWindowsKiroVersion 使用 PowerShell 讀取 exe 的 FileVersion
-func getWindowsKiroVersion() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		return "", err
-	}
-
+func getWindowsKiroVersion(installPath string) (string, error) {
 	exePath := filepath.Join(installPath, "Kiro.exe")
 
 	// 使用 PowerShell 讀取版本資訊
@@ -58,14 +63,8 @@ func getWindowsKiroVersion() (string, error) {
 	return version, nil
 }
 
-
 // getDarwinKiroVersion 讀取 Kiro.app 的 Info.plist 取得版本
-func getDarwinKiroVersion() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		return "", err
-	}
-
+func getDarwinKiroVersion(installPath string) (string, error) {
 	// Info.plist 位於 Kiro.app/Contents/Info.plist
 	plistPath := filepath.Join(installPath, "Contents", "Info.plist")
 
@@ -89,24 +88,9 @@ func getDarwinKiroVersion() (string, error) {
 	return version, nil
 }
 
-// getLinuxKiroVersion 嘗試從常見位置讀取版本資訊
-func getLinuxKiroVersion() (string, error) {
-	installPath, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		return "", err
-	}
-
-	// 嘗試讀取 package.json 或 version 檔案
-	// Electron 應用通常會有 resources/app/package.json
-	packageJsonPath := filepath.Join(installPath, "resources", "app", "package.json")
-
-	cmd := exec.Command("grep", "-oP", `"version"\s*:\s*"\K[^"]+`, packageJsonPath)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", ErrVersionNotFound
-	}
-
-	version := strings.TrimSpace(string(output))
+// getLinuxKiroVersion 讀取安裝目錄的 resources/app/package.json（AppImage 從檔名取得）
+func getLinuxKiroVersion(installPath string) (string, error) {
+	version := kiropath.ReadInstallVersion(installPath)
 	if version == "" {
 		return "", ErrVersionNotFound
 	}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// 日誌檔名稱（與 settings.json 同層）
+	LogFileName = "kiro-manager.log"
+	// 單一日誌檔大小上限，超過時輪替
+	maxLogFileSize = 2 << 20
+	// 保留的舊日誌檔數量（kiro-manager.log.1 ~ .3）
+	maxLogBackups = 3
+)
+
+var (
+	level = new(slog.LevelVar)
+
+	mu      sync.Mutex
+	logger  = newLogger(os.Stderr)
+	logFile *rotatingFile
+)
+
+func init() {
+	// 尚未初始化日誌檔前，只將警告以上的訊息輸出到 stderr
+	level.Set(slog.LevelWarn)
+}
+
+// newLogger 建立自動遮蔽敏感資料的 logger
+func newLogger(w io.Writer) *slog.Logger {
+	handler := slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+	return slog.New(&redactHandler{next: handler})
+}
+
+// Init 開啟 dir 下的輪替日誌檔並設為所有套件共用的 logger
+func Init(dir string, lvl slog.Level) error {
+	f, err := openRotatingFile(filepath.Join(dir, LogFileName), maxLogFileSize, maxLogBackups)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	old := logFile
+	logFile = f
+	logger = newLogger(f)
+	slog.SetDefault(logger)
+	mu.Unlock()
+
+	level.Set(lvl)
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Close 關閉日誌檔，之後的訊息（包含 slog 預設 logger）改輸出到 stderr
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if logFile == nil {
+		return nil
+	}
+	err := logFile.Close()
+	logFile = nil
+	logger = newLogger(os.Stderr)
+	slog.SetDefault(logger)
+	return err
+}
+
+// SetLevel 調整日誌等級
+func SetLevel(lvl slog.Level) {
+	level.Set(lvl)
+}
+
+// ParseLevel 解析設定中的日誌等級字串（debug / info / warn / error），無法解析時返回 Info
+func ParseLevel(s string) slog.Level {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo
+	}
+	return lvl
+}
+
+// Logger 取得共用的 logger
+func Logger() *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return logger
+}
+
+// LogFilePath 取得目前日誌檔路徑，尚未初始化時返回空字串
+func LogFilePath() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if logFile == nil {
+		return ""
+	}
+	return logFile.path
+}
+
+// Debug 記錄除錯訊息
+func Debug(msg string, args ...any) {
+	Logger().Log(context.Background(), slog.LevelDebug, msg, args...)
+}
+
+// Info 記錄一般訊息
+func Info(msg string, args ...any) {
+	Logger().Log(context.Background(), slog.LevelInfo, msg, args...)
+}
+
+// Warn 記錄警告訊息
+func Warn(msg string, args ...any) {
+	Logger().Log(context.Background(), slog.LevelWarn, msg, args...)
+}
+
+// Error 記錄錯誤訊息
+func Error(msg string, args ...any) {
+	Logger().Log(context.Background(), slog.LevelError, msg, args...)
+}
+
+// Export 依時間順序（最舊到最新）將所有日誌檔內容寫入 w
+func Export(w io.Writer) error {
+	mu.Lock()
+	f := logFile
+	mu.Unlock()
+	if f == nil {
+		return os.ErrNotExist
+	}
+	return f.export(w)
+}
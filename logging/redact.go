@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redactedValue 取代敏感資料的字串
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys 值一律遮蔽的屬性名稱（小寫、去除 _ 與 -）
+var sensitiveKeys = map[string]bool{
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"idtoken":       true,
+	"clientsecret":  true,
+	"authorization": true,
+	"passphrase":    true,
+	"password":      true,
+}
+
+// secretPatterns 字串中需要遮蔽的敏感資料，第一個群組為保留的前綴
+var secretPatterns = []*regexp.Regexp{
+	// JSON / 結構體 / 查詢字串中的鍵值，例如 "accessToken":"…"、RefreshToken:…、client_secret=…
+	regexp.MustCompile(`(?i)((?:access_?token|refresh_?token|id_?token|client_?secret|passphrase|password)["']?\s*[:=]\s*["']?)[^"'\s,}&]+`),
+	// HTTP Authorization header
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=:-]+`),
+	// Kiro 的 access token（aoa…）與 refresh token（aor…）
+	regexp.MustCompile(`()\bao[ar][A-Za-z0-9]{16,}[A-Za-z0-9._~+/=:-]*`),
+}
+
+// Redact 遮蔽字串中的 Token 與 client secret
+func Redact(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, "${1}"+redactedValue)
+	}
+	return s
+}
+
+// isSensitiveKey 判斷屬性名稱是否為敏感欄位
+func isSensitiveKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return sensitiveKeys[normalized]
+}
+
+// redactHandler 在輸出前遮蔽訊息與屬性中的敏感資料
+type redactHandler struct {
+	next slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr 遮蔽單一屬性，群組會遞迴處理
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redactedValue)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		// error 與結構體轉為字串後再遮蔽，避免欄位中的 Token 原樣輸出
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+		return slog.String(a.Key, Redact(fmt.Sprintf("%+v", a.Value.Any())))
+	}
+	return a
+}
@@ -3,8 +3,8 @@ package machineid
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"errors"
-	"runtime"
+
+	"kiro-manager/sysenv"
 )
 
 // GetMachineId 取得系統的 Machine ID，經過 SHA-256 雜湊後回傳
@@ -18,16 +18,7 @@ func GetMachineId() (string, error) {
 
 // GetRawMachineId 取得系統的原始 Machine ID（未雜湊）
 func GetRawMachineId() (string, error) {
-	switch runtime.GOOS {
-	case "windows":
-		return getWindowsMachineId()
-	case "darwin":
-		return getDarwinMachineId()
-	case "linux":
-		return getLinuxMachineId()
-	default:
-		return "", errors.New("unsupported platform: " + runtime.GOOS)
-	}
+	return sysenv.MachineID()
 }
 
 func hashSHA256(data string) string {
@@ -1,7 +1,12 @@
+//go:build !cli
+
 package main
 
 import (
 	"embed"
+	"os"
+
+	"kiro-manager/logging"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +18,10 @@ import (
 var assets embed.FS
 
 func main() {
+	initDataDir(os.Args[1:])
+	initNetwork()
+	defer logging.Close()
+
 	app := NewApp()
 
 	err := wails.Run(&options.App{
@@ -26,6 +35,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 9, G: 9, B: 11, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"kiro-manager/backup"
+)
+
+const (
+	// DefaultDays 預設的統計天數
+	DefaultDays = 30
+	// minBurnSpan 計算消耗速率所需的最短觀察時間，過短的區間無法反映實際用量
+	minBurnSpan = time.Hour
+	// dateLayout 日期格式
+	dateLayout = "2006-01-02"
+)
+
+// DailyUsage 單日用量
+type DailyUsage struct {
+	Date       string  `json:"date"`       // 日期（YYYY-MM-DD，本地時間）
+	Consumed   float64 `json:"consumed"`   // 當日消耗
+	Balance    float64 `json:"balance"`    // 當日最後一筆紀錄的餘額
+	UsageLimit float64 `json:"usageLimit"` // 當日最後一筆紀錄的總額度
+	Samples    int     `json:"samples"`    // 當日紀錄筆數
+}
+
+// Trend 單一備份在統計區間內的用量趨勢
+type Trend struct {
+	Name              string       `json:"name"`
+	SubscriptionTitle string       `json:"subscriptionTitle"`
+	Days              int          `json:"days"`              // 統計天數
+	Samples           int          `json:"samples"`           // 區間內紀錄筆數
+	From              string       `json:"from"`              // 第一筆紀錄時間（RFC3339）
+	To                string       `json:"to"`                // 最後一筆紀錄時間（RFC3339）
+	Balance           float64      `json:"balance"`           // 最新餘額
+	UsageLimit        float64      `json:"usageLimit"`        // 最新總額度
+	Consumed          float64      `json:"consumed"`          // 區間內總消耗
+	BurnRatePerDay    float64      `json:"burnRatePerDay"`    // 平均每日消耗（資料不足時為 0）
+	DaysRemaining     float64      `json:"daysRemaining"`     // 以目前速率估計的剩餘天數（無法估計時為 -1）
+	ProjectedZeroDate string       `json:"projectedZeroDate"` // 預估餘額歸零日期（無法估計時為空字串）
+	Daily             []DailyUsage `json:"daily"`
+}
+
+// Analyze 分析備份的用量歷史
+// 僅統計 now 之前 days 天內的紀錄；區間前的最後一筆作為基準，讓第一天的消耗也能計算。
+// 用量下降（例如每月額度重置）時，以新的已使用量作為重置後的消耗。
+func Analyze(name string, entries []backup.UsageHistoryEntry, now time.Time, days int) Trend {
+	if days <= 0 {
+		days = DefaultDays
+	}
+	trend := Trend{Name: name, Days: days, DaysRemaining: -1, Daily: []DailyUsage{}}
+
+	since := now.AddDate(0, 0, -days)
+	var baseline *backup.UsageHistoryEntry
+	var window []backup.UsageHistoryEntry
+	for i := range entries {
+		switch {
+		case entries[i].Time.Before(since):
+			baseline = &entries[i]
+		case !entries[i].Time.After(now):
+			window = append(window, entries[i])
+		}
+	}
+	if len(window) == 0 {
+		return trend
+	}
+
+	first, last := window[0], window[len(window)-1]
+	trend.Samples = len(window)
+	trend.SubscriptionTitle = last.SubscriptionTitle
+	trend.From = first.Time.Format(time.RFC3339)
+	trend.To = last.Time.Format(time.RFC3339)
+	trend.Balance = last.Balance
+	trend.UsageLimit = last.UsageLimit
+
+	prev := baseline
+	start := first.Time
+	if baseline != nil {
+		start = baseline.Time
+	}
+	dayIndex := map[string]int{}
+	for i := range window {
+		e := window[i]
+		consumed := 0.0
+		if prev != nil {
+			consumed = consumedBetween(*prev, e)
+		}
+		prev = &window[i]
+		trend.Consumed += consumed
+
+		date := e.Time.In(now.Location()).Format(dateLayout)
+		idx, ok := dayIndex[date]
+		if !ok {
+			idx = len(trend.Daily)
+			dayIndex[date] = idx
+			trend.Daily = append(trend.Daily, DailyUsage{Date: date})
+		}
+		day := &trend.Daily[idx]
+		day.Consumed += consumed
+		day.Balance = e.Balance
+		day.UsageLimit = e.UsageLimit
+		day.Samples++
+	}
+
+	span := last.Time.Sub(start)
+	if span < minBurnSpan || trend.Consumed <= 0 {
+		return trend
+	}
+	trend.BurnRatePerDay = trend.Consumed / (span.Hours() / 24)
+
+	remaining := trend.Balance / trend.BurnRatePerDay
+	if remaining < 0 {
+		remaining = 0
+	}
+	trend.DaysRemaining = remaining
+	zero := last.Time.Add(time.Duration(remaining * float64(24*time.Hour)))
+	trend.ProjectedZeroDate = zero.In(now.Location()).Format(dateLayout)
+	return trend
+}
+
+// consumedBetween 計算兩筆紀錄之間的消耗
+func consumedBetween(prev, cur backup.UsageHistoryEntry) float64 {
+	delta := cur.CurrentUsage - prev.CurrentUsage
+	if delta < 0 {
+		// 已使用量下降代表額度已重置，重置後的已使用量即為消耗
+		return cur.CurrentUsage
+	}
+	return delta
+}
+
+// csvHeader CSV 欄位
+var csvHeader = []string{"backup", "subscription", "date", "consumed", "balance", "usage_limit", "samples"}
+
+// WriteCSV 將每個備份的每日用量輸出為 CSV
+func WriteCSV(w io.Writer, trends []Trend) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, t := range trends {
+		for _, d := range t.Daily {
+			record := []string{
+				t.Name,
+				t.SubscriptionTitle,
+				d.Date,
+				formatFloat(d.Consumed),
+				formatFloat(d.Balance),
+				formatFloat(d.UsageLimit),
+				strconv.Itoa(d.Samples),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatFloat 以最短的十進位表示輸出（四捨五入至兩位小數）
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"kiro-manager/backup"
+)
+
+// entry 建立測試用的歷史紀錄
+func entry(t time.Time, used float64) backup.UsageHistoryEntry {
+	return backup.UsageHistoryEntry{
+		Time:              t,
+		SubscriptionTitle: "KIRO PRO",
+		UsageLimit:        100,
+		CurrentUsage:      used,
+		Balance:           100 - used,
+	}
+}
+
+// TestAnalyze 測試每日消耗、額度重置、消耗速率與歸零日期
+func TestAnalyze(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2025, 12, d, h, 0, 0, 0, time.UTC) }
+	now := day(5, 0)
+
+	entries := []backup.UsageHistoryEntry{
+		entry(day(1, 0), 50), // 區間外，作為基準
+		entry(day(2, 0), 55),
+		entry(day(2, 12), 60),
+		entry(day(3, 0), 2), // 額度重置
+		entry(day(4, 0), 10),
+		entry(day(6, 0), 99), // 晚於 now，不列入
+	}
+
+	trend := Analyze("work", entries, now, 3)
+
+	if trend.Samples != 4 {
+		t.Errorf("Samples = %d, want 4", trend.Samples)
+	}
+	wantDaily := map[string]float64{"2025-12-02": 10, "2025-12-03": 2, "2025-12-04": 8}
+	if len(trend.Daily) != len(wantDaily) {
+		t.Fatalf("Daily = %+v, want %d days", trend.Daily, len(wantDaily))
+	}
+	for _, d := range trend.Daily {
+		if d.Consumed != wantDaily[d.Date] {
+			t.Errorf("%s consumed = %v, want %v", d.Date, d.Consumed, wantDaily[d.Date])
+		}
+	}
+	if trend.Daily[0].Samples != 2 || trend.Daily[0].Balance != 40 {
+		t.Errorf("unexpected first day: %+v", trend.Daily[0])
+	}
+
+	// 3 天（基準 12/1 至 12/4）共消耗 20
+	if trend.Consumed != 20 {
+		t.Errorf("Consumed = %v, want 20", trend.Consumed)
+	}
+	if got := trend.BurnRatePerDay; got < 6.66 || got > 6.67 {
+		t.Errorf("BurnRatePerDay = %v, want ~6.67", got)
+	}
+	// 餘額 90 / 每日 6.67 = 13.5 天
+	if trend.DaysRemaining != 13.5 || trend.ProjectedZeroDate != "2025-12-17" {
+		t.Errorf("DaysRemaining = %v, ProjectedZeroDate = %q", trend.DaysRemaining, trend.ProjectedZeroDate)
+	}
+}
+
+// TestAnalyze_InsufficientData 測試資料不足時不估計歸零日期
+func TestAnalyze_InsufficientData(t *testing.T) {
+	now := time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC)
+
+	for name, entries := range map[string][]backup.UsageHistoryEntry{
+		"empty":       nil,
+		"single":      {entry(now.Add(-time.Hour), 10)},
+		"short span":  {entry(now.Add(-30*time.Minute), 10), entry(now, 12)},
+		"no consumed": {entry(now.Add(-48*time.Hour), 10), entry(now, 10)},
+	} {
+		trend := Analyze("work", entries, now, 0)
+		if trend.Days != DefaultDays {
+			t.Errorf("%s: Days = %d, want %d", name, trend.Days, DefaultDays)
+		}
+		if trend.BurnRatePerDay != 0 || trend.DaysRemaining != -1 || trend.ProjectedZeroDate != "" {
+			t.Errorf("%s: expected no projection, got %+v", name, trend)
+		}
+	}
+}
+
+// TestWriteCSV 測試 CSV 輸出
+func TestWriteCSV(t *testing.T) {
+	trends := []Trend{{
+		Name:              "work",
+		SubscriptionTitle: "KIRO PRO",
+		Daily: []DailyUsage{
+			{Date: "2025-12-02", Consumed: 10.125, Balance: 40, UsageLimit: 100, Samples: 2},
+		},
+	}, {
+		Name: "empty",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, trends); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "backup,subscription,date,consumed,balance,usage_limit,samples\n" +
+		"work,KIRO PRO,2025-12-02,10.13,40,100,2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(buf.String(), "empty") {
+		t.Error("backup without history should not produce rows")
+	}
+}
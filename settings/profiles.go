@@ -0,0 +1,141 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	ErrKiroProfileNotFound     = errors.New("kiro profile not found")
+	ErrKiroProfileExists       = errors.New("kiro profile already exists")
+	ErrKiroProfileNameRequired = errors.New("kiro profile name is required")
+	ErrInstallPathNotFound     = errors.New("kiro install path does not exist")
+)
+
+// FindKiroProfile 依名稱（不分大小寫）尋找安裝設定檔，找不到或 name 為空時返回 nil
+func (s *Settings) FindKiroProfile(name string) *KiroProfile {
+	if name == "" {
+		return nil
+	}
+	for i := range s.KiroProfiles {
+		if strings.EqualFold(s.KiroProfiles[i].Name, name) {
+			return &s.KiroProfiles[i]
+		}
+	}
+	return nil
+}
+
+// GetActiveKiroProfile 取得目前選擇的安裝設定檔，未選擇時返回 nil
+func GetActiveKiroProfile() *KiroProfile {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return nil
+	}
+	profile := settings.FindKiroProfile(settings.ActiveKiroProfile)
+	if profile == nil {
+		return nil
+	}
+	copied := *profile
+	return &copied
+}
+
+// SaveKiroProfile 新增或更新安裝設定檔並寫入設定檔
+// originalName 為空字串時新增，否則更新該名稱的設定檔（可同時更名，使用中的設定檔更名後維持選擇）
+func SaveKiroProfile(originalName string, profile KiroProfile) error {
+	profile = trimKiroProfile(profile)
+	if profile.Name == "" {
+		return ErrKiroProfileNameRequired
+	}
+	if _, err := os.Stat(profile.InstallPath); profile.InstallPath == "" || err != nil {
+		return ErrInstallPathNotFound
+	}
+
+	s := *GetCurrentSettings()
+	if existing := s.FindKiroProfile(profile.Name); existing != nil && !strings.EqualFold(existing.Name, originalName) {
+		return ErrKiroProfileExists
+	}
+
+	// 建立新的 slice，避免修改到目前生效的設定
+	profiles := make([]KiroProfile, 0, len(s.KiroProfiles)+1)
+	replaced := false
+	for _, p := range s.KiroProfiles {
+		if originalName != "" && strings.EqualFold(p.Name, originalName) {
+			profiles = append(profiles, profile)
+			replaced = true
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	if originalName != "" && !replaced {
+		return ErrKiroProfileNotFound
+	}
+	if !replaced {
+		profiles = append(profiles, profile)
+	}
+	s.KiroProfiles = profiles
+	if originalName != "" && strings.EqualFold(s.ActiveKiroProfile, originalName) {
+		s.ActiveKiroProfile = profile.Name
+	}
+	return SaveSettings(&s)
+}
+
+// DeleteKiroProfile 刪除安裝設定檔，刪除使用中的設定檔時改回預設安裝
+func DeleteKiroProfile(name string) error {
+	s := *GetCurrentSettings()
+	profile := s.FindKiroProfile(name)
+	if profile == nil {
+		return ErrKiroProfileNotFound
+	}
+	name = profile.Name
+
+	profiles := make([]KiroProfile, 0, len(s.KiroProfiles))
+	for _, p := range s.KiroProfiles {
+		if p.Name != name {
+			profiles = append(profiles, p)
+		}
+	}
+	s.KiroProfiles = profiles
+	if strings.EqualFold(s.ActiveKiroProfile, name) {
+		s.ActiveKiroProfile = ""
+	}
+	return SaveSettings(&s)
+}
+
+// SetActiveKiroProfile 選擇之後所有操作使用的安裝，name 為空字串時改回預設安裝
+func SetActiveKiroProfile(name string) error {
+	s := *GetCurrentSettings()
+	if name != "" {
+		profile := s.FindKiroProfile(name)
+		if profile == nil {
+			return ErrKiroProfileNotFound
+		}
+		name = profile.Name
+	}
+	s.ActiveKiroProfile = name
+	return SaveSettings(&s)
+}
+
+// trimKiroProfile 去除設定檔欄位前後的空白
+func trimKiroProfile(p KiroProfile) KiroProfile {
+	p.Name = strings.TrimSpace(p.Name)
+	p.InstallPath = strings.TrimSpace(p.InstallPath)
+	p.KiroVersion = strings.TrimSpace(p.KiroVersion)
+	return p
+}
+
+// validateKiroProfiles 去除名稱或路徑為空的設定檔，名稱重複（不分大小寫）時保留第一個
+func validateKiroProfiles(profiles []KiroProfile) []KiroProfile {
+	var result []KiroProfile
+	seen := make(map[string]bool)
+	for _, p := range profiles {
+		p = trimKiroProfile(p)
+		key := strings.ToLower(p.Name)
+		if p.Name == "" || p.InstallPath == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, p)
+	}
+	return result
+}
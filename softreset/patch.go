@@ -130,7 +130,6 @@ const patchCode = `/* KIRO_MANAGER_PATCH_V3 */
 /* END_KIRO_MANAGER_PATCH */
 `
 
-
 // GetExtensionJSPath 取得 extension.js 的路徑
 func GetExtensionJSPath() (string, error) {
 	installPath, err := kiropath.GetKiroInstallPath()
@@ -11,11 +11,14 @@ import (
 	"kiro-manager/awssso"
 	"kiro-manager/kiropath"
 	"kiro-manager/machineid"
+	"kiro-manager/settings"
+	"kiro-manager/sysenv"
 )
 
 const (
 	CustomMachineIDFileName    = "custom-machine-id"     // SHA256 雜湊後的值（給 Kiro 使用）
 	CustomMachineIDRawFileName = "custom-machine-id-raw" // 原始 UUID（給 UI 顯示）
+	TrashDirName               = "trash"                 // 資料目錄下的資源回收區
 )
 
 var (
@@ -29,6 +32,7 @@ type SoftResetResult struct {
 	NewMachineID string `json:"newMachineId"`
 	Patched      bool   `json:"patched"`
 	CacheCleared bool   `json:"cacheCleared"`
+	TrashDir     string `json:"trashDir,omitempty"` // 清除的 SSO cache 檔案移至的資源回收區
 }
 
 // SoftResetStatus 軟重置狀態
@@ -167,18 +171,27 @@ func ClearCustomMachineID() error {
 	return nil
 }
 
-// ClearSSOCache 刪除 SSO cache（複用 reset 模組的邏輯）
-func ClearSSOCache() error {
-	cachePath, err := awssso.GetSSOCachePath()
-	if err != nil {
-		return err
+// ClearSSOCache 清除 SSO cache 中屬於 Kiro 的檔案（token 與 client 註冊檔）
+// AWS CLI / SDK 等其他工具的 session 不受影響；啟用資源回收區時檔案會移至資料目錄下的 trash
+// 回傳檔案移至的資源回收區路徑，直接刪除時為空字串
+func ClearSSOCache() (string, error) {
+	var trashDir string
+	if settings.GetCurrentSettings().TrashSSOCache {
+		dataDir, err := sysenv.DataDir()
+		if err != nil {
+			return "", err
+		}
+		trashDir = filepath.Join(dataDir, TrashDirName, "sso-cache-"+sysenv.Now().Format("20060102-150405"))
 	}
 
-	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
-		return nil
+	removed, err := awssso.ClearKiroCacheFiles(trashDir)
+	if err != nil {
+		return "", err
 	}
-
-	return os.RemoveAll(cachePath)
+	if len(removed) == 0 {
+		trashDir = ""
+	}
+	return trashDir, nil
 }
 
 // SoftResetEnvironment 執行軟一鍵新機
@@ -223,11 +236,13 @@ func SoftResetEnvironment() (*SoftResetResult, error) {
 		result.Patched = true // 已經 patch 過
 	}
 
-	// 5. 清除 SSO cache
-	if err := ClearSSOCache(); err != nil {
+	// 5. 清除 SSO cache 中屬於 Kiro 的檔案
+	trashDir, err := ClearSSOCache()
+	if err != nil {
 		return result, err
 	}
 	result.CacheCleared = true
+	result.TrashDir = trashDir
 
 	return result, nil
 }
@@ -1,6 +1,6 @@
 //go:build windows
 
-package machineid
+package sysenv
 
 import (
 	"errors"
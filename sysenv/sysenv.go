@@ -0,0 +1,145 @@
+package sysenv
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"kiro-manager/datadir"
+)
+
+// Env 系統環境抽象：使用者家目錄、資料目錄、Machine ID 來源與時鐘
+// 各模組透過此介面取得系統位置，測試時可替換為指向暫存目錄的實作
+type Env interface {
+	// HomeDir 使用者家目錄（~/.aws、~/.kiro 的所在位置）
+	HomeDir() (string, error)
+	// DataDir Kiro Manager 的資料目錄（設定檔與備份）
+	DataDir() (string, error)
+	// MachineID 系統原始 Machine ID（未雜湊）
+	MachineID() (string, error)
+	// Now 目前時間
+	Now() time.Time
+}
+
+// OS 使用真實系統的預設實作
+type OS struct{}
+
+// HomeDir 回傳 os.UserHomeDir
+func (OS) HomeDir() (string, error) {
+	return os.UserHomeDir()
+}
+
+// DataDir 回傳 datadir 解析出的資料目錄
+func (OS) DataDir() (string, error) {
+	return datadir.Dir()
+}
+
+// MachineID 從系統讀取原始 Machine ID
+func (OS) MachineID() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return getWindowsMachineId()
+	case "darwin":
+		return getDarwinMachineId()
+	case "linux":
+		return getLinuxMachineId()
+	default:
+		return "", errors.New("unsupported platform: " + runtime.GOOS)
+	}
+}
+
+// Now 回傳 time.Now
+func (OS) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed 以固定值回應的實作，用於測試或沙盒環境
+// Time 為零值時使用目前時間
+type Fixed struct {
+	Home         string
+	Data         string
+	RawMachineID string
+	Time         time.Time
+}
+
+// ErrNotConfigured Fixed 未設定對應欄位
+var ErrNotConfigured = errors.New("sysenv: value not configured")
+
+// HomeDir 回傳 Home
+func (f Fixed) HomeDir() (string, error) {
+	if f.Home == "" {
+		return "", ErrNotConfigured
+	}
+	return f.Home, nil
+}
+
+// DataDir 回傳 Data
+func (f Fixed) DataDir() (string, error) {
+	if f.Data == "" {
+		return "", ErrNotConfigured
+	}
+	return f.Data, nil
+}
+
+// MachineID 回傳 RawMachineID
+func (f Fixed) MachineID() (string, error) {
+	if f.RawMachineID == "" {
+		return "", ErrNotConfigured
+	}
+	return f.RawMachineID, nil
+}
+
+// Now 回傳 Time，未設定時回傳目前時間
+func (f Fixed) Now() time.Time {
+	if f.Time.IsZero() {
+		return time.Now()
+	}
+	return f.Time
+}
+
+var (
+	current Env = OS{}
+	mu      sync.RWMutex
+)
+
+// Current 取得目前使用的環境
+func Current() Env {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set 替換目前使用的環境，回傳還原函式
+func Set(env Env) (restore func()) {
+	mu.Lock()
+	prev := current
+	current = env
+	mu.Unlock()
+	return func() {
+		mu.Lock()
+		current = prev
+		mu.Unlock()
+	}
+}
+
+// HomeDir 取得目前環境的使用者家目錄
+func HomeDir() (string, error) {
+	return Current().HomeDir()
+}
+
+// DataDir 取得目前環境的資料目錄
+func DataDir() (string, error) {
+	return Current().DataDir()
+}
+
+// MachineID 取得目前環境的原始 Machine ID
+func MachineID() (string, error) {
+	return Current().MachineID()
+}
+
+// Now 取得目前環境的時間
+func Now() time.Time {
+	return Current().Now()
+}
@@ -0,0 +1,48 @@
+package sysenv
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSet_Restore 測試替換環境後可還原為先前的實作
+func TestSet_Restore(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore := Set(Fixed{Home: "/home/test", Data: "/data", RawMachineID: "mid", Time: now})
+
+	if home, err := HomeDir(); err != nil || home != "/home/test" {
+		t.Errorf("HomeDir = %q, %v", home, err)
+	}
+	if dir, err := DataDir(); err != nil || dir != "/data" {
+		t.Errorf("DataDir = %q, %v", dir, err)
+	}
+	if id, err := MachineID(); err != nil || id != "mid" {
+		t.Errorf("MachineID = %q, %v", id, err)
+	}
+	if !Now().Equal(now) {
+		t.Errorf("Now = %v", Now())
+	}
+
+	restore()
+	if _, ok := Current().(OS); !ok {
+		t.Errorf("Current after restore = %T", Current())
+	}
+}
+
+// TestFixed_Unset 測試 Fixed 未設定的欄位回傳 ErrNotConfigured，時間退回目前時間
+func TestFixed_Unset(t *testing.T) {
+	var f Fixed
+	if _, err := f.HomeDir(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("HomeDir err = %v", err)
+	}
+	if _, err := f.DataDir(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("DataDir err = %v", err)
+	}
+	if _, err := f.MachineID(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("MachineID err = %v", err)
+	}
+	if time.Since(f.Now()) > time.Minute {
+		t.Errorf("Now = %v", f.Now())
+	}
+}
@@ -0,0 +1,28 @@
+package sysenv
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// TestMachineID RunIsolated 使用的固定 Machine ID
+const TestMachineID = "test-machine-id"
+
+// RunIsolated 供各套件的 TestMain 使用：將家目錄與資料目錄指向暫存目錄並固定 Machine ID，
+// 執行測試後清除暫存目錄並回傳結束碼，測試不會讀寫使用者真實的目錄或系統 Machine ID
+// 參數為 *testing.M，以介面接收避免正式程式碼依賴 testing 套件
+func RunIsolated(m interface{ Run() int }) int {
+	dir, err := os.MkdirTemp("", "kiro-manager-test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	restore := Set(Fixed{
+		Home:         filepath.Join(dir, "home"),
+		Data:         filepath.Join(dir, "data"),
+		RawMachineID: TestMachineID,
+	})
+	defer restore()
+	return m.Run()
+}
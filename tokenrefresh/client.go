@@ -0,0 +1,45 @@
+package tokenrefresh
+
+import (
+	"context"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
+)
+
+// 預設的 HTTP 請求超時
+const defaultTimeout = 30 * time.Second
+
+// Client Token 刷新客戶端
+// Social 與 IdC 使用不同端點；新的 ExpiresAt 依 Options.Now 計算，測試可固定時鐘
+type Client struct {
+	// SocialRefreshURL Social 刷新端點
+	SocialRefreshURL string
+	// IdCRefreshURL IdC 刷新端點
+	IdCRefreshURL string
+	httpclient.Options
+}
+
+// DefaultClient 套件函數使用的預設客戶端
+var DefaultClient = NewClient()
+
+// NewClient 建立使用正式端點與全域連線設定的客戶端
+func NewClient() *Client {
+	return &Client{
+		SocialRefreshURL: SocialRefreshURL,
+		IdCRefreshURL:    IdCRefreshURL,
+		Options:          httpclient.DefaultOptions(),
+	}
+}
+
+// expiresAt 以客戶端時鐘計算過期時間
+func (c *Client) expiresAt(expiresIn int) time.Time {
+	return c.CurrentTime().Add(time.Duration(expiresIn) * time.Second)
+}
+
+// RefreshAccessToken 根據 token 的認證類型刷新 AccessToken
+// IdC 認證會從 SSO cache 讀取 clientId 和 clientSecret
+func (c *Client) RefreshAccessToken(ctx context.Context, token *awssso.KiroAuthToken, machineId string) (*TokenInfo, error) {
+	return c.RefreshAccessTokenWithCredentials(ctx, token, machineId, "", "")
+}
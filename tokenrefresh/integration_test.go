@@ -1,13 +1,18 @@
 package tokenrefresh
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
+	"kiro-manager/usage"
 )
 
 // 整合測試：端到端刷新流程
@@ -18,7 +23,7 @@ import (
 // THE Token_Refresh_Module SHALL attempt to obtain a new AccessToken using the stored RefreshToken
 func TestIntegration_ExpiredTokenTriggersRefresh(t *testing.T) {
 	// 建立過期的 token
-	expiredTime := time.Now().Add(-1 * time.Hour).Format(time.RFC3339)
+	expiredTime := time.Now().Add(-1 * time.Hour)
 	token := &awssso.KiroAuthToken{
 		AccessToken:  "expired-access-token",
 		ExpiresAt:    expiredTime,
@@ -85,7 +90,7 @@ func TestIntegration_TokenUpdateAndPersistence(t *testing.T) {
 	}
 
 	// 模擬更新 token 檔案（使用與 WriteBackupToken 相同的邏輯）
-	err = updateTokenFile(tokenPath, newTokenInfo.AccessToken, newTokenInfo.ExpiresAt.Format(time.RFC3339))
+	err = updateTokenFile(tokenPath, newTokenInfo.AccessToken, newTokenInfo.ExpiresAt)
 	if err != nil {
 		t.Fatalf("Failed to update token file: %v", err)
 	}
@@ -108,9 +113,9 @@ func TestIntegration_TokenUpdateAndPersistence(t *testing.T) {
 	}
 
 	// 驗證 expiresAt 已更新
-	if updatedToken["expiresAt"] != newTokenInfo.ExpiresAt.Format(time.RFC3339) {
+	if want := newTokenInfo.ExpiresAt.UTC().Format(awssso.KiroTimeLayout); updatedToken["expiresAt"] != want {
 		t.Errorf("expiresAt not updated: got %v, expected %v",
-			updatedToken["expiresAt"], newTokenInfo.ExpiresAt.Format(time.RFC3339))
+			updatedToken["expiresAt"], want)
 	}
 
 	// 驗證其他欄位保持不變（需求 3.2）
@@ -128,9 +133,9 @@ func TestIntegration_TokenUpdateAndPersistence(t *testing.T) {
 	}
 
 	// 驗證更新後的 token 未過期
-	updatedKiroToken := &awssso.KiroAuthToken{
-		AccessToken: updatedToken["accessToken"].(string),
-		ExpiresAt:   updatedToken["expiresAt"].(string),
+	updatedKiroToken, err := awssso.ParseKiroAuthToken(updatedData)
+	if err != nil {
+		t.Fatalf("Failed to parse updated token: %v", err)
 	}
 	if awssso.IsTokenExpired(updatedKiroToken) {
 		t.Error("Updated token should not be expired")
@@ -140,25 +145,24 @@ func TestIntegration_TokenUpdateAndPersistence(t *testing.T) {
 }
 
 // updateTokenFile 更新 token 檔案（模擬 WriteBackupToken 的邏輯）
-func updateTokenFile(tokenPath string, accessToken string, expiresAt string) error {
+func updateTokenFile(tokenPath string, accessToken string, expiresAt time.Time) error {
 	// 讀取現有 token 檔案
 	data, err := os.ReadFile(tokenPath)
 	if err != nil {
 		return err
 	}
 
-	// 使用 map 來保留所有原始欄位
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
+	token, err := awssso.ParseKiroAuthToken(data)
+	if err != nil {
 		return err
 	}
 
 	// 僅更新 accessToken 和 expiresAt 欄位
-	tokenMap["accessToken"] = accessToken
-	tokenMap["expiresAt"] = expiresAt
+	token.AccessToken = accessToken
+	token.ExpiresAt = expiresAt
 
 	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(tokenMap, "", "  ")
+	updatedData, err := token.Marshal()
 	if err != nil {
 		return err
 	}
@@ -166,7 +170,6 @@ func updateTokenFile(tokenPath string, accessToken string, expiresAt string) err
 	return os.WriteFile(tokenPath, updatedData, 0644)
 }
 
-
 // TestIntegration_RefreshFlowContinuesWithBalanceQuery 測試刷新後餘額查詢繼續執行
 // 需求 1.4: WHEN the token refresh succeeds, THE system SHALL proceed with the original balance query operation
 func TestIntegration_RefreshFlowContinuesWithBalanceQuery(t *testing.T) {
@@ -207,14 +210,9 @@ func TestIntegration_RefreshFlowContinuesWithBalanceQuery(t *testing.T) {
 
 	// 讀取 token 並驗證過期
 	readData, _ := os.ReadFile(tokenPath)
-	var tokenMap map[string]interface{}
-	json.Unmarshal(readData, &tokenMap)
-
-	kiroToken := &awssso.KiroAuthToken{
-		AccessToken:  tokenMap["accessToken"].(string),
-		ExpiresAt:    tokenMap["expiresAt"].(string),
-		RefreshToken: tokenMap["refreshToken"].(string),
-		AuthMethod:   tokenMap["authMethod"].(string),
+	kiroToken, err := awssso.ParseKiroAuthToken(readData)
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
 	}
 
 	// 步驟 1 驗證: Token 應該已過期
@@ -232,7 +230,7 @@ func TestIntegration_RefreshFlowContinuesWithBalanceQuery(t *testing.T) {
 
 	// 步驟 3 & 4: 模擬刷新成功並更新 token
 	newAccessToken := "new-refreshed-access-token"
-	newExpiresAt := time.Now().Add(1 * time.Hour).Format(time.RFC3339)
+	newExpiresAt := time.Now().Add(1 * time.Hour)
 
 	err = updateTokenFile(tokenPath, newAccessToken, newExpiresAt)
 	if err != nil {
@@ -242,12 +240,9 @@ func TestIntegration_RefreshFlowContinuesWithBalanceQuery(t *testing.T) {
 
 	// 步驟 5: 驗證更新後的 token 可用於後續操作
 	updatedData, _ := os.ReadFile(tokenPath)
-	var updatedTokenMap map[string]interface{}
-	json.Unmarshal(updatedData, &updatedTokenMap)
-
-	updatedKiroToken := &awssso.KiroAuthToken{
-		AccessToken: updatedTokenMap["accessToken"].(string),
-		ExpiresAt:   updatedTokenMap["expiresAt"].(string),
+	updatedKiroToken, err := awssso.ParseKiroAuthToken(updatedData)
+	if err != nil {
+		t.Fatalf("Step 5 failed: Could not parse updated token: %v", err)
 	}
 
 	// 驗證更新後的 token 未過期
@@ -320,8 +315,8 @@ func TestIntegration_RefreshErrorHandling(t *testing.T) {
 
 				if tc.errorContains != "" {
 					if refreshErr, ok := err.(*RefreshError); ok {
-						if refreshErr.Message != tc.errorContains && 
-						   !containsString(refreshErr.Message, tc.errorContains) {
+						if refreshErr.Message != tc.errorContains &&
+							!containsString(refreshErr.Message, tc.errorContains) {
 							t.Errorf("Error message %q does not contain %q",
 								refreshErr.Message, tc.errorContains)
 						}
@@ -444,3 +439,78 @@ func TestIntegration_ExpiresAtCalculationInRefreshFlow(t *testing.T) {
 		})
 	}
 }
+
+// TestIntegration_RefreshThenUsageOffline 以本機 stub 伺服器跑完整的刷新 → 用量查詢流程
+func TestIntegration_RefreshThenUsageOffline(t *testing.T) {
+	const profileArn = "arn:aws:codewhisperer:us-east-1:123456789012:profile/TEST"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/refreshToken", func(w http.ResponseWriter, r *http.Request) {
+		var req SocialRefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken != "old-refresh-token" {
+			http.Error(w, "bad refresh token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(SocialRefreshResponse{
+			AccessToken: "new-access-token",
+			ExpiresIn:   3600,
+			ProfileArn:  profileArn,
+		})
+	})
+	mux.HandleFunc("/getUsageLimits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-access-token" {
+			http.Error(w, "unauthorized", http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("profileArn") != profileArn {
+			http.Error(w, "missing profileArn", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"subscriptionInfo":{"subscriptionTitle":"KIRO PRO"},"usageBreakdownList":[{"usageLimitWithPrecision":1000,"currentUsageWithPrecision":250}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fixed := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return fixed }
+
+	refresher := &Client{
+		SocialRefreshURL: server.URL + "/refreshToken",
+		Options:          httpclient.Options{Transport: server.Client().Transport, Now: clock},
+	}
+	usageClient := &usage.Client{
+		BaseURL: server.URL,
+		Options: httpclient.Options{Transport: server.Client().Transport, Now: clock},
+	}
+
+	token := &awssso.KiroAuthToken{
+		AccessToken:  "expired-access-token",
+		ExpiresAt:    fixed.Add(-time.Hour),
+		RefreshToken: "old-refresh-token",
+		AuthMethod:   "social",
+		Provider:     "Github",
+	}
+
+	ctx := context.Background()
+	info, err := refresher.RefreshAccessToken(ctx, token, "test-machine-id")
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if want := fixed.Add(time.Hour); !info.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", info.ExpiresAt, want)
+	}
+
+	token.AccessToken = info.AccessToken
+	token.ProfileArn = info.ProfileArn
+
+	usageInfo, err := usageClient.GetUsageLimitsWithMachineID(ctx, token, "test-machine-id")
+	if err != nil {
+		t.Fatalf("usage query failed: %v", err)
+	}
+	if usageInfo.Balance != 750 || usageInfo.SubscriptionTitle != "KIRO PRO" {
+		t.Errorf("unexpected usage info: %+v", usageInfo)
+	}
+	if !usageInfo.FetchedAt.Equal(fixed) {
+		t.Errorf("FetchedAt = %v, want %v", usageInfo.FetchedAt, fixed)
+	}
+}
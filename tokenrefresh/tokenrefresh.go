@@ -2,6 +2,7 @@ package tokenrefresh
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
 	"kiro-manager/kiroversion"
 	"kiro-manager/settings"
 )
@@ -46,12 +48,16 @@ type TokenInfo struct {
 type RefreshError struct {
 	Code    int    // HTTP 狀態碼（0 表示非 HTTP 錯誤）
 	Message string // 使用者友善的錯誤訊息
+	Detail  string // 補充說明（未分類 HTTP 錯誤的狀態碼與回應內容），附加在 Error() 之後
 	Cause   error  // 底層錯誤（用於除錯）
 }
 
 // Error 實作 error 介面
 func (e *RefreshError) Error() string {
-	return e.Message
+	if e.Detail == "" {
+		return e.Message
+	}
+	return e.Message + " " + e.Detail
 }
 
 // Unwrap 支援 errors.Unwrap
@@ -72,7 +78,6 @@ type SocialRefreshResponse struct {
 	ProfileArn   string `json:"profileArn"`
 }
 
-
 // IdCRefreshRequest IdC 刷新請求
 // 注意：AWS IdC OIDC API 使用 camelCase 欄位名稱
 type IdCRefreshRequest struct {
@@ -102,7 +107,7 @@ func CalculateExpiresAt(expiresIn int) time.Time {
 // 將 expiresIn 秒數加到當前時間，並將結果格式化為 "2006-01-02T15:04:05.000Z" 格式
 // 需求: 5.3
 func CalculateExpiresAtString(expiresIn int) string {
-	return CalculateExpiresAt(expiresIn).UTC().Format("2006-01-02T15:04:05.000Z")
+	return CalculateExpiresAt(expiresIn).UTC().Format(awssso.KiroTimeLayout)
 }
 
 // MapHTTPError 將 HTTP 狀態碼映射為使用者友善的錯誤訊息
@@ -110,8 +115,10 @@ func CalculateExpiresAtString(expiresIn int) string {
 // - HTTP 401/403 映射為「Token 已失效，請重新登入 Kiro」
 // - HTTP 429 映射為「請求過於頻繁，請稍後再試」
 // - HTTP 5xx 映射為「伺服器暫時無法使用，請稍後再試」
+// - 其他狀態碼為「Token 刷新失敗」，Error() 另附 HTTP 狀態碼與截斷後的回應內容
+// 狀態碼與回應內容保留在 Cause（*httpclient.StatusError），可用 errors.Is 比對 httpclient.ErrUnauthorized 等錯誤
 func MapHTTPError(statusCode int, body string) *RefreshError {
-	var message string
+	var message, detail string
 	switch {
 	case statusCode == 401 || statusCode == 403:
 		message = "Token 已失效，請重新登入 Kiro"
@@ -120,12 +127,15 @@ func MapHTTPError(statusCode int, body string) *RefreshError {
 	case statusCode >= 500 && statusCode < 600:
 		message = "伺服器暫時無法使用，請稍後再試"
 	default:
-		// 包含 HTTP 狀態碼和回應內容以便除錯
-		message = fmt.Sprintf("Token 刷新失敗 (HTTP %d): %s", statusCode, truncateString(body, 200))
+		// 包含 HTTP 狀態碼和回應內容以便除錯（例如 400 invalid_grant）
+		message = "Token 刷新失敗"
+		detail = fmt.Sprintf("(HTTP %d): %s", statusCode, truncateString(body, 200))
 	}
 	return &RefreshError{
 		Code:    statusCode,
 		Message: message,
+		Detail:  detail,
+		Cause:   &httpclient.StatusError{StatusCode: statusCode, Body: body},
 	}
 }
 
@@ -137,10 +147,15 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
+// RefreshSocialToken 使用預設客戶端以 Social 認證方式刷新 Token
+func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, error) {
+	return DefaultClient.RefreshSocialToken(context.Background(), refreshToken, machineId)
+}
+
 // RefreshSocialToken 使用 Social 認證方式刷新 Token
 // 發送 POST 請求到 Social 刷新端點，解析回應並返回新的 Token 資訊
 // machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
-func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, error) {
+func (c *Client) RefreshSocialToken(ctx context.Context, refreshToken string, machineId string) (*TokenInfo, error) {
 	// 驗證參數
 	if machineId == "" {
 		return nil, &RefreshError{
@@ -163,7 +178,7 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 	}
 
 	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", SocialRefreshURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.SocialRefreshURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -181,8 +196,7 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 	req.Header.Set("Sec-Fetch-Mode", "cors")
 
 	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.DoRequest(c.HTTPClient(defaultTimeout), c.RetryPolicy(), req)
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -204,7 +218,9 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 
 	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
 	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
+		refreshErr := MapHTTPError(resp.StatusCode, string(body))
+		refreshErr.Cause = httpclient.NewStatusError(resp, body)
+		return nil, refreshErr
 	}
 
 	// 解析 JSON 回應
@@ -221,7 +237,7 @@ func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, erro
 	return &TokenInfo{
 		AccessToken: socialResp.AccessToken,
 		ExpiresIn:   socialResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(socialResp.ExpiresIn),
+		ExpiresAt:   c.expiresAt(socialResp.ExpiresIn),
 		ProfileArn:  socialResp.ProfileArn,
 	}, nil
 }
@@ -246,11 +262,15 @@ func ParseSocialResponse(jsonData []byte) (*TokenInfo, error) {
 	}, nil
 }
 
+// RefreshIdCToken 使用預設客戶端以 IdC 認證方式刷新 Token
+func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return DefaultClient.RefreshIdCToken(context.Background(), refreshToken, clientID, clientSecret)
+}
 
 // RefreshIdCToken 使用 IdC 認證方式刷新 Token
 // 發送 POST 請求到 IdC 刷新端點，包含必要的 Headers
 // 需求: 2.2, 2.3, 5.2, 5.3
-func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+func (c *Client) RefreshIdCToken(ctx context.Context, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
 	// 建立請求 body
 	reqBody := IdCRefreshRequest{
 		ClientID:     clientID,
@@ -268,7 +288,7 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 	}
 
 	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", IdCRefreshURL, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.IdCRefreshURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -286,8 +306,7 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 	req.Header.Set("Connection", "keep-alive")
 
 	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.DoRequest(c.HTTPClient(defaultTimeout), c.RetryPolicy(), req)
 	if err != nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -309,7 +328,9 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 
 	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
 	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
+		refreshErr := MapHTTPError(resp.StatusCode, string(body))
+		refreshErr.Cause = httpclient.NewStatusError(resp, body)
+		return nil, refreshErr
 	}
 
 	// 解析 JSON 回應
@@ -326,7 +347,7 @@ func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, e
 	return &TokenInfo{
 		AccessToken: idcResp.AccessToken,
 		ExpiresIn:   idcResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(idcResp.ExpiresIn),
+		ExpiresAt:   c.expiresAt(idcResp.ExpiresIn),
 		TokenType:   idcResp.TokenType,
 	}, nil
 }
@@ -351,7 +372,6 @@ func ParseIdCResponse(jsonData []byte) (*TokenInfo, error) {
 	}, nil
 }
 
-
 // RefreshAccessToken 刷新 AccessToken
 // 根據 token 中的 AuthMethod 判斷使用 Social 或 IdC 刷新方式
 // machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
@@ -367,10 +387,15 @@ func RefreshAccessTokenFromBackup(token *awssso.KiroAuthToken, machineId string,
 	return RefreshAccessTokenWithCredentials(token, machineId, clientID, clientSecret)
 }
 
+// RefreshAccessTokenWithCredentials 使用預設客戶端刷新 AccessToken
+func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
+	return DefaultClient.RefreshAccessTokenWithCredentials(context.Background(), token, machineId, clientID, clientSecret)
+}
+
 // RefreshAccessTokenWithCredentials 刷新 AccessToken（內部實作）
 // 如果提供了 clientID 和 clientSecret，IdC 認證時會直接使用
 // 否則會從 SSO cache 讀取
-func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
+func (c *Client) RefreshAccessTokenWithCredentials(ctx context.Context, token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
 	if token == nil {
 		return nil, &RefreshError{
 			Code:    0,
@@ -397,7 +422,7 @@ func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId st
 				Message: "RefreshToken 不可為空",
 			}
 		}
-		return RefreshSocialToken(token.RefreshToken, machineId)
+		return c.RefreshSocialToken(ctx, token.RefreshToken, machineId)
 
 	case "idc":
 		// IdC 認證路由到 RefreshIdCToken
@@ -415,7 +440,7 @@ func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId st
 				return nil, err
 			}
 		}
-		return RefreshIdCToken(token.RefreshToken, clientID, clientSecret)
+		return c.RefreshIdCToken(ctx, token.RefreshToken, clientID, clientSecret)
 
 	default:
 		return nil, &RefreshError{
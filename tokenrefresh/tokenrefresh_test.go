@@ -2,6 +2,7 @@ package tokenrefresh
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"math/rand"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
 )
 
 // generateRandomString 生成指定長度的隨機字串
@@ -222,7 +224,6 @@ func TestParseSocialResponse_EmptyFields(t *testing.T) {
 	}
 }
 
-
 // generateRandomIdCResponse 生成隨機的 IdC 刷新回應
 func generateRandomIdCResponse(r *rand.Rand) IdCRefreshResponse {
 	tokenTypes := []string{"Bearer", "bearer", "JWT"}
@@ -349,7 +350,6 @@ func TestParseIdCResponse_EmptyFields(t *testing.T) {
 	}
 }
 
-
 // **Feature: token-refresh, Property 3: Authentication Type Routing**
 // *For any* KiroAuthToken, the refresh function SHALL route to Social refresh
 // when AuthMethod is "social" and to IdC refresh when AuthMethod is "idc"
@@ -673,7 +673,6 @@ func TestRefreshAccessToken_UnknownAuthType(t *testing.T) {
 	}
 }
 
-
 // **Feature: token-refresh, Property 4: HTTP Error Code Mapping**
 // *For any* HTTP error response with status code C, the returned RefreshError
 // SHALL have Code equal to C and an appropriate user-friendly Message based
@@ -857,3 +856,44 @@ func TestMapHTTPError_5xxRange(t *testing.T) {
 		}
 	}
 }
+
+// TestMapHTTPError_SentinelErrors 測試錯誤可用 errors.Is 比對分類
+func TestMapHTTPError_SentinelErrors(t *testing.T) {
+	testCases := []struct {
+		statusCode int
+		want       error
+	}{
+		{401, httpclient.ErrUnauthorized},
+		{403, httpclient.ErrUnauthorized},
+		{429, httpclient.ErrRateLimited},
+		{503, httpclient.ErrServerUnavailable},
+	}
+
+	for _, tc := range testCases {
+		var err error = MapHTTPError(tc.statusCode, "body")
+		if !errors.Is(err, tc.want) {
+			t.Errorf("HTTP %d: expected errors.Is(%v)", tc.statusCode, tc.want)
+		}
+	}
+
+	if err := error(MapHTTPError(400, "bad request")); errors.Is(err, httpclient.ErrUnauthorized) || errors.Is(err, httpclient.ErrServerUnavailable) {
+		t.Errorf("HTTP 400 should not match a retryable or auth sentinel")
+	}
+}
+
+// TestMapHTTPError_DetailInError 測試未分類的 HTTP 錯誤在 Error() 中附上狀態碼與截斷後的回應內容
+func TestMapHTTPError_DetailInError(t *testing.T) {
+	err := MapHTTPError(400, `{"error":"invalid_grant"}`)
+	if got, want := err.Error(), `Token 刷新失敗 (HTTP 400): {"error":"invalid_grant"}`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	long := MapHTTPError(400, strings.Repeat("x", 300))
+	if got := long.Error(); !strings.HasSuffix(got, strings.Repeat("x", 200)+"...") || strings.Contains(got, strings.Repeat("x", 201)) {
+		t.Errorf("body should be truncated to 200 bytes, got %q", got)
+	}
+
+	if got := MapHTTPError(401, "denied").Error(); got != "Token 已失效，請重新登入 Kiro" {
+		t.Errorf("classified errors should not include the body, got %q", got)
+	}
+}
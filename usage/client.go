@@ -0,0 +1,29 @@
+package usage
+
+import (
+	"time"
+
+	"kiro-manager/httpclient"
+)
+
+// HTTP 請求超時設定
+const httpTimeout = 10 * time.Second
+
+// Client 用量查詢客戶端
+// 查詢時間 FetchedAt 以 Options.Now 記錄，測試可固定時鐘比對快取內容
+type Client struct {
+	// BaseURL API 端點（不含路徑）
+	BaseURL string
+	httpclient.Options
+}
+
+// DefaultClient 套件函數使用的預設客戶端
+var DefaultClient = NewClient()
+
+// NewClient 建立使用正式端點與全域連線設定的客戶端
+func NewClient() *Client {
+	return &Client{
+		BaseURL: DefaultBaseURL,
+		Options: httpclient.DefaultOptions(),
+	}
+}
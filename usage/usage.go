@@ -1,27 +1,30 @@
 package usage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
 	"kiro-manager/kiroversion"
+	"kiro-manager/logging"
 	"kiro-manager/machineid"
 	"kiro-manager/settings"
 )
 
-// HTTP 請求超時設定
-const httpTimeout = 10 * time.Second
-
 const (
-	// API endpoint
-	usageLimitsURL = "https://q.us-east-1.amazonaws.com/getUsageLimits"
+	// DefaultBaseURL API 端點
+	DefaultBaseURL = "https://q.us-east-1.amazonaws.com"
+	// getUsageLimits 路徑
+	usageLimitsPath = "/getUsageLimits"
 	// Query parameters
 	originParam       = "AI_EDITOR"
 	resourceTypeParam = "AGENTIC_REQUEST"
@@ -76,13 +79,44 @@ type UsageBreakdown struct {
 	Bonuses                   []Bonus        `json:"bonuses"`
 }
 
+// 額度類別
+const (
+	CategoryBase      = "base"      // 訂閱方案的基本額度
+	CategoryFreeTrial = "freeTrial" // 免費試用額度
+	CategoryBonus     = "bonus"     // 獎勵額度
+)
+
+// BreakdownItem 單一額度來源的用量
+type BreakdownItem struct {
+	Category     string  `json:"category"`     // 額度類別（base / freeTrial / bonus）
+	DisplayName  string  `json:"displayName"`  // 所屬用量明細的名稱
+	BonusCode    string  `json:"bonusCode"`    // 獎勵代碼（僅 bonus）
+	Status       string  `json:"status"`       // 免費試用或獎勵的狀態（FreeTrialStatus / Status）
+	UsageLimit   float64 `json:"usageLimit"`   // 額度
+	CurrentUsage float64 `json:"currentUsage"` // 已使用
+	Balance      float64 `json:"balance"`      // 餘額 = UsageLimit - CurrentUsage
+}
+
 // UsageInfo 計算後的用量資訊
 type UsageInfo struct {
-	SubscriptionTitle string  // 訂閱類型名稱
-	UsageLimit        float64 // 總額度
-	CurrentUsage      float64 // 已使用
-	Balance           float64 // 餘額 = UsageLimit - CurrentUsage
-	IsLowBalance      bool    // 餘額低於 20%
+	SubscriptionTitle string          // 訂閱類型名稱
+	UsageLimit        float64         // 總額度
+	CurrentUsage      float64         // 已使用
+	Balance           float64         // 餘額 = UsageLimit - CurrentUsage
+	IsLowBalance      bool            // 餘額低於 20%
+	Breakdown         []BreakdownItem // 各額度來源的明細（加總即為 UsageLimit / CurrentUsage）
+	FetchedAt         time.Time       // 查詢時間（僅 API 查詢結果）
+}
+
+// newBreakdownItem 建立額度明細
+func newBreakdownItem(category, displayName string, usageLimit, currentUsage float64) BreakdownItem {
+	return BreakdownItem{
+		Category:     category,
+		DisplayName:  displayName,
+		UsageLimit:   usageLimit,
+		CurrentUsage: currentUsage,
+		Balance:      usageLimit - currentUsage,
+	}
 }
 
 // CalculateBalance 從 API 響應計算餘額（使用預設閾值 0.2）
@@ -104,25 +138,35 @@ func CalculateBalanceWithThreshold(response *UsageLimitsResponse, threshold floa
 
 	var totalUsageLimit float64
 	var totalCurrentUsage float64
+	items := []BreakdownItem{}
 
 	for _, breakdown := range response.UsageBreakdownList {
 		// 基本額度
-		totalUsageLimit += breakdown.UsageLimitWithPrecision
-		totalCurrentUsage += breakdown.CurrentUsageWithPrecision
+		items = append(items, newBreakdownItem(CategoryBase, breakdown.DisplayName,
+			breakdown.UsageLimitWithPrecision, breakdown.CurrentUsageWithPrecision))
 
 		// 免費試用額度（如果存在）
 		if breakdown.FreeTrialInfo != nil {
-			totalUsageLimit += breakdown.FreeTrialInfo.UsageLimitWithPrecision
-			totalCurrentUsage += breakdown.FreeTrialInfo.CurrentUsageWithPrecision
+			item := newBreakdownItem(CategoryFreeTrial, breakdown.DisplayName,
+				breakdown.FreeTrialInfo.UsageLimitWithPrecision, breakdown.FreeTrialInfo.CurrentUsageWithPrecision)
+			item.Status = breakdown.FreeTrialInfo.FreeTrialStatus
+			items = append(items, item)
 		}
 
 		// 獎勵額度（如果存在）
 		for _, bonus := range breakdown.Bonuses {
-			totalUsageLimit += bonus.UsageLimit
-			totalCurrentUsage += bonus.CurrentUsage
+			item := newBreakdownItem(CategoryBonus, breakdown.DisplayName, bonus.UsageLimit, bonus.CurrentUsage)
+			item.BonusCode = bonus.BonusCode
+			item.Status = bonus.Status
+			items = append(items, item)
 		}
 	}
 
+	for _, item := range items {
+		totalUsageLimit += item.UsageLimit
+		totalCurrentUsage += item.CurrentUsage
+	}
+
 	balance := totalUsageLimit - totalCurrentUsage
 
 	// Property 2: Low Balance Detection
@@ -139,6 +183,7 @@ func CalculateBalanceWithThreshold(response *UsageLimitsResponse, threshold floa
 		CurrentUsage:      totalCurrentUsage,
 		Balance:           balance,
 		IsLowBalance:      isLowBalance,
+		Breakdown:         items,
 	}
 }
 
@@ -152,13 +197,19 @@ func GetUsageLimits(token *awssso.KiroAuthToken) (*UsageInfo, error) {
 	return GetUsageLimitsWithMachineID(token, machineID)
 }
 
+// GetUsageLimitsWithMachineID 使用預設客戶端呼叫 API 取得用量資訊（使用指定的 Machine ID）
+// machineID 應為 SHA256 雜湊後的值
+func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string) (*UsageInfo, error) {
+	return DefaultClient.GetUsageLimitsWithMachineID(context.Background(), token, machineID)
+}
+
 // GetUsageLimitsWithMachineID 呼叫 API 取得用量資訊（使用指定的 Machine ID）
 // machineID 應為 SHA256 雜湊後的值
 // Requirements: 2.1, 2.2, 2.3
 // 支援兩種認證類型：
 // - social (GitHub/Google): 需要 profileArn 作為 query parameter
 // - idc (AWS Identity Center): 不需要 profileArn
-func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string) (*UsageInfo, error) {
+func (c *Client) GetUsageLimitsWithMachineID(ctx context.Context, token *awssso.KiroAuthToken, machineID string) (*UsageInfo, error) {
 	if token == nil || token.AccessToken == "" {
 		return nil, fmt.Errorf("invalid token: missing accessToken")
 	}
@@ -174,7 +225,7 @@ func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string)
 
 	// 建構 API URL with query parameters
 	// Requirements: 2.2 - social 類型使用 profileArn 作為 query parameter
-	apiURL, err := url.Parse(usageLimitsURL)
+	apiURL, err := url.Parse(strings.TrimRight(c.BaseURL, "/") + usageLimitsPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
 	}
@@ -189,7 +240,7 @@ func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string)
 	apiURL.RawQuery = query.Encode()
 
 	// 建立 HTTP 請求
-	req, err := http.NewRequest(http.MethodGet, apiURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -210,34 +261,33 @@ func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string)
 	xAmzUserAgent := fmt.Sprintf("aws-sdk-js/1.0.0 KiroIDE-%s-%s", kiroVersion, machineID)
 	req.Header.Set("x-amz-user-agent", xAmzUserAgent)
 
-	// DEBUG: 顯示 User-Agent headers
-	fmt.Printf("[DEBUG] User-Agent: %s\n", userAgent)
-	fmt.Printf("[DEBUG] x-amz-user-agent: %s\n", xAmzUserAgent)
+	// 不記錄完整的 User-Agent（包含 Machine ID 雜湊）
+	logging.Debug("requesting usage limits", "kiroVersion", kiroVersion, "authMethod", token.AuthMethod)
 
 	// amz-sdk-invocation-id: 每次請求隨機生成 UUID
 	req.Header.Set("amz-sdk-invocation-id", uuid.New().String())
 
-	// amz-sdk-request header
-	req.Header.Set("amz-sdk-request", "attempt=1; max=1")
-
 	// Connection header
 	req.Header.Set("Connection", "close")
 
-	// 發送 HTTP GET 請求
+	// 發送 HTTP GET 請求（429/5xx 與網路錯誤依重試策略重試）
 	// Requirements: 1.4 - 設定超時以避免長時間等待
-	client := &http.Client{
-		Timeout: httpTimeout,
-	}
-	resp, err := client.Do(req)
+	policy := c.RetryPolicy()
+	resp, err := httpclient.Do(ctx, c.HTTPClient(httpTimeout), policy, func(attempt int) (*http.Request, error) {
+		r := req.Clone(ctx)
+		// amz-sdk-request header：與 AWS SDK 相同，標示目前的嘗試次數
+		r.Header.Set("amz-sdk-request", fmt.Sprintf("attempt=%d; max=%d", attempt, policy.MaxAttempts))
+		return r, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// 檢查 HTTP 狀態碼
+	// 檢查 HTTP 狀態碼（可用 errors.Is 比對 httpclient.ErrUnauthorized、ErrRateLimited、ErrServerUnavailable）
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API request failed: %w", httpclient.NewStatusError(resp, body))
 	}
 
 	// 解析 JSON 響應
@@ -252,7 +302,9 @@ func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string)
 	}
 
 	// 計算餘額並返回 UsageInfo
-	return CalculateBalance(&response), nil
+	info := CalculateBalance(&response)
+	info.FetchedAt = c.CurrentTime()
+	return info, nil
 }
 
 // GetUsageLimitsSafe 安全地呼叫 API 取得用量資訊（使用當前系統 Machine ID）
@@ -284,14 +336,14 @@ func GetUsageLimitsSafe(token *awssso.KiroAuthToken) *UsageInfo {
 // 當發生任何錯誤時，返回空的 UsageInfo 而非 panic
 func GetUsageLimitsSafeWithMachineID(token *awssso.KiroAuthToken, machineID string) *UsageInfo {
 	if token == nil || machineID == "" {
-		fmt.Printf("[DEBUG] GetUsageLimitsSafeWithMachineID: token=%v, machineID=%s\n", token != nil, machineID)
+		logging.Debug("skip usage request: missing token or machine id", "hasToken", token != nil, "hasMachineID", machineID != "")
 		return &UsageInfo{}
 	}
 
 	info, err := GetUsageLimitsWithMachineID(token, machineID)
 	if err != nil {
 		// 錯誤時返回空的 UsageInfo，不 panic
-		fmt.Printf("[DEBUG] GetUsageLimitsWithMachineID error: %v\n", err)
+		logging.Warn("failed to get usage limits", "err", err)
 		return &UsageInfo{}
 	}
 
@@ -299,6 +351,6 @@ func GetUsageLimitsSafeWithMachineID(token *awssso.KiroAuthToken, machineID stri
 		return &UsageInfo{}
 	}
 
-	fmt.Printf("[DEBUG] GetUsageLimitsWithMachineID success: %+v\n", info)
+	logging.Debug("got usage limits", "subscription", info.SubscriptionTitle, "balance", info.Balance)
 	return info
 }
@@ -1,15 +1,29 @@
 package usage
 
 import (
+	"context"
+	"errors"
 	"math"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/httpclient"
 )
 
+// TestMain 停用全域重試，避免會呼叫真實 API 的測試因重試等待而變慢
+func TestMain(m *testing.M) {
+	httpclient.Configure(httpclient.Config{Retry: httpclient.RetryPolicy{MaxAttempts: 1}})
+	os.Exit(m.Run())
+}
+
 // generateUsageBreakdownList 生成隨機的 UsageBreakdown 列表
 // 包含基本額度、免費試用額度和獎勵額度
 func generateUsageBreakdownList(rand *rand.Rand, size int) []UsageBreakdown {
@@ -206,6 +220,40 @@ func TestCalculateBalance_EmptyList(t *testing.T) {
 	}
 }
 
+// TestCalculateBalance_Breakdown 測試各額度來源的明細會保留下來
+func TestCalculateBalance_Breakdown(t *testing.T) {
+	response := &UsageLimitsResponse{
+		SubscriptionInfo: SubscriptionInfo{SubscriptionTitle: "KIRO FREE"},
+		UsageBreakdownList: []UsageBreakdown{{
+			DisplayName:               "Credits",
+			UsageLimitWithPrecision:   50,
+			CurrentUsageWithPrecision: 10,
+			FreeTrialInfo: &FreeTrialInfo{
+				UsageLimitWithPrecision:   500,
+				CurrentUsageWithPrecision: 480,
+				FreeTrialStatus:           "ACTIVE",
+			},
+			Bonuses: []Bonus{
+				{BonusCode: "WELCOME", UsageLimit: 100, CurrentUsage: 100, Status: "EXHAUSTED"},
+			},
+		}},
+	}
+
+	result := CalculateBalance(response)
+
+	want := []BreakdownItem{
+		{Category: CategoryBase, DisplayName: "Credits", UsageLimit: 50, CurrentUsage: 10, Balance: 40},
+		{Category: CategoryFreeTrial, DisplayName: "Credits", Status: "ACTIVE", UsageLimit: 500, CurrentUsage: 480, Balance: 20},
+		{Category: CategoryBonus, DisplayName: "Credits", BonusCode: "WELCOME", Status: "EXHAUSTED", UsageLimit: 100, CurrentUsage: 100, Balance: 0},
+	}
+	if !reflect.DeepEqual(result.Breakdown, want) {
+		t.Errorf("Breakdown = %+v, want %+v", result.Breakdown, want)
+	}
+	if result.UsageLimit != 650 || result.CurrentUsage != 590 || result.Balance != 60 {
+		t.Errorf("totals should equal the sum of the breakdown, got %+v", result)
+	}
+}
+
 // **Feature: backup-usage-display, Property 4: Error Handling Graceful Degradation**
 // *For any* API request that fails or returns an error, the system SHALL return
 // empty/default values without crashing.
@@ -307,3 +355,41 @@ func generateRandomString(rand *rand.Rand, length int) string {
 	}
 	return string(result)
 }
+
+// TestClient_RetryAndTypedErrors 測試 429 會重試，且錯誤可用 errors.Is 分類
+func TestClient_RetryAndTypedErrors(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		BaseURL: server.URL,
+		Options: httpclient.Options{
+			Transport: server.Client().Transport,
+			Retry:     &httpclient.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		},
+	}
+	token := &awssso.KiroAuthToken{AccessToken: "token", AuthMethod: "IdC"}
+
+	_, err := client.GetUsageLimitsWithMachineID(context.Background(), token, "machine")
+	if !errors.Is(err, httpclient.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+
+	calls.Store(0)
+	status = http.StatusUnauthorized
+	_, err = client.GetUsageLimitsWithMachineID(context.Background(), token, "machine")
+	if !errors.Is(err, httpclient.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("401 should not be retried, got %d attempts", calls.Load())
+	}
+}